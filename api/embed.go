@@ -4,3 +4,8 @@ import _ "embed"
 
 //go:embed api.yaml
 var SwaggerConfig []byte
+
+// GatewaySwaggerConfig is the OpenAPI document of the grpc_gateway server
+//
+//go:embed api.swagger.json
+var GatewaySwaggerConfig []byte
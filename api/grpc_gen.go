@@ -1,3 +1,4 @@
 package api
 
-//go:generate protoc --go_out=. --go-grpc_out=. api.proto
+// google/api/annotations.proto and google/api/http.proto of googleapis have to be on the include path of protoc
+//go:generate protoc --go_out=. --go-grpc_out=. --grpc-gateway_out=. --openapiv2_out=. api.proto
@@ -0,0 +1,146 @@
+package e2e_tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/aleksandrzhukovskii/go-template/internal/model"
+	pb "github.com/aleksandrzhukovskii/go-template/internal/service/grpc"
+)
+
+type GatewaySuite struct {
+	suite.Suite
+}
+
+type gatewayStatus struct {
+	Code    codes.Code `json:"code"`
+	Message string     `json:"message"`
+}
+
+func (s *GatewaySuite) Test_Main() {
+	var res pb.MainInfo
+	s.Equal(http.StatusOK, s.call(http.MethodGet, "/v1", "", &res))
+	s.Contains(res.Info, "Remote Addr: bufconn")
+}
+
+func (s *GatewaySuite) Test_Product() {
+	var product pb.Product
+	s.Run("Add Product", func() {
+		s.Equal(http.StatusOK, s.call(http.MethodPost, "/v1/products",
+			`{"name": "Gateway Product", "price": {"currencyCode": "USD", "units": "10", "nanos": 500000000}}`,
+			&product))
+		s.NotEmpty(product.Id)
+		s.Equal("Gateway Product", product.Name)
+		s.Equal(int64(10), product.Price.GetUnits())
+		s.Equal(int32(500000000), product.Price.GetNanos())
+		s.Equal(uint64(1), product.Version)
+	})
+
+	s.Run("Get Product", func() {
+		var res pb.Product
+		s.Equal(http.StatusOK, s.call(http.MethodGet, "/v1/products/"+product.Id, "", &res))
+		s.True(proto.Equal(&product, &res))
+	})
+
+	s.Run("Update Product", func() {
+		var res pb.UpdateResponse
+		s.Equal(http.StatusOK, s.call(http.MethodPatch, "/v1/products/"+product.Id,
+			`{"name": "Renamed Product", "version": "1"}`, &res))
+		s.NotEmpty(res.Msg)
+
+		var got pb.Product
+		s.Equal(http.StatusOK, s.call(http.MethodGet, "/v1/products/"+product.Id, "", &got))
+		s.Equal("Renamed Product", got.Name)
+		s.Equal(uint64(2), got.Version)
+	})
+
+	s.Run("Version Conflict", func() {
+		var res gatewayStatus
+		s.Equal(http.StatusBadRequest, s.call(http.MethodPatch, "/v1/products/"+product.Id,
+			`{"name": "Stale Product", "version": "1"}`, &res))
+		s.Equal(codes.FailedPrecondition, res.Code)
+	})
+
+	s.Run("History", func() {
+		var res pb.ProductHistory
+		s.Equal(http.StatusOK, s.call(http.MethodGet, "/v1/products/"+product.Id+"/history", "", &res))
+		s.Len(res.Entries, 2)
+		s.Equal("tester", res.Entries[1].Actor)
+	})
+
+	s.Run("List Products", func() {
+		var res pb.Products
+		s.Equal(http.StatusOK, s.call(http.MethodGet, "/v1/products?nameContains=renamed", "", &res))
+		s.Len(res.Items, 1)
+		s.Equal(product.Id, res.Items[0].Id)
+	})
+
+	s.Run("Invalid Product", func() {
+		var res gatewayStatus
+		s.Equal(http.StatusBadRequest, s.call(http.MethodPost, "/v1/products",
+			`{"name": "", "price": {"currencyCode": "USD", "units": "1"}}`, &res))
+		s.Equal(codes.InvalidArgument, res.Code)
+	})
+
+	s.Run("Delete Product", func() {
+		var res pb.DeleteResponse
+		s.Equal(http.StatusOK, s.call(http.MethodDelete, "/v1/products/"+product.Id, "", &res))
+		s.NotEmpty(res.Msg)
+
+		var missing gatewayStatus
+		s.Equal(http.StatusNotFound, s.call(http.MethodGet, "/v1/products/"+product.Id, "", &missing))
+		s.Equal(codes.NotFound, missing.Code)
+		s.Equal(model.ErrorNotFound.Error(), missing.Message)
+	})
+
+	s.Run("Restore Product", func() {
+		var res pb.RestoreResponse
+		s.Equal(http.StatusOK, s.call(http.MethodPost, "/v1/products/"+product.Id+":restore", "", &res))
+		s.NotEmpty(res.Msg)
+	})
+}
+
+func (s *GatewaySuite) Test_UnknownRoute() {
+	var res gatewayStatus
+	s.Equal(http.StatusNotFound, s.call(http.MethodGet, "/v1/unknown", "", &res))
+	s.Equal(codes.NotFound, res.Code)
+}
+
+func (s *GatewaySuite) Test_SwaggerConfig() {
+	res, err := http.Get("http://app-test:8000/swagger.yaml")
+	s.Require().NoError(err)
+	defer res.Body.Close()
+	s.Equal(http.StatusOK, res.StatusCode)
+	var doc struct {
+		Paths map[string]any `json:"paths"`
+	}
+	s.NoError(json.NewDecoder(res.Body).Decode(&doc))
+	s.Contains(doc.Paths, "/v1/products")
+	s.Contains(doc.Paths, "/v1/products/{id}")
+}
+
+// call sends the JSON body to the gateway as the tester
+func (s *GatewaySuite) call(method string, path string, body string, res any) int {
+	req, err := http.NewRequest(method, "http://app-test:8000"+path, strings.NewReader(body))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(model.ActorHeader, "tester")
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	s.Require().NoError(err)
+	if msg, ok := res.(proto.Message); ok {
+		s.NoError(protojson.Unmarshal(b, msg), string(b))
+	} else {
+		s.NoError(json.Unmarshal(b, res), string(b))
+	}
+	return resp.StatusCode
+}
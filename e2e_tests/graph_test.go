@@ -1,9 +1,11 @@
 package e2e_tests
 
 import (
+	"bufio"
 	"bytes"
-	"database/sql"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -112,6 +114,9 @@ func (s *GraphSuite) Test_Subscription() {
 func (s *GraphSuite) Test_Product() {
 	var productID string
 	var productID2 string
+	var firstPageID string
+	var cursor string
+	var batchID string
 
 	tests := []struct {
 		name  string
@@ -121,13 +126,48 @@ func (s *GraphSuite) Test_Product() {
 	}{
 		{
 			name:  "Add Product",
-			query: `mutation { addProduct { id } }`,
+			query: `mutation($name: String!, $price: Decimal!) { addProduct(product: {name: $name, price: $price, currency: "USD"}) { id name price currency createdAt version } }`,
+			vars:  map[string]any{"name": "New Product", "price": "10.50"},
 			check: func(body io.Reader) {
 				result := s.getMap(body)["data"].(map[string]any)["addProduct"].(map[string]any)
 				s.NotEmpty(result["id"], "Product ID should not be empty")
+				s.Equal("New Product", result["name"], "Stored product name should match")
+				s.Equal("10.5", result["price"], "Stored product price should match")
+				s.Equal("USD", result["currency"], "Stored product currency should match")
+				s.NotEmpty(result["createdAt"], "Stored product create time should be filled")
+				s.Equal(1.0, result["version"], "Stored product version should be 1")
 				productID = result["id"].(string)
 			},
 		},
+		{
+			name:  "Add Product Invalid",
+			query: `mutation { addProduct(product: {name: "", price: "10.5", currency: "USD"}) { id } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Len(result["errors"], 1)
+				s.Contains(result["errors"].([]any)[0].(map[string]any)["message"], model.ErrorInvalidProduct.Error(),
+					"Add should fail")
+			},
+		},
+		{
+			name:  "Add Product Float Price",
+			query: `mutation { addProduct(product: {name: "New Product", price: 10.5, currency: "USD"}) { id } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Len(result["errors"], 1, "Floats should be rejected as prices")
+			},
+		},
+		{
+			name:  "Add Product Already Exists",
+			query: `mutation($id: String) { addProduct(product: {id: $id, name: "New Product", price: "10.5", currency: "USD"}) { id } }`,
+			vars:  map[string]any{"id": &productID},
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Len(result["errors"], 1)
+				s.Equal(model.ErrorAlreadyExists.Error(), result["errors"].([]any)[0].(map[string]any)["message"],
+					"Add should fail")
+			},
+		},
 		{
 			name:  "Update Product Name",
 			query: `mutation($id: String!, $name: String) { updateProduct(id: $id, name: $name) { msg } }`,
@@ -139,13 +179,25 @@ func (s *GraphSuite) Test_Product() {
 		},
 		{
 			name:  "Update Product Price",
-			query: `mutation($id: String!, $price: Float) { updateProduct(id: $id, price: $price) { msg } }`,
-			vars:  map[string]any{"id": &productID, "price": 99.99},
+			query: `mutation($id: String!, $price: Decimal, $version: UInt64) { updateProduct(id: $id, price: $price, currency: "EUR", version: $version) { msg } }`,
+			vars:  map[string]any{"id": &productID, "price": "99.99", "version": 2},
 			check: func(body io.Reader) {
 				result := s.getMap(body)["data"].(map[string]any)["updateProduct"].(map[string]any)
 				s.Equal("Product updated", result["msg"], "Update should be successful")
 			},
 		},
+		{
+			name:  "Update Product Stale Version",
+			query: `mutation($id: String!) { updateProduct(id: $id, price: 1, version: 2) { msg } }`,
+			vars:  map[string]any{"id": &productID},
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Len(result["errors"], 1)
+				gqlErr := result["errors"].([]any)[0].(map[string]any)
+				s.Equal(model.ErrorVersionConflict.Error(), gqlErr["message"], "Update should fail")
+				s.Equal("CONFLICT", gqlErr["extensions"].(map[string]any)["code"])
+			},
+		},
 		{
 			name:  "Update Product Nothing to Update",
 			query: `mutation($id: String!) { updateProduct(id: $id) { msg } }`,
@@ -159,8 +211,8 @@ func (s *GraphSuite) Test_Product() {
 		},
 		{
 			name:  "Update Product Not Exist",
-			query: `mutation($id: String!, $price: Float) { updateProduct(id: $id, price: $price) { msg } }`,
-			vars:  map[string]any{"id": "123", "price": 99.99},
+			query: `mutation($id: String!, $price: Decimal) { updateProduct(id: $id, price: $price) { msg } }`,
+			vars:  map[string]any{"id": "123", "price": "99.99"},
 			check: func(body io.Reader) {
 				result := s.getMap(body)
 				s.Len(result["errors"], 1)
@@ -170,28 +222,52 @@ func (s *GraphSuite) Test_Product() {
 		},
 		{
 			name:  "Get Product",
-			query: `query($id: String!) { getProduct(filter: {id: $id}) { id name price createdAt } }`,
+			query: `query($id: String!) { getProduct(filter: {id: $id}) { id name price currency createdAt version } }`,
 			vars:  map[string]any{"id": &productID},
 			check: func(body io.Reader) {
 				result := s.getMap(body)["data"].(map[string]any)["getProduct"].(map[string]any)
 				s.Equal(productID, result["id"], "Retrieved product ID should match")
 				s.Equal("Updated Product", result["name"], "Retrieved product name should match")
-				s.Equal(99.99, result["price"], "Retrieved product price should match")
+				s.Equal("99.99", result["price"], "Retrieved product price should match")
+				s.Equal("EUR", result["currency"], "Retrieved product currency should match")
 				s.NotEmpty(result["createdAt"], "Retrieved product create time should be filled")
+				s.Equal(3.0, result["version"], "Retrieved product version should match")
+			},
+		},
+		{
+			name:  "Get Product As Of Now",
+			query: `query($id: String!) { getProduct(filter: {id: $id, asOf: 4294967295}) { name version } }`,
+			vars:  map[string]any{"id": &productID},
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["getProduct"].(map[string]any)
+				s.Equal("Updated Product", result["name"], "Latest product name should match")
+				s.Equal(3.0, result["version"], "Latest product version should match")
+			},
+		},
+		{
+			name:  "Get Product As Of Before Creation",
+			query: `query($id: String!) { getProduct(filter: {id: $id, asOf: 1}) { id } }`,
+			vars:  map[string]any{"id": &productID},
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Len(result["errors"], 1)
+				s.Equal(model.ErrorNotFound.Error(), result["errors"].([]any)[0].(map[string]any)["message"])
 			},
 		},
 		{
 			name:  "Get All Products",
-			query: `query { getProducts { id name price createdAt } }`,
+			query: `query { getProducts { edges { cursor node { id name price createdAt } } pageInfo { hasNextPage endCursor } } }`,
 			check: func(body io.Reader) {
-				products := s.getMap(body)["data"].(map[string]any)["getProducts"].([]any)
+				result := s.getMap(body)["data"].(map[string]any)["getProducts"].(map[string]any)
+				products := result["edges"].([]any)
 				s.Len(products, 1)
-				s.Equal(productID, products[0].(map[string]any)["id"])
+				s.Equal(productID, products[0].(map[string]any)["node"].(map[string]any)["id"])
+				s.Equal(false, result["pageInfo"].(map[string]any)["hasNextPage"])
 			},
 		},
 		{
 			name:  "Add Product Fill More",
-			query: `mutation { addProduct { id } }`,
+			query: `mutation { addProduct(sample: true) { id } }`,
 			check: func(body io.Reader) {
 				result := s.getMap(body)["data"].(map[string]any)["addProduct"].(map[string]any)
 				s.NotEmpty(result["id"])
@@ -200,15 +276,216 @@ func (s *GraphSuite) Test_Product() {
 		},
 		{
 			name:  "Get All Products Check 2",
-			query: `query { getProducts { id name price createdAt } }`,
+			query: `query { getProducts { edges { cursor node { id name price createdAt } } pageInfo { hasNextPage endCursor } } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["getProducts"].(map[string]any)
+				s.Len(result["edges"], 2)
+			},
+		},
+		{
+			name:  "Get All Products First Page",
+			query: `query { getProducts(first: 1) { edges { node { id } } pageInfo { hasNextPage endCursor } } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["getProducts"].(map[string]any)
+				products := result["edges"].([]any)
+				s.Len(products, 1)
+				s.Equal(true, result["pageInfo"].(map[string]any)["hasNextPage"])
+				s.NotEmpty(result["pageInfo"].(map[string]any)["endCursor"])
+				firstPageID = products[0].(map[string]any)["node"].(map[string]any)["id"].(string)
+				cursor = result["pageInfo"].(map[string]any)["endCursor"].(string)
+			},
+		},
+		{
+			name:  "Get All Products Second Page",
+			query: `query($after: String) { getProducts(first: 1, after: $after) { edges { node { id } } pageInfo { hasNextPage } } }`,
+			vars:  map[string]any{"after": &cursor},
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["getProducts"].(map[string]any)
+				products := result["edges"].([]any)
+				s.Len(products, 1)
+				s.Equal(false, result["pageInfo"].(map[string]any)["hasNextPage"])
+				s.NotEqual(firstPageID, products[0].(map[string]any)["node"].(map[string]any)["id"])
+			},
+		},
+		{
+			name:  "Get All Products Invalid Cursor",
+			query: `query { getProducts(after: "%%%") { edges { cursor } } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Len(result["errors"], 1)
+				s.Contains(result["errors"].([]any)[0].(map[string]any)["message"], model.ErrorInvalidPage.Error(),
+					"Get all should fail")
+			},
+		},
+		{
+			name:  "Get All Products Filtered",
+			query: `query { getProducts(filter: {nameContains: "UPDATED"}) { edges { node { id } } } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["getProducts"].(map[string]any)
+				products := result["edges"].([]any)
+				s.Len(products, 1)
+				s.Equal(productID, products[0].(map[string]any)["node"].(map[string]any)["id"])
+			},
+		},
+		{
+			name:  "Get All Products Sorted First Page",
+			query: `query { getProducts(first: 1, orderBy: {field: PRICE, direction: DESC}) { edges { node { id price } } pageInfo { hasNextPage endCursor } } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["getProducts"].(map[string]any)
+				products := result["edges"].([]any)
+				s.Len(products, 1)
+				s.Equal(true, result["pageInfo"].(map[string]any)["hasNextPage"])
+				firstPageID = products[0].(map[string]any)["node"].(map[string]any)["id"].(string)
+				cursor = result["pageInfo"].(map[string]any)["endCursor"].(string)
+			},
+		},
+		{
+			name:  "Get All Products Sorted Second Page",
+			query: `query($after: String) { getProducts(first: 1, after: $after, orderBy: {field: PRICE, direction: DESC}) { edges { node { id } } pageInfo { hasNextPage } } }`,
+			vars:  map[string]any{"after": &cursor},
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["getProducts"].(map[string]any)
+				products := result["edges"].([]any)
+				s.Len(products, 1)
+				s.Equal(false, result["pageInfo"].(map[string]any)["hasNextPage"])
+				s.NotEqual(firstPageID, products[0].(map[string]any)["node"].(map[string]any)["id"])
+			},
+		},
+		{
+			name:  "Get All Products Filter By Exact Price",
+			query: `query { getProducts(filter: {minPrice: "99.99", maxPrice: "99.990"}) { edges { node { id } } } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["getProducts"].(map[string]any)
+				products := result["edges"].([]any)
+				s.Len(products, 1)
+				s.Equal(productID, products[0].(map[string]any)["node"].(map[string]any)["id"])
+			},
+		},
+		{
+			name:  "Get All Products Invalid Filter",
+			query: `query { getProducts(filter: {minPrice: 10, maxPrice: 1}) { edges { cursor } } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Len(result["errors"], 1)
+				s.Contains(result["errors"].([]any)[0].(map[string]any)["message"], model.ErrorInvalidQuery.Error(),
+					"Get all should fail")
+			},
+		},
+		{
+			name:  "Search Products",
+			query: `query { searchProducts(query: "upd PROD") { id } }`,
 			check: func(body io.Reader) {
-				products := s.getMap(body)["data"].(map[string]any)["getProducts"].([]any)
-				s.Len(products, 2)
+				result := s.getMap(body)["data"].(map[string]any)["searchProducts"].([]any)
+				s.Require().Len(result, 1, "Result should contain 1 product")
+				s.Equal(productID, result[0].(map[string]any)["id"], "Found product ID should match")
+			},
+		},
+		{
+			name:  "Search Products Middle Of Word",
+			query: `query { searchProducts(query: "pdated") { id } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["searchProducts"].([]any)
+				s.Empty(result, "Terms should only match the beginnings of the words")
+			},
+		},
+		{
+			name:  "Search Products No Words",
+			query: `query { searchProducts(query: "?!") { id } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Len(result["errors"], 1)
+				s.Contains(result["errors"].([]any)[0].(map[string]any)["message"], model.ErrorInvalidQuery.Error(),
+					"Search should fail")
+			},
+		},
+		{
+			name:  "Delete Product Stale Version",
+			query: `mutation($id: String!) { deleteProduct(id: $id, version: 1) { msg } }`,
+			vars:  map[string]any{"id": &productID},
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Len(result["errors"], 1)
+				s.Equal("CONFLICT",
+					result["errors"].([]any)[0].(map[string]any)["extensions"].(map[string]any)["code"])
 			},
 		},
 		{
 			name:  "Delete Product",
-			query: `mutation($id: String!) { deleteProduct(id: $id) { msg } }`,
+			query: `mutation($id: String!, $version: UInt64) { deleteProduct(id: $id, version: $version) { msg } }`,
+			vars:  map[string]any{"id": &productID, "version": 3},
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["deleteProduct"].(map[string]any)
+				s.Equal("Product deleted", result["msg"])
+			},
+		},
+		{
+			name:  "Get All Products Without Deleted",
+			query: `query { getProducts { edges { node { id } } } }`,
+			check: func(body io.Reader) {
+				edges := s.getMap(body)["data"].(map[string]any)["getProducts"].(map[string]any)["edges"].([]any)
+				s.Len(edges, 1)
+				s.Equal(productID2, edges[0].(map[string]any)["node"].(map[string]any)["id"])
+			},
+		},
+		{
+			name:  "Search Products Deleted",
+			query: `query { searchProducts(query: "updated") { id } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["searchProducts"].([]any)
+				s.Empty(result, "Deleted product should not be found")
+			},
+		},
+		{
+			name:  "Get All Products Include Deleted",
+			query: `query { getProducts(filter: {includeDeleted: true}) { edges { node { id version deletedAt } } } }`,
+			check: func(body io.Reader) {
+				edges := s.getMap(body)["data"].(map[string]any)["getProducts"].(map[string]any)["edges"].([]any)
+				s.Len(edges, 2)
+				for _, edge := range edges {
+					node := edge.(map[string]any)["node"].(map[string]any)
+					if node["id"] == productID {
+						s.NotZero(node["deletedAt"])
+						s.Equal(4.0, node["version"])
+					} else {
+						s.Zero(node["deletedAt"])
+					}
+				}
+			},
+		},
+		{
+			name:  "Restore Product",
+			query: `mutation($id: String!) { restoreProduct(id: $id) { msg } }`,
+			vars:  map[string]any{"id": &productID},
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["restoreProduct"].(map[string]any)
+				s.Equal("Product restored", result["msg"])
+			},
+		},
+		{
+			name:  "Restore Product Not Deleted",
+			query: `mutation($id: String!) { restoreProduct(id: $id) { msg } }`,
+			vars:  map[string]any{"id": &productID},
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Len(result["errors"], 1)
+				s.Equal(model.ErrorNoRowsRestored.Error(), result["errors"].([]any)[0].(map[string]any)["message"],
+					"Restore should fail")
+			},
+		},
+		{
+			name:  "Get Product Restored",
+			query: `query($id: String!) { getProduct(filter: {id: $id}) { name version deletedAt } }`,
+			vars:  map[string]any{"id": &productID},
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["getProduct"].(map[string]any)
+				s.Equal("Updated Product", result["name"])
+				s.Equal(5.0, result["version"])
+				s.Zero(result["deletedAt"])
+			},
+		},
+		{
+			name:  "Delete Product Restored",
+			query: `mutation($id: String!) { deleteProduct(id: $id, version: 5) { msg } }`,
 			vars:  map[string]any{"id": &productID},
 			check: func(body io.Reader) {
 				result := s.getMap(body)["data"].(map[string]any)["deleteProduct"].(map[string]any)
@@ -231,8 +508,9 @@ func (s *GraphSuite) Test_Product() {
 			check: func(body io.Reader) {
 				result := s.getMap(body)
 				s.Len(result["errors"], 1)
-				s.Equal(sql.ErrNoRows.Error(), result["errors"].([]any)[0].(map[string]any)["message"],
-					"Update should fail")
+				gqlErr := result["errors"].([]any)[0].(map[string]any)
+				s.Equal(model.ErrorNotFound.Error(), gqlErr["message"], "Get should fail")
+				s.Equal("NOT_FOUND", gqlErr["extensions"].(map[string]any)["code"])
 			},
 		},
 		{
@@ -246,12 +524,286 @@ func (s *GraphSuite) Test_Product() {
 					"Update should fail")
 			},
 		},
+		{
+			name:  "Get Product As Of Now Deleted",
+			query: `query($id: String!) { getProduct(filter: {id: $id, asOf: 4294967295}) { id } }`,
+			vars:  map[string]any{"id": &productID},
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Len(result["errors"], 1)
+				s.Equal(model.ErrorNotFound.Error(), result["errors"].([]any)[0].(map[string]any)["message"])
+			},
+		},
+		{
+			name:  "Get Product History",
+			query: `query($id: String!) { getProductHistory(id: $id) { productId version operation changedAt actor before { name price } after { name price deletedAt } } }`,
+			vars:  map[string]any{"id": &productID},
+			check: func(body io.Reader) {
+				entries := s.getMap(body)["data"].(map[string]any)["getProductHistory"].([]any)
+				s.Require().Len(entries, 6)
+				for i, op := range []string{"add", "update", "update", "delete", "restore", "delete"} {
+					entry := entries[i].(map[string]any)
+					s.Equal(productID, entry["productId"], "Entries should belong to the product")
+					s.Equal(op, entry["operation"], "Operations should follow the changes")
+					s.Equal(float64(i+1), entry["version"], "Versions should follow the changes")
+					s.Equal("tester", entry["actor"], "Changes should be attributed to the caller")
+					s.NotZero(entry["changedAt"], "Change time should be filled")
+				}
+				entry := func(i int, field string) map[string]any {
+					return entries[i].(map[string]any)[field].(map[string]any)
+				}
+				s.Nil(entries[0].(map[string]any)["before"], "First change should have no previous state")
+				s.Equal("New Product", entry(0, "after")["name"])
+				s.Equal("New Product", entry(1, "before")["name"])
+				s.Equal("Updated Product", entry(1, "after")["name"])
+				s.Equal("10.5", entry(2, "before")["price"])
+				s.Equal("99.99", entry(2, "after")["price"])
+				s.NotZero(entry(3, "after")["deletedAt"])
+				s.Zero(entry(4, "after")["deletedAt"])
+			},
+		},
+		{
+			name:  "Purge Products Recently Deleted",
+			query: `mutation { purgeProducts(days: 1) { purged } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["purgeProducts"].(map[string]any)
+				s.Equal(0.0, result["purged"])
+			},
+		},
+		{
+			name:  "Purge Products",
+			query: `mutation { purgeProducts(days: 0) { purged } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["purgeProducts"].(map[string]any)
+				s.Equal(2.0, result["purged"])
+			},
+		},
+		{
+			name:  "Restore Product Purged",
+			query: `mutation($id: String!) { restoreProduct(id: $id) { msg } }`,
+			vars:  map[string]any{"id": &productID},
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Len(result["errors"], 1)
+			},
+		},
+		{
+			name:  "Get Product History Purged",
+			query: `query($id: String!) { getProductHistory(id: $id) { version } }`,
+			vars:  map[string]any{"id": &productID},
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["getProductHistory"].([]any)
+				s.Empty(result, "History should be purged along with the product")
+			},
+		},
 		{
 			name:  "Get All Products Empty",
-			query: `query { getProducts { id name price createdAt } }`,
+			query: `query { getProducts(filter: {includeDeleted: true}) { edges { cursor node { id name price createdAt } } pageInfo { hasNextPage endCursor } } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["getProducts"].(map[string]any)
+				s.Empty(result["edges"])
+				s.Nil(result["pageInfo"].(map[string]any)["endCursor"])
+			},
+		},
+		{
+			name:  "Add Products Batch",
+			query: `mutation($products: [NewProduct!]!) { addProducts(products: $products) { id product { id name price version } error } }`,
+			vars: map[string]any{"products": []map[string]any{
+				{"id": "batch-1", "name": "Batch Product", "price": 1, "currency": "USD"},
+				{"name": "Batch Product 2", "price": "12345678901234.5678", "currency": "JPY"},
+				{"id": "batch-1", "name": "Duplicate Product", "price": 3, "currency": "USD"},
+				{"name": "Invalid Product", "price": -1, "currency": "USD"},
+			}},
+			check: func(body io.Reader) {
+				results := s.getMap(body)["data"].(map[string]any)["addProducts"].([]any)
+				s.Require().Len(results, 4)
+				first := results[0].(map[string]any)
+				s.Equal("batch-1", first["id"])
+				s.Equal("Batch Product", first["product"].(map[string]any)["name"])
+				s.Nil(first["error"])
+				second := results[1].(map[string]any)
+				batchID = second["id"].(string)
+				s.NotEmpty(batchID)
+				s.Nil(second["error"])
+				s.Equal("12345678901234.5678", second["product"].(map[string]any)["price"], "Price should be kept exactly")
+				s.Contains(results[2].(map[string]any)["error"], model.ErrorAlreadyExists.Error())
+				s.Nil(results[2].(map[string]any)["product"])
+				s.Contains(results[3].(map[string]any)["error"], model.ErrorInvalidProduct.Error())
+			},
+		},
+		{
+			name:  "Add Products Batch Empty",
+			query: `mutation { addProducts(products: []) { id error } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Len(result["errors"], 1)
+			},
+		},
+		{
+			name:  "Search Products Ranked",
+			query: `query { searchProducts(query: "batch prod") { id } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["searchProducts"].([]any)
+				s.Require().Len(result, 2, "Result should contain 2 products")
+				s.Equal("batch-1", result[0].(map[string]any)["id"], "Shorter name should rank first")
+				s.Equal(batchID, result[1].(map[string]any)["id"], "Longer name should rank second")
+			},
+		},
+		{
+			name:  "Search Products Limited",
+			query: `query { searchProducts(query: "batch", limit: 1) { id } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["searchProducts"].([]any)
+				s.Require().Len(result, 1, "Result should be limited")
+				s.Equal("batch-1", result[0].(map[string]any)["id"], "Most relevant product should be kept")
+			},
+		},
+		{
+			name:  "Update Products Batch",
+			query: `mutation($products: [ProductUpdate!]!) { updateProducts(products: $products) { id error } }`,
+			vars: map[string]any{"products": []map[string]any{
+				{"id": "batch-1", "price": 10, "version": 1},
+				{"id": &batchID, "name": "Renamed Product", "version": 5},
+				{"id": "missing", "price": 1},
+			}},
+			check: func(body io.Reader) {
+				results := s.getMap(body)["data"].(map[string]any)["updateProducts"].([]any)
+				s.Require().Len(results, 3)
+				s.Nil(results[0].(map[string]any)["error"])
+				s.Contains(results[1].(map[string]any)["error"], model.ErrorVersionConflict.Error())
+				s.Contains(results[2].(map[string]any)["error"], model.ErrorNoRowsUpdated.Error())
+			},
+		},
+		{
+			name:  "Update Product Missing Variable",
+			query: `mutation($id: String!, $name: String, $price: Decimal) { updateProduct(id: $id, name: $name, price: $price, currency: "EUR", version: 1) { msg } }`,
+			vars:  map[string]any{"id": &batchID, "price": "5"},
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["updateProduct"].(map[string]any)
+				s.Equal("Product updated", result["msg"], "Update should be successful")
+			},
+		},
+		{
+			name:  "Get Product Missing Variable",
+			query: `query($id: String!) { getProduct(filter: {id: $id}) { name price currency version } }`,
+			vars:  map[string]any{"id": &batchID},
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["getProduct"].(map[string]any)
+				s.Equal("Batch Product 2", result["name"], "Name of the missing variable should be kept")
+				s.Equal("5", result["price"], "Price should be set")
+				s.Equal("EUR", result["currency"], "Currency should be set")
+				s.Equal(2.0, result["version"], "Version should be incremented")
+			},
+		},
+		{
+			name:  "Update Product Clear Name",
+			query: `mutation($id: String!, $name: String) { updateProduct(id: $id, name: $name, version: 2) { msg } }`,
+			vars:  map[string]any{"id": &batchID, "name": nil},
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["updateProduct"].(map[string]any)
+				s.Equal("Product updated", result["msg"], "Update should be successful")
+			},
+		},
+		{
+			name:  "Update Product Clear Currency",
+			query: `mutation($id: String!) { updateProduct(id: $id, currency: null) { msg } }`,
+			vars:  map[string]any{"id": &batchID},
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Len(result["errors"], 1)
+				s.Contains(result["errors"].([]any)[0].(map[string]any)["message"], model.ErrorInvalidProduct.Error(),
+					"Update should fail")
+			},
+		},
+		{
+			name:  "Update Products Batch Clear Price",
+			query: `mutation($id: String!) { updateProducts(products: [{id: $id, price: null, version: 3}]) { id error } }`,
+			vars:  map[string]any{"id": &batchID},
+			check: func(body io.Reader) {
+				results := s.getMap(body)["data"].(map[string]any)["updateProducts"].([]any)
+				s.Require().Len(results, 1)
+				s.Nil(results[0].(map[string]any)["error"])
+			},
+		},
+		{
+			name:  "Get Product Cleared",
+			query: `query($id: String!) { getProduct(filter: {id: $id}) { name price currency version } }`,
+			vars:  map[string]any{"id": &batchID},
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["getProduct"].(map[string]any)
+				s.Empty(result["name"], "Name should be cleared")
+				s.Equal("0", result["price"], "Price should be cleared")
+				s.Equal("EUR", result["currency"], "Currency out of the update should be kept")
+				s.Equal(4.0, result["version"], "Version should be incremented")
+			},
+		},
+		{
+			name:  "Delete Products Batch",
+			query: `mutation($products: [ProductDelete!]!) { deleteProducts(products: $products) { id error } }`,
+			vars: map[string]any{"products": []map[string]any{
+				{"id": "batch-1", "version": 2},
+				{"id": &batchID},
+				{"id": "batch-1"},
+			}},
 			check: func(body io.Reader) {
-				products := s.getMap(body)["data"].(map[string]any)["getProducts"].([]any)
-				s.Empty(products)
+				results := s.getMap(body)["data"].(map[string]any)["deleteProducts"].([]any)
+				s.Require().Len(results, 3)
+				s.Nil(results[0].(map[string]any)["error"])
+				s.Nil(results[1].(map[string]any)["error"])
+				s.Contains(results[2].(map[string]any)["error"], model.ErrorNoRowsDeleted.Error())
+			},
+		},
+		{
+			name:  "Purge Products After Batch",
+			query: `mutation { purgeProducts(days: 0) { purged } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["purgeProducts"].(map[string]any)
+				s.Equal(2.0, result["purged"])
+			},
+		},
+		{
+			name: "Upsert Product Created",
+			query: `mutation { upsertProduct(id: "upsert-1", name: "Synced Product", price: "5.25", currency: "USD") ` +
+				`{ created product { id price version } } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["upsertProduct"].(map[string]any)
+				s.Equal(true, result["created"])
+				product := result["product"].(map[string]any)
+				s.Equal("upsert-1", product["id"])
+				s.Equal("5.25", product["price"])
+				s.Equal(1.0, product["version"])
+			},
+		},
+		{
+			name: "Upsert Product Unchanged",
+			query: `mutation { upsertProduct(id: "upsert-1", name: "Synced Product", price: "5.250", currency: "USD") ` +
+				`{ created product { version } } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["upsertProduct"].(map[string]any)
+				s.Equal(false, result["created"])
+				s.Equal(1.0, result["product"].(map[string]any)["version"], "Repeated upsert should keep the version")
+			},
+		},
+		{
+			name: "Upsert Product Replaced",
+			query: `mutation { upsertProduct(id: "upsert-1", name: "Synced Product 2", price: "6", currency: "EUR") ` +
+				`{ created product { name currency version } } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)["data"].(map[string]any)["upsertProduct"].(map[string]any)
+				s.Equal(false, result["created"])
+				product := result["product"].(map[string]any)
+				s.Equal("Synced Product 2", product["name"])
+				s.Equal("EUR", product["currency"])
+				s.Equal(2.0, product["version"])
+			},
+		},
+		{
+			name: "Upsert Product Invalid",
+			query: `mutation { upsertProduct(id: "upsert-1", name: "", price: "6", currency: "EUR") ` +
+				`{ created } }`,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Contains(result["errors"].([]any)[0].(map[string]any)["message"], model.ErrorInvalidProduct.Error())
 			},
 		},
 	}
@@ -267,6 +819,7 @@ func (s *GraphSuite) Test_Product() {
 			req, err := http.NewRequest(http.MethodPost, "http://app-test:8000/query", bytes.NewBuffer(bodyBytes))
 			s.NoError(err)
 			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(model.ActorHeader, "tester")
 
 			resp, err := http.DefaultClient.Do(req)
 			s.NoError(err)
@@ -280,6 +833,215 @@ func (s *GraphSuite) Test_Product() {
 	}
 }
 
+func (s *GraphSuite) Test_ProductChanged() {
+	id := s.graphql(`mutation { addProduct(product: {name: "Watched Product", price: "1", currency: "USD"}) { id } }`,
+		nil)["addProduct"].(map[string]any)["id"].(string)
+
+	u, err := url.Parse("ws://app-test:8000/subscription")
+	s.NoError(err)
+	dialer := websocket.Dialer{
+		HandshakeTimeout: 45 * time.Second,
+		Subprotocols:     []string{"graphql-transport-ws"},
+	}
+	c, _, err := dialer.Dial(u.String(), nil)
+	s.Require().NoError(err)
+	defer c.Close()
+	s.Require().NoError(c.WriteJSON(map[string]any{"type": "connection_init"}))
+	var ack map[string]any
+	s.Require().NoError(c.ReadJSON(&ack))
+	s.Equal("connection_ack", ack["type"])
+
+	subscriptions := map[string]string{
+		"product":  `subscription($id: String) { productChanged(id: $id) { sequence type product { id name } } }`,
+		"products": `subscription { productsChanged { sequence type product { id name } } }`,
+	}
+	for key, query := range subscriptions {
+		s.Require().NoError(c.WriteJSON(map[string]any{
+			"id":      key,
+			"type":    "subscribe",
+			"payload": map[string]any{"query": query, "variables": map[string]any{"id": id}},
+		}))
+	}
+	// The changes made before the subscription starts aren't sent
+	time.Sleep(200 * time.Millisecond)
+
+	other := s.graphql(`mutation { addProduct(product: {name: "Other Product", price: "1", currency: "USD"}) { id } }`,
+		nil)["addProduct"].(map[string]any)["id"].(string)
+	s.graphql(`mutation($id: String!) { updateProduct(id: $id, name: "Watched Renamed") { msg } }`,
+		map[string]any{"id": id})
+	s.graphql(`mutation($id: String!) { deleteProduct(id: $id) { msg } }`, map[string]any{"id": id})
+
+	events := map[string][]map[string]any{}
+	s.Require().NoError(c.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	for len(events["product"]) < 2 || len(events["products"]) < 3 {
+		var msg struct {
+			ID      string `json:"id"`
+			Type    string `json:"type"`
+			Payload struct {
+				Data map[string]map[string]any `json:"data"`
+			} `json:"payload"`
+		}
+		s.Require().NoError(c.ReadJSON(&msg))
+		s.Require().Equal("next", msg.Type)
+		for _, event := range msg.Payload.Data {
+			events[msg.ID] = append(events[msg.ID], event)
+		}
+	}
+
+	s.Run("Product Changed", func() {
+		s.Len(events["product"], 2)
+		s.Equal("UPDATED", events["product"][0]["type"])
+		s.Equal("Watched Renamed", events["product"][0]["product"].(map[string]any)["name"])
+		s.Equal("DELETED", events["product"][1]["type"])
+		s.Equal(id, events["product"][1]["product"].(map[string]any)["id"])
+	})
+
+	s.Run("Products Changed", func() {
+		s.Len(events["products"], 3)
+		s.Equal("CREATED", events["products"][0]["type"])
+		s.Equal(other, events["products"][0]["product"].(map[string]any)["id"])
+		s.Equal("UPDATED", events["products"][1]["type"])
+		s.Equal("DELETED", events["products"][2]["type"])
+		s.Equal(events["products"][0]["sequence"].(float64)+2, events["products"][2]["sequence"])
+	})
+
+	for key := range subscriptions {
+		_ = c.WriteJSON(map[string]any{"type": "complete", "id": key})
+	}
+	_ = c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "test done"))
+}
+
+func (s *GraphSuite) Test_ProductsChangedSSE() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://app-test:8000/subscription",
+		strings.NewReader(`{"query":"subscription { productsChanged { type product { id name } } }"}`))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	res, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer res.Body.Close()
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("text/event-stream", res.Header.Get("Content-Type"))
+	// The changes made before the subscription starts aren't sent
+	time.Sleep(200 * time.Millisecond)
+
+	id := s.graphql(`mutation { addProduct(product: {name: "Streamed Product", price: "1", currency: "USD"}) { id } }`,
+		nil)["addProduct"].(map[string]any)["id"].(string)
+
+	lines := bufio.NewScanner(res.Body)
+	for lines.Scan() {
+		data, ok := strings.CutPrefix(lines.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		event := s.getMap(strings.NewReader(data))["data"].(map[string]any)["productsChanged"].(map[string]any)
+		s.Equal("CREATED", event["type"])
+		s.Equal(id, event["product"].(map[string]any)["id"])
+		s.Equal("Streamed Product", event["product"].(map[string]any)["name"])
+		return
+	}
+	s.Fail("the event hasn't been streamed", lines.Err())
+}
+
+func (s *GraphSuite) Test_Products() {
+	var ids []string
+	for _, name := range []string{"Listed Product 1", "Listed Product 2"} {
+		ids = append(ids, s.graphql(`mutation($name: String!) { addProduct(product: {name: $name, price: "1", `+
+			`currency: "USD"}) { id } }`, map[string]any{"name": name})["addProduct"].(map[string]any)["id"].(string))
+	}
+
+	s.Run("Products", func() {
+		res := s.graphql(`query($ids: [String!]!) { products(ids: $ids) { id name } }`,
+			map[string]any{"ids": []string{ids[1], "missing", ids[0], ids[1]}})["products"].([]any)
+		s.Require().Len(res, 4)
+		s.Equal("Listed Product 2", res[0].(map[string]any)["name"])
+		s.Nil(res[1])
+		s.Equal("Listed Product 1", res[2].(map[string]any)["name"])
+		s.Equal(ids[1], res[3].(map[string]any)["id"])
+	})
+
+	s.Run("No Products", func() {
+		res := s.graphql(`{ products(ids: []) { id } }`, nil)["products"].([]any)
+		s.Empty(res)
+	})
+
+	s.Run("Too Many Products", func() {
+		res, err := http.Post("http://app-test:8000/query", "application/json", strings.NewReader(
+			`{"query":"query($ids: [String!]!) { products(ids: $ids) { id } }","variables":{"ids":["`+
+				strings.Repeat(`id","`, model.MaxBatchSize)+`id"]}}`))
+		s.Require().NoError(err)
+		defer res.Body.Close()
+		errs := s.getMap(res.Body)["errors"].([]any)
+		s.Require().Len(errs, 1)
+		s.Equal("INVALID_ARGUMENT", errs[0].(map[string]any)["extensions"].(map[string]any)["code"])
+	})
+
+	s.Run("Deleted Product", func() {
+		s.graphql(`mutation($id: String!) { deleteProduct(id: $id) { msg } }`, map[string]any{"id": ids[0]})
+		res := s.graphql(`query($ids: [String!]!) { products(ids: $ids) { id } }`,
+			map[string]any{"ids": ids})["products"].([]any)
+		s.Require().Len(res, 2)
+		s.Nil(res[0])
+		s.Equal(ids[1], res[1].(map[string]any)["id"])
+	})
+}
+
+func (s *GraphSuite) Test_Limits() {
+	code := func(query string) any {
+		body, err := json.Marshal(map[string]any{"query": query})
+		s.Require().NoError(err)
+		res, err := http.Post("http://app-test:8000/query", "application/json", bytes.NewReader(body))
+		s.Require().NoError(err)
+		defer res.Body.Close()
+		errs, ok := s.getMap(res.Body)["errors"].([]any)
+		if !ok {
+			return nil
+		}
+		s.Require().Len(errs, 1)
+		return errs[0].(map[string]any)["extensions"].(map[string]any)["code"]
+	}
+
+	s.Run("Too Many Aliases", func() {
+		var query strings.Builder
+		for i := range 101 {
+			fmt.Fprintf(&query, "m%d: main ", i)
+		}
+		s.Equal("ALIAS_LIMIT_EXCEEDED", code("{ "+query.String()+"}"))
+		s.Nil(code("{ m1: main m2: main }"))
+	})
+
+	s.Run("Too Complex", func() {
+		var query strings.Builder
+		for i := range 11 {
+			fmt.Fprintf(&query, "p%d: getProducts(first: 1000) { edges { node { id } } } ", i)
+		}
+		s.Equal("COMPLEXITY_LIMIT_EXCEEDED", code("{ "+query.String()+"}"))
+		s.Nil(code("{ getProducts(first: 1000) { edges { node { id } } } }"))
+	})
+
+	s.Run("Introspection", func() {
+		s.Nil(code("{ __schema { queryType { name fields { name type { ofType { ofType { ofType { name } } } } } } } }"))
+	})
+}
+
+// graphql sends the operation as the tester, the errors fail the test
+func (s *GraphSuite) graphql(query string, vars map[string]any) map[string]any {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	s.Require().NoError(err)
+	req, err := http.NewRequest(http.MethodPost, "http://app-test:8000/query", bytes.NewReader(body))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(model.ActorHeader, "tester")
+	res, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer res.Body.Close()
+	ret := s.getMap(res.Body)
+	s.Require().Nil(ret["errors"])
+	return ret["data"].(map[string]any)
+}
+
 func (s *GraphSuite) getMap(body io.Reader) map[string]any {
 	result := make(map[string]any)
 	if err := json.NewDecoder(body).Decode(&result); err != nil {
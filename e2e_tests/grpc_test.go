@@ -2,10 +2,18 @@ package e2e_tests
 
 import (
 	"context"
+	"math"
 
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
 	pb "github.com/aleksandrzhukovskii/go-template/internal/service/grpc"
@@ -25,6 +33,7 @@ func (s *GrpcSuite) SetupSuite() {
 	s.NoError(err)
 	s.client = pb.NewProductServiceClient(s.conn)
 	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.ctx = metadata.AppendToOutgoingContext(s.ctx, model.ActorHeader, "tester")
 }
 
 func (s *GrpcSuite) TearDownSuite() {
@@ -39,17 +48,101 @@ func (s *GrpcSuite) Test_Main() {
 	s.Contains(res.Info, "authority: app-test:8000")
 }
 
+func (s *GrpcSuite) Test_Health() {
+	client := grpc_health_v1.NewHealthClient(s.conn)
+	for _, service := range []string{"", "template.ProductService"} {
+		res, err := client.Check(s.ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+		s.Require().NoError(err, service)
+		s.Equal(grpc_health_v1.HealthCheckResponse_SERVING, res.Status, service)
+	}
+
+	_, err := client.Check(s.ctx, &grpc_health_v1.HealthCheckRequest{Service: "unknown"})
+	s.Equal(codes.NotFound, status.Code(err))
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	stream, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{Service: "template.ProductService"})
+	s.Require().NoError(err)
+	res, err := stream.Recv()
+	s.Require().NoError(err)
+	s.Equal(grpc_health_v1.HealthCheckResponse_SERVING, res.Status)
+}
+
+func (s *GrpcSuite) Test_Reflection() {
+	stream, err := reflectionpb.NewServerReflectionClient(s.conn).ServerReflectionInfo(s.ctx)
+	s.Require().NoError(err)
+	defer func() {
+		s.NoError(stream.CloseSend())
+	}()
+
+	s.Require().NoError(stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+	res, err := stream.Recv()
+	s.Require().NoError(err)
+	var services []string
+	for _, service := range res.GetListServicesResponse().GetService() {
+		services = append(services, service.Name)
+	}
+	s.Contains(services, "template.ProductService")
+	s.Contains(services, "grpc.health.v1.Health")
+	s.Contains(services, "grpc.reflection.v1.ServerReflection")
+
+	s.Require().NoError(stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{
+			FileContainingSymbol: "template.ProductService",
+		},
+	}))
+	res, err = stream.Recv()
+	s.Require().NoError(err)
+	s.NotEmpty(res.GetFileDescriptorResponse().GetFileDescriptorProto())
+}
+
 func (s *GrpcSuite) Test_Product() {
 	var productID string
 	var productID2 string
 
 	s.Run("Add Product", func() {
-		resp, err := s.client.AddProduct(s.ctx, &pb.Empty{})
+		resp, err := s.client.AddProduct(s.ctx, &pb.AddRequest{Name: "New Product", Price: s.money(10, 500000000)})
 		s.NoError(err)
 		s.NotEmpty(resp.Id, "Product ID should not be empty")
+		s.Equal("New Product", resp.Name)
+		s.Equal("USD", resp.Price.CurrencyCode)
+		s.Equal(int64(10), resp.Price.Units)
+		s.Equal(int32(500000000), resp.Price.Nanos)
+		s.NotEmpty(resp.CreatedAt)
+		s.Equal(uint64(1), resp.Version)
 		productID = resp.Id
 	})
 
+	s.Run("Add Product Invalid", func() {
+		_, err := s.client.AddProduct(s.ctx, &pb.AddRequest{Price: s.money(10, 500000000)})
+		s.Error(err)
+		s.Equal(codes.InvalidArgument, status.Code(err))
+		s.Contains(err.Error(), model.ErrorInvalidProduct.Error())
+	})
+
+	s.Run("Add Product Invalid Money", func() {
+		_, err := s.client.AddProduct(s.ctx, &pb.AddRequest{Name: "New Product", Price: s.money(1, -1)})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+		s.Contains(err.Error(), model.ErrorInvalidProduct.Error())
+
+		_, err = s.client.AddProduct(s.ctx, &pb.AddRequest{Name: "New Product", Price: s.money(1, 1)})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+		s.Contains(err.Error(), model.ErrorInvalidProduct.Error())
+
+		_, err = s.client.AddProduct(s.ctx, &pb.AddRequest{Name: "New Product"})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+		s.Contains(err.Error(), model.ErrorInvalidProduct.Error())
+	})
+
+	s.Run("Add Product Already Exists", func() {
+		_, err := s.client.AddProduct(s.ctx, &pb.AddRequest{Id: &productID, Name: "New Product",
+			Price: s.money(10, 500000000)})
+		s.Error(err)
+		s.Equal(codes.AlreadyExists, status.Code(err))
+	})
+
 	s.Run("Update Product Name", func() {
 		resp, err := s.client.UpdateProduct(s.ctx, &pb.UpdateRequest{
 			Id:   productID,
@@ -61,13 +154,25 @@ func (s *GrpcSuite) Test_Product() {
 
 	s.Run("Update Product Price", func() {
 		resp, err := s.client.UpdateProduct(s.ctx, &pb.UpdateRequest{
-			Id:    productID,
-			Price: s.floatToPtr(99.99),
+			Id:      productID,
+			Price:   &pb.Money{CurrencyCode: "EUR", Units: 99, Nanos: 990000000},
+			Version: 2,
 		})
 		s.NoError(err)
 		s.Equal("Product updated", resp.Msg)
 	})
 
+	s.Run("Update Product Stale Version", func() {
+		_, err := s.client.UpdateProduct(s.ctx, &pb.UpdateRequest{
+			Id:      productID,
+			Price:   s.money(1, 0),
+			Version: 2,
+		})
+		s.Error(err)
+		s.Equal(codes.FailedPrecondition, status.Code(err))
+		s.Contains(err.Error(), model.ErrorVersionConflict.Error())
+	})
+
 	s.Run("Update Product Nothing to update", func() {
 		_, err := s.client.UpdateProduct(s.ctx, &pb.UpdateRequest{
 			Id: productID,
@@ -79,9 +184,9 @@ func (s *GrpcSuite) Test_Product() {
 	s.Run("Update Product Not Exist", func() {
 		_, err := s.client.UpdateProduct(s.ctx, &pb.UpdateRequest{
 			Id:    "123",
-			Price: s.floatToPtr(99.99),
+			Price: s.money(99, 990000000),
 		})
-		s.Error(err)
+		s.Equal(codes.NotFound, status.Code(err))
 		s.Contains(err.Error(), model.ErrorNoRowsUpdated.Error())
 	})
 
@@ -90,35 +195,209 @@ func (s *GrpcSuite) Test_Product() {
 		s.NoError(err)
 		s.Equal(productID, res.Id)
 		s.Equal("Updated Product", res.Name)
-		s.Equal(99.99, res.Price)
+		s.Equal("EUR", res.Price.CurrencyCode)
+		s.Equal(int64(99), res.Price.Units)
+		s.Equal(int32(990000000), res.Price.Nanos)
 		s.NotEmpty(res.CreatedAt)
+		s.Equal(uint64(3), res.Version)
+	})
+
+	s.Run("Get Product As Of Now", func() {
+		asOf := uint32(math.MaxUint32)
+		res, err := s.client.GetProduct(s.ctx, &pb.GetProductRequest{Id: productID, AsOf: &asOf})
+		s.NoError(err)
+		s.Equal("Updated Product", res.Name)
+		s.Equal(uint64(3), res.Version)
+	})
+
+	s.Run("Get Product As Of Before Creation", func() {
+		asOf := uint32(1)
+		_, err := s.client.GetProduct(s.ctx, &pb.GetProductRequest{Id: productID, AsOf: &asOf})
+		s.Equal(codes.NotFound, status.Code(err))
 	})
 
 	s.Run("Get All Products", func() {
-		res, err := s.client.GetProducts(s.ctx, &pb.Empty{})
+		res, err := s.client.GetProducts(s.ctx, &pb.GetProductsRequest{})
 		s.NoError(err)
+		s.False(res.HasMore)
+		s.Empty(res.NextCursor)
 		s.Len(res.Items, 1)
 		s.Equal(productID, res.Items[0].Id)
 		s.Equal("Updated Product", res.Items[0].Name)
-		s.Equal(99.99, res.Items[0].Price)
+		s.Equal(int64(99), res.Items[0].Price.Units)
+		s.Equal(int32(990000000), res.Items[0].Price.Nanos)
 		s.NotEmpty(res.Items[0].CreatedAt)
 	})
 
 	s.Run("Add Product Fill More", func() {
-		resp, err := s.client.AddProduct(s.ctx, &pb.Empty{})
+		resp, err := s.client.AddProduct(s.ctx, &pb.AddRequest{Sample: true})
 		s.NoError(err)
 		s.NotEmpty(resp.Id)
 		productID2 = resp.Id
 	})
 
 	s.Run("Get All Products Check 2", func() {
-		res, err := s.client.GetProducts(s.ctx, &pb.Empty{})
+		res, err := s.client.GetProducts(s.ctx, &pb.GetProductsRequest{})
 		s.NoError(err)
 		s.Len(res.Items, 2)
 	})
 
+	s.Run("Get All Products Paged", func() {
+		first, err := s.client.GetProducts(s.ctx, &pb.GetProductsRequest{PageSize: 1})
+		s.NoError(err)
+		s.Len(first.Items, 1)
+		s.True(first.HasMore)
+		s.NotEmpty(first.NextCursor)
+
+		second, err := s.client.GetProducts(s.ctx, &pb.GetProductsRequest{PageSize: 1, Cursor: first.NextCursor})
+		s.NoError(err)
+		s.Len(second.Items, 1)
+		s.False(second.HasMore)
+		s.NotEqual(first.Items[0].Id, second.Items[0].Id)
+	})
+
+	s.Run("Get All Products Invalid Cursor", func() {
+		_, err := s.client.GetProducts(s.ctx, &pb.GetProductsRequest{Cursor: "%%%"})
+		s.Error(err)
+		s.Equal(codes.InvalidArgument, status.Code(err))
+		s.Contains(err.Error(), model.ErrorInvalidPage.Error())
+	})
+
+	s.Run("Get All Products Filtered", func() {
+		res, err := s.client.GetProducts(s.ctx, &pb.GetProductsRequest{NameContains: "UPDATED"})
+		s.NoError(err)
+		s.Len(res.Items, 1)
+		s.Equal(productID, res.Items[0].Id)
+
+		res, err = s.client.GetProducts(s.ctx, &pb.GetProductsRequest{MinPrice: s.stringToPtr("1000")})
+		s.NoError(err)
+		s.Empty(res.Items)
+
+		res, err = s.client.GetProducts(s.ctx, &pb.GetProductsRequest{MinPrice: s.stringToPtr("99.99"),
+			MaxPrice: s.stringToPtr("99.99")})
+		s.NoError(err)
+		s.Require().Len(res.Items, 1)
+		s.Equal(productID, res.Items[0].Id)
+	})
+
+	s.Run("Get All Products Sorted Paged", func() {
+		req := &pb.GetProductsRequest{PageSize: 1, SortBy: pb.ProductSortField_PRODUCT_SORT_FIELD_PRICE, Descending: true}
+		first, err := s.client.GetProducts(s.ctx, req)
+		s.NoError(err)
+		s.Len(first.Items, 1)
+		s.True(first.HasMore)
+
+		req.Cursor = first.NextCursor
+		second, err := s.client.GetProducts(s.ctx, req)
+		s.NoError(err)
+		s.Len(second.Items, 1)
+		s.False(second.HasMore)
+		s.GreaterOrEqual(first.Items[0].Price.Units, second.Items[0].Price.Units)
+
+		req.SortBy = pb.ProductSortField_PRODUCT_SORT_FIELD_NAME
+		_, err = s.client.GetProducts(s.ctx, req)
+		s.Equal(codes.InvalidArgument, status.Code(err))
+		s.Contains(err.Error(), model.ErrorInvalidPage.Error())
+	})
+
+	s.Run("Get All Products Invalid Query", func() {
+		_, err := s.client.GetProducts(s.ctx, &pb.GetProductsRequest{MinPrice: s.stringToPtr("10"),
+			MaxPrice: s.stringToPtr("1")})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+		s.Contains(err.Error(), model.ErrorInvalidQuery.Error())
+
+		_, err = s.client.GetProducts(s.ctx, &pb.GetProductsRequest{MinPrice: s.stringToPtr("ten")})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+		s.Contains(err.Error(), model.ErrorInvalidQuery.Error())
+
+		_, err = s.client.GetProducts(s.ctx, &pb.GetProductsRequest{SortBy: 42})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+		s.Contains(err.Error(), model.ErrorInvalidQuery.Error())
+	})
+
+	s.Run("Search Products", func() {
+		res, err := s.client.SearchProducts(s.ctx, &pb.SearchProductsRequest{Query: "upd PROD"})
+		s.NoError(err)
+		s.Require().Len(res.Items, 1)
+		s.Equal(productID, res.Items[0].Id)
+	})
+
+	s.Run("Search Products Middle Of Word", func() {
+		res, err := s.client.SearchProducts(s.ctx, &pb.SearchProductsRequest{Query: "pdated"})
+		s.NoError(err)
+		s.Empty(res.Items)
+	})
+
+	s.Run("Search Products Invalid", func() {
+		_, err := s.client.SearchProducts(s.ctx, &pb.SearchProductsRequest{Query: "?!"})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+		s.Contains(err.Error(), model.ErrorInvalidQuery.Error())
+
+		_, err = s.client.SearchProducts(s.ctx, &pb.SearchProductsRequest{Query: "product", Limit: -1})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+	})
+
+	s.Run("Delete Product Stale Version", func() {
+		_, err := s.client.DeleteProduct(s.ctx, &pb.DeleteRequest{Id: productID, Version: 1})
+		s.Error(err)
+		s.Equal(codes.FailedPrecondition, status.Code(err))
+	})
+
 	s.Run("Delete Product", func() {
-		res, err := s.client.DeleteProduct(s.ctx, &pb.DeleteRequest{Id: productID})
+		res, err := s.client.DeleteProduct(s.ctx, &pb.DeleteRequest{Id: productID, Version: 3})
+		s.NoError(err)
+		s.Equal("Product deleted", res.Msg)
+	})
+
+	s.Run("Get All Products Without Deleted", func() {
+		res, err := s.client.GetProducts(s.ctx, &pb.GetProductsRequest{})
+		s.NoError(err)
+		s.Len(res.Items, 1)
+		s.Equal(productID2, res.Items[0].Id)
+	})
+
+	s.Run("Search Products Deleted", func() {
+		res, err := s.client.SearchProducts(s.ctx, &pb.SearchProductsRequest{Query: "updated"})
+		s.NoError(err)
+		s.Empty(res.Items)
+	})
+
+	s.Run("Get All Products Include Deleted", func() {
+		res, err := s.client.GetProducts(s.ctx, &pb.GetProductsRequest{IncludeDeleted: true})
+		s.NoError(err)
+		s.Len(res.Items, 2)
+		for _, item := range res.Items {
+			if item.Id == productID {
+				s.NotZero(item.DeletedAt)
+				s.Equal(uint64(4), item.Version)
+			} else {
+				s.Zero(item.DeletedAt)
+			}
+		}
+	})
+
+	s.Run("Restore Product", func() {
+		res, err := s.client.RestoreProduct(s.ctx, &pb.RestoreRequest{Id: productID})
+		s.NoError(err)
+		s.Equal("Product restored", res.Msg)
+	})
+
+	s.Run("Restore Product Not Deleted", func() {
+		_, err := s.client.RestoreProduct(s.ctx, &pb.RestoreRequest{Id: productID})
+		s.Equal(codes.NotFound, status.Code(err))
+		s.Contains(err.Error(), model.ErrorNoRowsRestored.Error())
+	})
+
+	s.Run("Get Product Restored", func() {
+		res, err := s.client.GetProduct(s.ctx, &pb.GetProductRequest{Id: productID})
+		s.NoError(err)
+		s.Equal("Updated Product", res.Name)
+		s.Equal(uint64(5), res.Version)
+		s.Zero(res.DeletedAt)
+	})
+
+	s.Run("Delete Product Restored", func() {
+		res, err := s.client.DeleteProduct(s.ctx, &pb.DeleteRequest{Id: productID, Version: 5})
 		s.NoError(err)
 		s.Equal("Product deleted", res.Msg)
 	})
@@ -131,27 +410,307 @@ func (s *GrpcSuite) Test_Product() {
 
 	s.Run("Get Product Not Exist", func() {
 		_, err := s.client.GetProduct(s.ctx, &pb.GetProductRequest{Id: productID})
-		s.Error(err)
-		s.Contains(err.Error(), "sql: no rows")
+		s.Equal(codes.NotFound, status.Code(err))
+		s.Contains(err.Error(), model.ErrorNotFound.Error())
 	})
 
 	s.Run("Delete Product Not Exist", func() {
 		_, err := s.client.DeleteProduct(s.ctx, &pb.DeleteRequest{Id: productID})
-		s.Error(err)
+		s.Equal(codes.NotFound, status.Code(err))
 		s.Contains(err.Error(), model.ErrorNoRowsDeleted.Error())
 	})
 
+	s.Run("Get Product As Of Now Deleted", func() {
+		asOf := uint32(math.MaxUint32)
+		_, err := s.client.GetProduct(s.ctx, &pb.GetProductRequest{Id: productID, AsOf: &asOf})
+		s.Error(err)
+	})
+
+	s.Run("Get Product History", func() {
+		res, err := s.client.GetProductHistory(s.ctx, &pb.GetProductHistoryRequest{Id: productID})
+		s.NoError(err)
+		s.Require().Len(res.Entries, 6)
+		for i, op := range []string{"add", "update", "update", "delete", "restore", "delete"} {
+			s.Equal(op, res.Entries[i].Operation)
+			s.Equal(uint64(i+1), res.Entries[i].Version)
+			s.Equal("tester", res.Entries[i].Actor)
+		}
+		s.Nil(res.Entries[0].Before)
+		s.Equal("New Product", res.Entries[0].After.Name)
+		s.Equal("New Product", res.Entries[1].Before.Name)
+		s.Equal("Updated Product", res.Entries[1].After.Name)
+		s.Equal(int32(500000000), res.Entries[2].Before.Price.Nanos)
+		s.Equal(int32(990000000), res.Entries[2].After.Price.Nanos)
+		s.Equal("EUR", res.Entries[2].After.Price.CurrencyCode)
+		s.NotZero(res.Entries[3].After.DeletedAt)
+		s.Zero(res.Entries[4].After.DeletedAt)
+	})
+
+	s.Run("Purge Products Recently Deleted", func() {
+		res, err := s.client.PurgeProducts(s.ctx, &pb.PurgeRequest{Days: 1})
+		s.NoError(err)
+		s.Zero(res.Purged)
+	})
+
+	s.Run("Purge Products", func() {
+		res, err := s.client.PurgeProducts(s.ctx, &pb.PurgeRequest{Days: 0})
+		s.NoError(err)
+		s.Equal(int64(2), res.Purged)
+	})
+
+	s.Run("Restore Product Purged", func() {
+		_, err := s.client.RestoreProduct(s.ctx, &pb.RestoreRequest{Id: productID})
+		s.Equal(codes.NotFound, status.Code(err))
+	})
+
+	s.Run("Get Product History Purged", func() {
+		res, err := s.client.GetProductHistory(s.ctx, &pb.GetProductHistoryRequest{Id: productID})
+		s.NoError(err)
+		s.Empty(res.Entries)
+	})
+
 	s.Run("Get All Products Empty", func() {
-		res, err := s.client.GetProducts(s.ctx, &pb.Empty{})
+		res, err := s.client.GetProducts(s.ctx, &pb.GetProductsRequest{IncludeDeleted: true})
 		s.NoError(err)
 		s.Empty(res.Items)
 	})
+
+	var batchID string
+	s.Run("Add Products Batch", func() {
+		res, err := s.client.AddProducts(s.ctx, &pb.AddProductsRequest{Items: []*pb.AddRequest{
+			{Id: s.stringToPtr("batch-1"), Name: "Batch Product", Price: s.money(1, 0)},
+			{Name: "Batch Product 2", Price: s.money(12345678901234, 567800000)},
+			{Id: s.stringToPtr("batch-1"), Name: "Duplicate Product", Price: s.money(3, 0)},
+			{Name: "Invalid Product", Price: s.money(-1, 0)},
+		}})
+		s.Require().NoError(err)
+		s.Require().Len(res.Results, 4)
+		s.Equal("batch-1", res.Results[0].Id)
+		s.Equal("Batch Product", res.Results[0].Product.Name)
+		s.Empty(res.Results[0].Error)
+		batchID = res.Results[1].Id
+		s.NotEmpty(batchID)
+		s.Empty(res.Results[1].Error)
+		s.Equal(int64(12345678901234), res.Results[1].Product.Price.Units)
+		s.Equal(int32(567800000), res.Results[1].Product.Price.Nanos)
+		s.Contains(res.Results[2].Error, model.ErrorAlreadyExists.Error())
+		s.Nil(res.Results[2].Product)
+		s.Contains(res.Results[3].Error, model.ErrorInvalidProduct.Error())
+	})
+
+	s.Run("Add Products Batch Empty", func() {
+		_, err := s.client.AddProducts(s.ctx, &pb.AddProductsRequest{})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+	})
+
+	s.Run("Search Products Ranked", func() {
+		res, err := s.client.SearchProducts(s.ctx, &pb.SearchProductsRequest{Query: "batch prod"})
+		s.NoError(err)
+		s.Require().Len(res.Items, 2)
+		s.Equal("batch-1", res.Items[0].Id)
+		s.Equal(batchID, res.Items[1].Id)
+
+		res, err = s.client.SearchProducts(s.ctx, &pb.SearchProductsRequest{Query: "batch", Limit: 1})
+		s.NoError(err)
+		s.Require().Len(res.Items, 1)
+		s.Equal("batch-1", res.Items[0].Id)
+	})
+
+	s.Run("Update Products Batch", func() {
+		res, err := s.client.UpdateProducts(s.ctx, &pb.UpdateProductsRequest{Items: []*pb.UpdateRequest{
+			{Id: "batch-1", Price: s.money(10, 0), Version: 1},
+			{Id: batchID, Name: s.stringToPtr("Renamed Product"), Version: 5},
+			{Id: "missing", Price: s.money(1, 0)},
+		}})
+		s.Require().NoError(err)
+		s.Require().Len(res.Results, 3)
+		s.Empty(res.Results[0].Error)
+		s.Equal(int64(10), res.Results[0].Product.GetPrice().GetUnits(), "Updated product should be returned")
+		s.Equal(uint64(2), res.Results[0].Product.GetVersion())
+		s.Contains(res.Results[1].Error, model.ErrorVersionConflict.Error())
+		s.Contains(res.Results[2].Error, model.ErrorNoRowsUpdated.Error())
+	})
+
+	s.Run("Update Product Field Mask", func() {
+		_, err := s.client.UpdateProduct(s.ctx, &pb.UpdateRequest{
+			Id:         batchID,
+			Price:      &pb.Money{CurrencyCode: "EUR", Units: 5},
+			Version:    1,
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "price", "price.currency_code"}},
+		})
+		s.NoError(err)
+
+		res, err := s.client.GetProduct(s.ctx, &pb.GetProductRequest{Id: batchID})
+		s.NoError(err)
+		s.Empty(res.Name, "Name in the mask should be cleared")
+		s.Equal(int64(5), res.Price.Units)
+		s.Equal("EUR", res.Price.CurrencyCode)
+		s.Equal(uint64(2), res.Version)
+	})
+
+	s.Run("Update Product Field Mask Invalid", func() {
+		_, err := s.client.UpdateProduct(s.ctx, &pb.UpdateRequest{
+			Id:         batchID,
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"price.currency_code"}},
+		})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+		s.Contains(err.Error(), model.ErrorInvalidProduct.Error())
+
+		_, err = s.client.UpdateProduct(s.ctx, &pb.UpdateRequest{
+			Id:         batchID,
+			Name:       s.stringToPtr("Batch Product 2"),
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"id"}},
+		})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+		s.Contains(err.Error(), model.ErrorInvalidProduct.Error())
+	})
+
+	s.Run("Update Products Batch Field Mask", func() {
+		res, err := s.client.UpdateProducts(s.ctx, &pb.UpdateProductsRequest{Items: []*pb.UpdateRequest{
+			{Id: batchID, Name: s.stringToPtr("Batch Product 2"), Version: 2,
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name", "price"}}},
+		}})
+		s.Require().NoError(err)
+		s.Require().Len(res.Results, 1)
+		s.Empty(res.Results[0].Error)
+
+		prod, err := s.client.GetProduct(s.ctx, &pb.GetProductRequest{Id: batchID})
+		s.NoError(err)
+		s.Equal("Batch Product 2", prod.Name)
+		s.Zero(prod.Price.Units, "Price in the mask should be cleared")
+		s.Zero(prod.Price.Nanos, "Price in the mask should be cleared")
+		s.Equal("EUR", prod.Price.CurrencyCode, "Currency out of the mask should be kept")
+		s.Equal(uint64(3), prod.Version)
+	})
+
+	s.Run("Update Product Field Mask Price Currency", func() {
+		_, err := s.client.UpdateProduct(s.ctx, &pb.UpdateRequest{
+			Id:         batchID,
+			Price:      &pb.Money{CurrencyCode: "GBP", Units: 7},
+			Version:    3,
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"price"}},
+		})
+		s.NoError(err)
+
+		prod, err := s.client.GetProduct(s.ctx, &pb.GetProductRequest{Id: batchID})
+		s.NoError(err)
+		s.Equal(int64(7), prod.Price.Units)
+		s.Equal("GBP", prod.Price.CurrencyCode, "Price path should cover the currency")
+		s.Equal(uint64(4), prod.Version)
+	})
+
+	s.Run("Delete Products Batch", func() {
+		res, err := s.client.DeleteProducts(s.ctx, &pb.DeleteProductsRequest{Items: []*pb.DeleteRequest{
+			{Id: "batch-1", Version: 2},
+			{Id: batchID},
+			{Id: "batch-1"},
+		}})
+		s.Require().NoError(err)
+		s.Require().Len(res.Results, 3)
+		s.Empty(res.Results[0].Error)
+		s.NotZero(res.Results[0].Product.GetDeletedAt(), "Deleted product should be returned")
+		s.Empty(res.Results[1].Error)
+		s.Contains(res.Results[2].Error, model.ErrorNoRowsDeleted.Error())
+	})
+
+	s.Run("Purge Products After Batch", func() {
+		res, err := s.client.PurgeProducts(s.ctx, &pb.PurgeRequest{Days: 0})
+		s.NoError(err)
+		s.Equal(int64(2), res.Purged)
+	})
+
+	s.Run("Upsert Product", func() {
+		res, err := s.client.UpsertProduct(s.ctx, &pb.UpsertRequest{Id: "upsert-1", Name: "Synced Product",
+			Price: s.money(5, 250000000)})
+		s.Require().NoError(err)
+		s.True(res.Created)
+		s.Equal("upsert-1", res.Product.Id)
+		s.Equal(uint64(1), res.Product.Version)
+
+		res, err = s.client.UpsertProduct(s.ctx, &pb.UpsertRequest{Id: "upsert-1", Name: "Synced Product",
+			Price: s.money(5, 250000000)})
+		s.Require().NoError(err)
+		s.False(res.Created)
+		s.Equal(uint64(1), res.Product.Version, "Repeated upsert should keep the version")
+
+		res, err = s.client.UpsertProduct(s.ctx, &pb.UpsertRequest{Id: "upsert-1", Name: "Synced Product 2",
+			Price: &pb.Money{CurrencyCode: "EUR", Units: 6}})
+		s.Require().NoError(err)
+		s.False(res.Created)
+		s.Equal("Synced Product 2", res.Product.Name)
+		s.Equal("EUR", res.Product.Price.CurrencyCode)
+		s.Equal(uint64(2), res.Product.Version)
+	})
+
+	s.Run("Upsert Product Invalid", func() {
+		_, err := s.client.UpsertProduct(s.ctx, &pb.UpsertRequest{Id: "upsert-1", Name: "Synced Product"})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func (s *GrpcSuite) Test_WatchProducts() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	stream := s.watch(ctx, nil)
+
+	added, err := s.client.AddProduct(s.ctx, &pb.AddRequest{Name: "Watched Product", Price: s.money(1, 0)})
+	s.Require().NoError(err)
+	_, err = s.client.UpdateProduct(s.ctx, &pb.UpdateRequest{Id: added.Id, Name: s.stringToPtr("Watched Renamed")})
+	s.Require().NoError(err)
+	_, err = s.client.DeleteProduct(s.ctx, &pb.DeleteRequest{Id: added.Id})
+	s.Require().NoError(err)
+
+	var created *pb.ProductEvent
+	s.Run("Live Events", func() {
+		created, err = stream.Recv()
+		s.Require().NoError(err)
+		s.Equal(pb.ProductEventType_PRODUCT_EVENT_TYPE_CREATED, created.Type)
+		s.True(proto.Equal(added, created.Product))
+
+		updated, err := stream.Recv()
+		s.Require().NoError(err)
+		s.Equal(created.Sequence+1, updated.Sequence)
+		s.Equal(pb.ProductEventType_PRODUCT_EVENT_TYPE_UPDATED, updated.Type)
+		s.Equal("Watched Renamed", updated.Product.Name)
+
+		deleted, err := stream.Recv()
+		s.Require().NoError(err)
+		s.Equal(pb.ProductEventType_PRODUCT_EVENT_TYPE_DELETED, deleted.Type)
+		s.Equal(added.Id, deleted.Product.Id)
+	})
+
+	s.Run("Resume", func() {
+		resumed := s.watch(ctx, proto.Uint64(created.Sequence))
+		for _, typ := range []pb.ProductEventType{pb.ProductEventType_PRODUCT_EVENT_TYPE_UPDATED,
+			pb.ProductEventType_PRODUCT_EVENT_TYPE_DELETED} {
+			event, err := resumed.Recv()
+			s.Require().NoError(err)
+			s.Equal(typ, event.Type)
+			s.Equal(added.Id, event.Product.Id)
+		}
+	})
+
+	s.Run("Resume Ahead", func() {
+		ahead, err := s.client.WatchProducts(ctx, &pb.WatchProductsRequest{ResumeAfter: proto.Uint64(math.MaxUint64)})
+		s.Require().NoError(err)
+		_, err = ahead.Recv()
+		s.Equal(codes.InvalidArgument, status.Code(err))
+	})
+}
+
+// watch waits for the subscription to start
+func (s *GrpcSuite) watch(ctx context.Context, after *uint64) pb.ProductService_WatchProductsClient {
+	stream, err := s.client.WatchProducts(ctx, &pb.WatchProductsRequest{ResumeAfter: after})
+	s.Require().NoError(err)
+	_, err = stream.Header()
+	s.Require().NoError(err)
+	return stream
 }
 
 func (s *GrpcSuite) stringToPtr(val string) *string {
 	return &val
 }
 
-func (s *GrpcSuite) floatToPtr(val float64) *float64 {
-	return &val
+func (s *GrpcSuite) money(units int64, nanos int32) *pb.Money {
+	return &pb.Money{CurrencyCode: "USD", Units: units, Nanos: nanos}
 }
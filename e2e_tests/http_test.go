@@ -1,16 +1,24 @@
 package e2e_tests
 
 import (
+	"bufio"
 	"bytes"
-	"database/sql"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
+	pb "github.com/aleksandrzhukovskii/go-template/internal/service/grpc"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/suite"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
 )
 
 type HTTPSuite struct {
@@ -41,14 +49,55 @@ func (s *HTTPSuite) Test_SwaggerConfig() {
 	res.Body.Close()
 }
 
+func (s *HTTPSuite) Test_Problem() {
+	req, err := http.NewRequest(http.MethodGet, "http://app-test:8000/get?id=missing", nil)
+	s.NoError(err)
+	req.Header.Set(model.RequestIDHeader, "problem-test")
+	res, err := http.DefaultClient.Do(req)
+	s.NoError(err)
+	defer res.Body.Close()
+	s.Equal(http.StatusNotFound, res.StatusCode)
+	s.Equal(model.ProblemContentType, res.Header.Get("Content-Type"))
+	s.Equal("problem-test", res.Header.Get(model.RequestIDHeader))
+	var problem model.Problem
+	s.NoError(json.NewDecoder(res.Body).Decode(&problem))
+	s.Equal(model.Problem{
+		Type:      "urn:problem-type:not-found",
+		Title:     "Not Found",
+		Status:    http.StatusNotFound,
+		Detail:    model.ErrorNotFound.Error(),
+		Instance:  "/get?id=missing",
+		RequestID: "problem-test",
+	}, problem)
+
+	res, err = http.PostForm("http://app-test:8000/add", url.Values{"name": {"Product"}, "price": {"-1"}, "currency": {"USD"}})
+	s.NoError(err)
+	defer res.Body.Close()
+	s.Equal(http.StatusBadRequest, res.StatusCode)
+	s.Equal(model.ProblemContentType, res.Header.Get("Content-Type"))
+	problem = model.Problem{}
+	s.NoError(json.NewDecoder(res.Body).Decode(&problem))
+	s.Equal("urn:problem-type:invalid-argument", problem.Type)
+	s.Equal(http.StatusBadRequest, problem.Status)
+	s.NotEmpty(problem.RequestID, "Request ID should be generated")
+	s.Equal(res.Header.Get(model.RequestIDHeader), problem.RequestID)
+	s.Equal([]model.ProblemField{{Field: "price", Detail: "invalid product: price must not be negative"}},
+		problem.Errors)
+}
+
 func (s *HTTPSuite) Test_Product() {
 	var productID string
 	var productID2 string
+	var firstPageID string
+	var cursor string
+	var batchID string
 	tests := []struct {
 		name       string
 		method     string
 		path       string
 		params     map[string]any
+		body       any
+		mergePatch bool
 		wantStatus int
 		check      func(body io.Reader)
 	}{
@@ -56,13 +105,64 @@ func (s *HTTPSuite) Test_Product() {
 			name:       "Add Product",
 			method:     http.MethodPost,
 			path:       "/add",
+			params:     map[string]any{"name": s.stringToPtr("New Product"), "price": "10.50", "currency": "USD"},
 			wantStatus: http.StatusOK,
 			check: func(body io.Reader) {
 				result := s.getMap(body)
 				s.NotEmpty(result["id"], "Product ID should not be empty")
+				s.Equal("New Product", result["name"], "Stored product name should match")
+				s.Equal("10.5", result["price"], "Stored product price should match")
+				s.Equal("USD", result["currency"], "Stored product currency should match")
+				s.NotEmpty(result["created_at"], "Stored product create time should be filled")
+				s.Equal(1.0, result["version"], "Stored product version should be 1")
 				productID = result["id"].(string)
 			},
 		},
+		{
+			name:       "Add Product Invalid",
+			method:     http.MethodPost,
+			path:       "/add",
+			params:     map[string]any{"price": 10.5, "currency": "USD"},
+			wantStatus: http.StatusBadRequest,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Contains(result["detail"], model.ErrorInvalidProduct.Error(), "Add should fail")
+			},
+		},
+		{
+			name:       "Add Product Too Precise",
+			method:     http.MethodPost,
+			path:       "/add",
+			params:     map[string]any{"name": s.stringToPtr("New Product"), "price": "0.00001", "currency": "USD"},
+			wantStatus: http.StatusBadRequest,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Contains(result["detail"], model.ErrorInvalidProduct.Error(), "Add should fail")
+			},
+		},
+		{
+			name:       "Add Product Without Currency",
+			method:     http.MethodPost,
+			path:       "/add",
+			params:     map[string]any{"name": s.stringToPtr("New Product"), "price": 10.5},
+			wantStatus: http.StatusBadRequest,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Contains(result["detail"], model.ErrorInvalidProduct.Error(), "Add should fail")
+			},
+		},
+		{
+			name:   "Add Product Already Exists",
+			method: http.MethodPost,
+			path:   "/add",
+			params: map[string]any{"id": &productID, "name": s.stringToPtr("New Product"), "price": 10.5,
+				"currency": "USD"},
+			wantStatus: http.StatusConflict,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal(model.ErrorAlreadyExists.Error(), result["detail"], "Add should fail")
+			},
+		},
 		{
 			name:       "Update Product Name",
 			method:     http.MethodPut,
@@ -78,13 +178,35 @@ func (s *HTTPSuite) Test_Product() {
 			name:       "Update Product Price",
 			method:     http.MethodPut,
 			path:       "/update",
-			params:     map[string]any{"id": &productID, "price": 99.99},
+			params:     map[string]any{"id": &productID, "price": "99.99", "currency": "EUR", "version": 2},
 			wantStatus: http.StatusOK,
 			check: func(body io.Reader) {
 				result := s.getMap(body)
 				s.Equal("Product updated", result["msg"], "Update should be successful")
 			},
 		},
+		{
+			name:       "Update Product Stale Version",
+			method:     http.MethodPut,
+			path:       "/update",
+			params:     map[string]any{"id": &productID, "price": 1, "version": 2},
+			wantStatus: http.StatusConflict,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal(model.ErrorVersionConflict.Error(), result["detail"], "Update should fail")
+			},
+		},
+		{
+			name:       "Update Product Invalid Currency",
+			method:     http.MethodPut,
+			path:       "/update",
+			params:     map[string]any{"id": &productID, "name": s.stringToPtr("Updated Product"), "currency": "usd"},
+			wantStatus: http.StatusBadRequest,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Contains(result["detail"], model.ErrorInvalidProduct.Error(), "Update should fail")
+			},
+		},
 		{
 			name:       "Update Product Nothing to update",
 			method:     http.MethodPut,
@@ -93,7 +215,7 @@ func (s *HTTPSuite) Test_Product() {
 			wantStatus: http.StatusBadRequest,
 			check: func(body io.Reader) {
 				result := s.getMap(body)
-				s.Equal(model.ErrorNoUpdateParams.Error(), result["error"], "Update should fail")
+				s.Equal(model.ErrorNoUpdateParams.Error(), result["detail"], "Update should fail")
 			},
 		},
 		{
@@ -101,10 +223,10 @@ func (s *HTTPSuite) Test_Product() {
 			method:     http.MethodPut,
 			path:       "/update",
 			params:     map[string]any{"id": s.stringToPtr("123"), "price": 99.99},
-			wantStatus: http.StatusBadRequest,
+			wantStatus: http.StatusNotFound,
 			check: func(body io.Reader) {
 				result := s.getMap(body)
-				s.Equal(model.ErrorNoRowsUpdated.Error(), result["error"], "Update should fail")
+				s.Equal(model.ErrorNoRowsUpdated.Error(), result["detail"], "Update should fail")
 			},
 		},
 		{
@@ -117,21 +239,52 @@ func (s *HTTPSuite) Test_Product() {
 				result := s.getMap(body)
 				s.Equal(productID, result["id"], "Retrieved product ID should match")
 				s.Equal("Updated Product", result["name"], "Retrieved product name should match")
-				s.Equal(99.99, result["price"], "Retrieved product price should match")
+				s.Equal("99.99", result["price"], "Retrieved product price should match")
+				s.Equal("EUR", result["currency"], "Retrieved product currency should match")
 				s.NotEmpty(result["created_at"], "Retrieved product create time should be filled")
+				s.Equal(3.0, result["version"], "Retrieved product version should match")
+			},
+		},
+		{
+			name:       "Get Product As Of Now",
+			method:     http.MethodGet,
+			path:       "/get",
+			params:     map[string]any{"id": &productID, "as_of": uint32(math.MaxUint32)},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal("Updated Product", result["name"], "Latest product name should match")
+				s.Equal(3.0, result["version"], "Latest product version should match")
 			},
 		},
+		{
+			name:       "Get Product As Of Before Creation",
+			method:     http.MethodGet,
+			path:       "/get",
+			params:     map[string]any{"id": &productID, "as_of": 1},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Get Product As Of Invalid",
+			method:     http.MethodGet,
+			path:       "/get",
+			params:     map[string]any{"id": &productID, "as_of": "yesterday"},
+			wantStatus: http.StatusBadRequest,
+		},
 		{
 			name:       "Get All Products",
 			method:     http.MethodGet,
 			path:       "/get_all",
 			wantStatus: http.StatusOK,
 			check: func(body io.Reader) {
-				result := s.getSlice(body)
+				page := s.getPage(body)
+				s.False(page.HasMore, "There should be no more products")
+				s.Empty(page.NextCursor, "Next cursor should be empty on the last page")
+				result := page.Items
 				s.Len(result, 1, "Result should contain 1 product")
 				s.Equal(productID, result[0]["id"], "Retrieved product ID should match")
 				s.Equal("Updated Product", result[0]["name"], "Retrieved product name should match")
-				s.Equal(99.99, result[0]["price"], "Retrieved product price should match")
+				s.Equal("99.99", result[0]["price"], "Retrieved product price should match")
 				s.NotEmpty(result[0]["created_at"], "Retrieved product create time should be filled")
 			},
 		},
@@ -139,6 +292,7 @@ func (s *HTTPSuite) Test_Product() {
 			name:       "Add Product Fill More",
 			method:     http.MethodPost,
 			path:       "/add",
+			params:     map[string]any{"sample": true},
 			wantStatus: http.StatusOK,
 			check: func(body io.Reader) {
 				result := s.getMap(body)
@@ -152,26 +306,227 @@ func (s *HTTPSuite) Test_Product() {
 			path:       "/get_all",
 			wantStatus: http.StatusOK,
 			check: func(body io.Reader) {
-				result := s.getSlice(body)
+				result := s.getPage(body).Items
 				s.Len(result, 2, "Result should contain 2 products")
 			},
 		},
 		{
-			name:       "Delete Product",
+			name:       "Get All Products First Page",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"page_size": 1},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				page := s.getPage(body)
+				s.Len(page.Items, 1, "Result should contain 1 product")
+				s.True(page.HasMore, "There should be more products")
+				s.NotEmpty(page.NextCursor, "Next cursor should be filled")
+				firstPageID = page.Items[0]["id"].(string)
+				cursor = page.NextCursor
+			},
+		},
+		{
+			name:       "Get All Products Second Page",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"page_size": 1, "cursor": &cursor},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				page := s.getPage(body)
+				s.Len(page.Items, 1, "Result should contain 1 product")
+				s.False(page.HasMore, "There should be no more products")
+				s.NotEqual(firstPageID, page.Items[0]["id"], "Pages should not overlap")
+			},
+		},
+		{
+			name:       "Get All Products Invalid Cursor",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"cursor": "%%%"},
+			wantStatus: http.StatusBadRequest,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Contains(result["detail"], model.ErrorInvalidPage.Error(), "Get all should fail")
+			},
+		},
+		{
+			name:       "Get All Products Cursor Of Another Sort",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"cursor": &cursor, "sort": "price"},
+			wantStatus: http.StatusBadRequest,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Contains(result["detail"], model.ErrorInvalidPage.Error(), "Get all should fail")
+			},
+		},
+		{
+			name:       "Get All Products Filter By Name",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"name": "UPDATED"},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getPage(body).Items
+				s.Len(result, 1, "Result should contain 1 product")
+				s.Equal(productID, result[0]["id"], "Filtered product ID should match")
+			},
+		},
+		{
+			name:       "Get All Products Filter By Price",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"min_price": 1000},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getPage(body).Items
+				s.Empty(result, "Result should be empty")
+			},
+		},
+		{
+			name:       "Get All Products Filter By Exact Price",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"min_price": "99.99", "max_price": "99.990"},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getPage(body).Items
+				s.Len(result, 1, "Result should contain 1 product")
+				s.Equal(productID, result[0]["id"], "Filtered product ID should match")
+			},
+		},
+		{
+			name:       "Get All Products Filter Past Exact Price",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"min_price": "99.99000000000000001", "max_price": "1000"},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				// The bound is the same float as the price, but not the same decimal
+				for _, item := range s.getPage(body).Items {
+					s.NotEqual(productID, item["id"], "Product should be below the min price")
+				}
+			},
+		},
+		{
+			name:       "Get All Products Sorted By Price",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"sort": "-price"},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getPage(body).Items
+				s.Len(result, 2, "Result should contain 2 products")
+				s.True(decimal.RequireFromString(result[0]["price"].(string)).
+					GreaterThanOrEqual(decimal.RequireFromString(result[1]["price"].(string))),
+					"Products should be sorted by price")
+			},
+		},
+		{
+			name:       "Get All Products Sorted Paged",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"sort": "-price", "page_size": 1},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				page := s.getPage(body)
+				s.Len(page.Items, 1, "Result should contain 1 product")
+				s.True(page.HasMore, "There should be more products")
+				firstPageID = page.Items[0]["id"].(string)
+				cursor = page.NextCursor
+			},
+		},
+		{
+			name:       "Get All Products Sorted Next Page",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"sort": "-price", "page_size": 1, "cursor": &cursor},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				page := s.getPage(body)
+				s.Len(page.Items, 1, "Result should contain 1 product")
+				s.False(page.HasMore, "There should be no more products")
+				s.NotEqual(firstPageID, page.Items[0]["id"], "Pages should not overlap")
+			},
+		},
+		{
+			name:       "Get All Products Invalid Sort",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"sort": "weight"},
+			wantStatus: http.StatusBadRequest,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Contains(result["detail"], model.ErrorInvalidQuery.Error(), "Get all should fail")
+			},
+		},
+		{
+			name:       "Get All Products Invalid Price Range",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"min_price": 10, "max_price": 1},
+			wantStatus: http.StatusBadRequest,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Contains(result["detail"], model.ErrorInvalidQuery.Error(), "Get all should fail")
+			},
+		},
+		{
+			name:       "Search Products",
+			method:     http.MethodGet,
+			path:       "/search",
+			params:     map[string]any{"q": "upd PROD"},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getPage(body).Items
+				s.Require().Len(result, 1, "Result should contain 1 product")
+				s.Equal(productID, result[0]["id"], "Found product ID should match")
+			},
+		},
+		{
+			name:       "Search Products Middle Of Word",
+			method:     http.MethodGet,
+			path:       "/search",
+			params:     map[string]any{"q": "pdated"},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				s.Empty(s.getPage(body).Items, "Terms should only match the beginnings of the words")
+			},
+		},
+		{
+			name:       "Search Products No Words",
+			method:     http.MethodGet,
+			path:       "/search",
+			params:     map[string]any{"q": "?!"},
+			wantStatus: http.StatusBadRequest,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Contains(result["detail"], model.ErrorInvalidQuery.Error(), "Search should fail")
+			},
+		},
+		{
+			name:       "Search Products Invalid Limit",
+			method:     http.MethodGet,
+			path:       "/search",
+			params:     map[string]any{"q": "product", "limit": "many"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Delete Product Stale Version",
 			method:     http.MethodDelete,
 			path:       "/delete",
-			params:     map[string]any{"id": &productID},
-			wantStatus: http.StatusOK,
+			params:     map[string]any{"id": &productID, "version": 1},
+			wantStatus: http.StatusConflict,
 			check: func(body io.Reader) {
 				result := s.getMap(body)
-				s.Equal("Product deleted", result["msg"], "Delete should be successful")
+				s.Equal(model.ErrorVersionConflict.Error(), result["detail"], "Delete should fail")
 			},
 		},
 		{
-			name:       "Delete Product Second",
+			name:       "Delete Product",
 			method:     http.MethodDelete,
 			path:       "/delete",
-			params:     map[string]any{"id": &productID2},
+			params:     map[string]any{"id": &productID, "version": 3},
 			wantStatus: http.StatusOK,
 			check: func(body io.Reader) {
 				result := s.getMap(body)
@@ -179,35 +534,498 @@ func (s *HTTPSuite) Test_Product() {
 			},
 		},
 		{
-			name:       "Get Product Not Exist",
+			name:       "Get All Products Without Deleted",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getPage(body).Items
+				s.Len(result, 1, "Result should contain 1 product")
+				s.Equal(productID2, result[0]["id"], "Deleted product should be hidden")
+			},
+		},
+		{
+			name:       "Search Products Deleted",
+			method:     http.MethodGet,
+			path:       "/search",
+			params:     map[string]any{"q": "updated"},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				s.Empty(s.getPage(body).Items, "Deleted product should not be found")
+			},
+		},
+		{
+			name:       "Get All Products Include Deleted",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"include_deleted": true},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getPage(body).Items
+				s.Len(result, 2, "Result should contain 2 products")
+				for _, item := range result {
+					if item["id"] == productID {
+						s.NotEmpty(item["deleted_at"], "Deleted product delete time should be filled")
+						s.Equal(4.0, item["version"], "Delete should increment the version")
+					} else {
+						s.Empty(item["deleted_at"], "Live product delete time should be empty")
+					}
+				}
+			},
+		},
+		{
+			name:       "Restore Product",
+			method:     http.MethodPost,
+			path:       "/restore",
+			params:     map[string]any{"id": &productID},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal("Product restored", result["msg"], "Restore should be successful")
+			},
+		},
+		{
+			name:       "Restore Product Not Deleted",
+			method:     http.MethodPost,
+			path:       "/restore",
+			params:     map[string]any{"id": &productID},
+			wantStatus: http.StatusNotFound,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal(model.ErrorNoRowsRestored.Error(), result["detail"], "Restore should fail")
+			},
+		},
+		{
+			name:       "Get Product Restored",
 			method:     http.MethodGet,
 			path:       "/get",
 			params:     map[string]any{"id": &productID},
-			wantStatus: http.StatusBadRequest,
+			wantStatus: http.StatusOK,
 			check: func(body io.Reader) {
 				result := s.getMap(body)
-				s.Equal(sql.ErrNoRows.Error(), result["error"], "Get should fail")
+				s.Equal("Updated Product", result["name"], "Restored product name should match")
+				s.Equal(5.0, result["version"], "Restore should increment the version")
+				s.Empty(result["deleted_at"], "Restored product delete time should be empty")
 			},
 		},
 		{
-			name:       "Delete Product Not Exist",
+			name:       "Delete Product Restored",
 			method:     http.MethodDelete,
 			path:       "/delete",
-			params:     map[string]any{"id": &productID},
-			wantStatus: http.StatusBadRequest,
+			params:     map[string]any{"id": &productID, "version": 5},
+			wantStatus: http.StatusOK,
 			check: func(body io.Reader) {
 				result := s.getMap(body)
-				s.Equal(model.ErrorNoRowsDeleted.Error(), result["error"], "Delete should fail")
+				s.Equal("Product deleted", result["msg"], "Delete should be successful")
 			},
 		},
 		{
-			name:       "Get All Products Empty",
-			method:     http.MethodGet,
-			path:       "/get_all",
+			name:       "Delete Product Second",
+			method:     http.MethodDelete,
+			path:       "/delete",
+			params:     map[string]any{"id": &productID2},
 			wantStatus: http.StatusOK,
 			check: func(body io.Reader) {
-				result := s.getSlice(body)
-				s.Empty(result, "Result should be empty")
+				result := s.getMap(body)
+				s.Equal("Product deleted", result["msg"], "Delete should be successful")
+			},
+		},
+		{
+			name:       "Get Product Not Exist",
+			method:     http.MethodGet,
+			path:       "/get",
+			params:     map[string]any{"id": &productID},
+			wantStatus: http.StatusNotFound,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal(model.ErrorNotFound.Error(), result["detail"], "Get should fail")
+			},
+		},
+		{
+			name:       "Delete Product Not Exist",
+			method:     http.MethodDelete,
+			path:       "/delete",
+			params:     map[string]any{"id": &productID},
+			wantStatus: http.StatusNotFound,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal(model.ErrorNoRowsDeleted.Error(), result["detail"], "Delete should fail")
+			},
+		},
+		{
+			name:       "Get Product As Of Now Deleted",
+			method:     http.MethodGet,
+			path:       "/get",
+			params:     map[string]any{"id": &productID, "as_of": uint32(math.MaxUint32)},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Get Product History",
+			method:     http.MethodGet,
+			path:       "/history",
+			params:     map[string]any{"id": &productID},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				entries := s.getHistory(body)
+				s.Require().Len(entries, 6)
+				for i, op := range []string{"add", "update", "update", "delete", "restore", "delete"} {
+					s.Equal(op, entries[i]["operation"], "Operations should follow the changes")
+					s.Equal(float64(i+1), entries[i]["version"], "Versions should follow the changes")
+					s.Equal("tester", entries[i]["actor"], "Changes should be attributed to the caller")
+				}
+				s.Nil(entries[0]["before"], "First change should have no previous state")
+				s.Equal("New Product", entries[0]["after"].(map[string]any)["name"])
+				s.Equal("New Product", entries[1]["before"].(map[string]any)["name"])
+				s.Equal("Updated Product", entries[1]["after"].(map[string]any)["name"])
+				s.Equal("10.5", entries[2]["before"].(map[string]any)["price"])
+				s.Equal("99.99", entries[2]["after"].(map[string]any)["price"])
+				s.Equal("EUR", entries[2]["after"].(map[string]any)["currency"])
+				s.NotEmpty(entries[3]["after"].(map[string]any)["deleted_at"])
+				s.Empty(entries[4]["after"].(map[string]any)["deleted_at"])
+			},
+		},
+		{
+			name:       "Purge Products Invalid Days",
+			method:     http.MethodDelete,
+			path:       "/purge",
+			params:     map[string]any{"days": -1},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Purge Products Recently Deleted",
+			method:     http.MethodDelete,
+			path:       "/purge",
+			params:     map[string]any{"days": 1},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal(0.0, result["purged"], "Recently deleted products should be kept")
+			},
+		},
+		{
+			name:       "Purge Products",
+			method:     http.MethodDelete,
+			path:       "/purge",
+			params:     map[string]any{"days": 0},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal(2.0, result["purged"], "Deleted products should be purged")
+			},
+		},
+		{
+			name:       "Restore Product Purged",
+			method:     http.MethodPost,
+			path:       "/restore",
+			params:     map[string]any{"id": &productID},
+			wantStatus: http.StatusNotFound,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal(model.ErrorNoRowsRestored.Error(), result["detail"], "Restore should fail")
+			},
+		},
+		{
+			name:       "Get Product History Purged",
+			method:     http.MethodGet,
+			path:       "/history",
+			params:     map[string]any{"id": &productID},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				s.Empty(s.getHistory(body), "History should be purged along with the product")
+			},
+		},
+		{
+			name:       "Get All Products Empty",
+			method:     http.MethodGet,
+			path:       "/get_all",
+			params:     map[string]any{"include_deleted": true},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getPage(body).Items
+				s.Empty(result, "Result should be empty")
+			},
+		},
+		{
+			name:   "Add Products Batch",
+			method: http.MethodPost,
+			path:   "/batch",
+			body: []map[string]any{
+				{"id": "batch-1", "name": "Batch Product", "price": 1, "currency": "USD"},
+				{"name": "Batch Product 2", "price": "12345678901234.5678", "currency": "JPY"},
+				{"id": "batch-1", "name": "Duplicate Product", "price": 3, "currency": "USD"},
+				{"name": "Invalid Product", "price": -1, "currency": "USD"},
+			},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				results := s.getBatch(body)
+				s.Require().Len(results, 4)
+				s.Equal("batch-1", results[0]["id"])
+				s.Equal("Batch Product", results[0]["product"].(map[string]any)["name"])
+				s.Nil(results[0]["error"])
+				batchID = results[1]["id"].(string)
+				s.NotEmpty(batchID)
+				s.Nil(results[1]["error"])
+				s.Equal("12345678901234.5678", results[1]["product"].(map[string]any)["price"],
+					"Price should be kept exactly")
+				s.Contains(results[2]["error"], model.ErrorAlreadyExists.Error())
+				s.Nil(results[2]["product"])
+				s.Contains(results[3]["error"], model.ErrorInvalidProduct.Error())
+			},
+		},
+		{
+			name:       "Add Products Batch Empty",
+			method:     http.MethodPost,
+			path:       "/batch",
+			body:       []map[string]any{},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Add Products Batch Not An Array",
+			method:     http.MethodPost,
+			path:       "/batch",
+			body:       map[string]any{"name": "Batch Product", "price": 1},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Search Products Ranked",
+			method:     http.MethodGet,
+			path:       "/search",
+			params:     map[string]any{"q": "batch prod"},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getPage(body).Items
+				s.Require().Len(result, 2, "Result should contain 2 products")
+				s.Equal("batch-1", result[0]["id"], "Shorter name should rank first")
+				s.Equal(batchID, result[1]["id"], "Longer name should rank second")
+			},
+		},
+		{
+			name:       "Search Products Limited",
+			method:     http.MethodGet,
+			path:       "/search",
+			params:     map[string]any{"q": "batch", "limit": 1},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getPage(body).Items
+				s.Require().Len(result, 1, "Result should be limited")
+				s.Equal("batch-1", result[0]["id"], "Most relevant product should be kept")
+			},
+		},
+		{
+			name:   "Update Products Batch",
+			method: http.MethodPut,
+			path:   "/batch",
+			body: []map[string]any{
+				{"id": "batch-1", "price": 10, "version": 1},
+				{"id": &batchID, "name": "Renamed Product", "version": 5},
+				{"id": "missing", "price": 1},
+			},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				results := s.getBatch(body)
+				s.Require().Len(results, 3)
+				s.Nil(results[0]["error"])
+				s.Contains(results[1]["error"], model.ErrorVersionConflict.Error())
+				s.Contains(results[2]["error"], model.ErrorNoRowsUpdated.Error())
+			},
+		},
+		{
+			name:       "Patch Product",
+			method:     http.MethodPatch,
+			path:       "/update",
+			params:     map[string]any{"id": &batchID, "version": 1},
+			body:       map[string]any{"name": nil, "price": "5", "currency": "EUR"},
+			mergePatch: true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Get Product Patched",
+			method:     http.MethodGet,
+			path:       "/get",
+			params:     map[string]any{"id": &batchID},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Nil(result["name"], "Name should be cleared")
+				s.Equal("5", result["price"], "Price should be set")
+				s.Equal("EUR", result["currency"], "Currency should be set")
+				s.Equal(2.0, result["version"], "Version should be incremented")
+			},
+		},
+		{
+			name:       "Patch Product Stale Version",
+			method:     http.MethodPatch,
+			path:       "/update",
+			params:     map[string]any{"id": &batchID, "version": 1},
+			body:       map[string]any{"name": "Batch Product 2"},
+			mergePatch: true,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "Patch Product Clear Currency",
+			method:     http.MethodPatch,
+			path:       "/update",
+			params:     map[string]any{"id": &batchID},
+			body:       map[string]any{"currency": nil},
+			mergePatch: true,
+			wantStatus: http.StatusBadRequest,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Contains(result["detail"], model.ErrorInvalidProduct.Error(), "Patch should fail")
+			},
+		},
+		{
+			name:       "Patch Product Nothing to update",
+			method:     http.MethodPatch,
+			path:       "/update",
+			params:     map[string]any{"id": &batchID},
+			body:       map[string]any{},
+			mergePatch: true,
+			wantStatus: http.StatusBadRequest,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Contains(result["detail"], model.ErrorNoUpdateParams.Error(), "Patch should fail")
+			},
+		},
+		{
+			name:   "Update Products Batch Clear Price",
+			method: http.MethodPut,
+			path:   "/batch",
+			body: []map[string]any{
+				{"id": &batchID, "name": "Batch Product 2", "price": nil, "version": 2},
+			},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				results := s.getBatch(body)
+				s.Require().Len(results, 1)
+				s.Nil(results[0]["error"])
+			},
+		},
+		{
+			name:       "Get Product Batch Cleared",
+			method:     http.MethodGet,
+			path:       "/get",
+			params:     map[string]any{"id": &batchID},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal("Batch Product 2", result["name"], "Name should be set")
+				s.Equal("0", result["price"], "Price should be cleared")
+				s.Equal("EUR", result["currency"], "Currency should be kept")
+				s.Equal(3.0, result["version"], "Version should be incremented")
+			},
+		},
+		{
+			name:   "Delete Products Batch",
+			method: http.MethodDelete,
+			path:   "/batch",
+			body: []map[string]any{
+				{"id": "batch-1", "version": 2},
+				{"id": &batchID},
+				{"id": "batch-1"},
+			},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				results := s.getBatch(body)
+				s.Require().Len(results, 3)
+				s.Nil(results[0]["error"])
+				s.Nil(results[1]["error"])
+				s.Contains(results[2]["error"], model.ErrorNoRowsDeleted.Error())
+			},
+		},
+		{
+			name:       "Purge Products After Batch",
+			method:     http.MethodDelete,
+			path:       "/purge",
+			params:     map[string]any{"days": 0},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal(float64(2), result["purged"])
+			},
+		},
+		{
+			name:       "Upsert Product Created",
+			method:     http.MethodPut,
+			path:       "/products/upsert-1",
+			params:     map[string]any{"name": s.stringToPtr("Synced Product"), "price": "5.25", "currency": "USD"},
+			wantStatus: http.StatusCreated,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal("upsert-1", result["id"], "Product ID should be taken from the path")
+				s.Equal("5.25", result["price"], "Stored product price should match")
+				s.Equal(1.0, result["version"], "Created product version should be 1")
+			},
+		},
+		{
+			name:       "Upsert Product Unchanged",
+			method:     http.MethodPut,
+			path:       "/products/upsert-1",
+			params:     map[string]any{"name": s.stringToPtr("Synced Product"), "price": "5.250", "currency": "USD"},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal("Synced Product", result["name"], "Stored product name should match")
+				s.Equal(1.0, result["version"], "Repeated upsert should keep the version")
+			},
+		},
+		{
+			name:       "Upsert Product Replaced",
+			method:     http.MethodPut,
+			path:       "/products/upsert-1",
+			params:     map[string]any{"name": s.stringToPtr("Synced Product 2"), "price": "6", "currency": "EUR"},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Equal("Synced Product 2", result["name"], "Name should be replaced")
+				s.Equal("6", result["price"], "Price should be replaced")
+				s.Equal("EUR", result["currency"], "Currency should be replaced")
+				s.Equal(2.0, result["version"], "Version should be incremented")
+			},
+		},
+		{
+			name:       "Upsert Product Invalid",
+			method:     http.MethodPut,
+			path:       "/products/upsert-1",
+			params:     map[string]any{"name": s.stringToPtr("Synced Product 2"), "currency": "EUR"},
+			wantStatus: http.StatusBadRequest,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Contains(result["detail"], model.ErrorInvalidProduct.Error(), "Upsert should fail")
+			},
+		},
+		{
+			name:       "Delete Product Upserted",
+			method:     http.MethodDelete,
+			path:       "/delete",
+			params:     map[string]any{"id": "upsert-1", "version": 2},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Upsert Product Restored",
+			method:     http.MethodPut,
+			path:       "/products/upsert-1",
+			params:     map[string]any{"name": s.stringToPtr("Synced Product 2"), "price": "6", "currency": "EUR"},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				result := s.getMap(body)
+				s.Nil(result["deleted_at"], "Product should be restored")
+				s.Equal(4.0, result["version"], "Version should be incremented")
+			},
+		},
+		{
+			name:       "Get Product History Upserted",
+			method:     http.MethodGet,
+			path:       "/history",
+			params:     map[string]any{"id": "upsert-1"},
+			wantStatus: http.StatusOK,
+			check: func(body io.Reader) {
+				entries := s.getHistory(body)
+				s.Require().Len(entries, 4)
+				for i, op := range []model.Operation{model.OperationAdd, model.OperationUpdate,
+					model.OperationDelete, model.OperationUpdate} {
+					s.Equal(string(op), entries[i]["operation"])
+				}
 			},
 		},
 	}
@@ -226,13 +1044,25 @@ func (s *HTTPSuite) Test_Product() {
 				}
 			}
 
-			if tc.method == http.MethodGet || tc.method == http.MethodDelete {
+			if tc.body != nil {
+				var data []byte
+				data, err = json.Marshal(tc.body)
+				s.NoError(err)
+				req, err = http.NewRequest(tc.method, "http://app-test:8000"+tc.path+"?"+params.Encode(),
+					bytes.NewReader(data))
+				s.NoError(err)
+				req.Header.Set("Content-Type", "application/json")
+				if tc.mergePatch {
+					req.Header.Set("Content-Type", model.MergePatchContentType)
+				}
+			} else if tc.method == http.MethodGet || tc.method == http.MethodDelete {
 				req, err = http.NewRequest(tc.method, "http://app-test:8000"+tc.path+"?"+params.Encode(), nil)
 			} else {
 				req, err = http.NewRequest(tc.method, "http://app-test:8000"+tc.path, bytes.NewBufferString(params.Encode()))
 				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 			}
 			s.NoError(err)
+			req.Header.Set(model.ActorHeader, "tester")
 
 			resp, err := http.DefaultClient.Do(req)
 			s.NoError(err)
@@ -245,6 +1075,342 @@ func (s *HTTPSuite) Test_Product() {
 	}
 }
 
+func (s *HTTPSuite) Test_ProductResource() {
+	send := func(method string, path string, contentType string, body string) *http.Response {
+		req, err := http.NewRequest(method, "http://app-test:8000"+path, bytes.NewBufferString(body))
+		s.NoError(err)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		res, err := http.DefaultClient.Do(req)
+		s.NoError(err)
+		return res
+	}
+
+	res := send(http.MethodPost, "/products", "application/x-www-form-urlencoded",
+		url.Values{"name": {"Resource Product"}, "price": {"12.30"}, "currency": {"USD"}}.Encode())
+	s.Equal(http.StatusCreated, res.StatusCode)
+	created := s.getMap(res.Body)
+	res.Body.Close()
+	id := created["id"].(string)
+	location := "/products/" + id
+	s.Equal(location, res.Header.Get("Location"))
+
+	res = send(http.MethodGet, location, "", "")
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("Resource Product", s.getMap(res.Body)["name"])
+	res.Body.Close()
+
+	res = send(http.MethodPatch, location+"?version=1", model.MergePatchContentType, `{"name": "Patched Product"}`)
+	s.Equal(http.StatusOK, res.StatusCode)
+	patched := s.getMap(res.Body)
+	res.Body.Close()
+	s.Equal("Patched Product", patched["name"])
+	s.Equal("12.3", patched["price"])
+	s.Equal(2.0, patched["version"])
+
+	res = send(http.MethodGet, "/products?name=patched", "", "")
+	s.Equal(http.StatusOK, res.StatusCode)
+	page := s.getPage(res.Body)
+	res.Body.Close()
+	s.Len(page.Items, 1)
+	s.Equal(id, page.Items[0]["id"])
+
+	res = send(http.MethodDelete, location+"?version=1", "", "")
+	s.Equal(http.StatusConflict, res.StatusCode)
+	res.Body.Close()
+
+	res = send(http.MethodDelete, location, "", "")
+	s.Equal(http.StatusNoContent, res.StatusCode)
+	data, err := io.ReadAll(res.Body)
+	s.NoError(err)
+	s.Empty(data)
+	res.Body.Close()
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		res = send(method, location, "", "")
+		s.Equal(http.StatusNotFound, res.StatusCode, method)
+		s.Equal(model.ProblemContentType, res.Header.Get("Content-Type"), method)
+		res.Body.Close()
+	}
+	res = send(http.MethodPatch, location, model.MergePatchContentType, `{"name": "Missing Product"}`)
+	s.Equal(http.StatusNotFound, res.StatusCode)
+	res.Body.Close()
+}
+
+func (s *HTTPSuite) Test_UpdateContent() {
+	send := func(contentType string, accept string, body []byte) *http.Response {
+		req, err := http.NewRequest(http.MethodPut, "http://app-test:8000/update", bytes.NewReader(body))
+		s.NoError(err)
+		req.Header.Set("Content-Type", contentType)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		res, err := http.DefaultClient.Do(req)
+		s.NoError(err)
+		return res
+	}
+	msgpack := &codec.MsgpackHandle{WriteExt: true}
+
+	res, err := http.DefaultClient.PostForm("http://app-test:8000/products",
+		url.Values{"name": {"Content Product"}, "price": {"10.00"}, "currency": {"USD"}})
+	s.NoError(err)
+	s.Equal(http.StatusCreated, res.StatusCode)
+	id := s.getMap(res.Body)["id"].(string)
+	res.Body.Close()
+
+	res = send("application/json", "", []byte(`{"id": "`+id+`", "version": 1, "name": "JSON Product"}`))
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Contains(res.Header.Get("Content-Type"), "application/json")
+	s.Equal("Product updated", s.getMap(res.Body)["msg"])
+	res.Body.Close()
+
+	var body []byte
+	s.NoError(codec.NewEncoderBytes(&body, msgpack).Encode(map[string]any{"id": id, "version": 2, "price": "15.50"}))
+	res = send("application/x-msgpack", "application/json;q=0.5, application/msgpack", body)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("application/msgpack", res.Header.Get("Content-Type"))
+	data, err := io.ReadAll(res.Body)
+	s.NoError(err)
+	res.Body.Close()
+	var msg map[string]string
+	s.NoError(codec.NewDecoderBytes(data, msgpack).Decode(&msg))
+	s.Equal("Product updated", msg["msg"])
+
+	body, err = proto.Marshal(&pb.UpdateRequest{Id: id, Name: proto.String("Proto Product"), Version: 3})
+	s.NoError(err)
+	res = send("application/x-protobuf", "application/x-protobuf", body)
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("application/x-protobuf", res.Header.Get("Content-Type"))
+	data, err = io.ReadAll(res.Body)
+	s.NoError(err)
+	res.Body.Close()
+	var updated pb.UpdateResponse
+	s.NoError(proto.Unmarshal(data, &updated))
+	s.Equal("Product updated", updated.GetMsg())
+
+	res, err = http.DefaultClient.Get("http://app-test:8000/products/" + id)
+	s.NoError(err)
+	product := s.getMap(res.Body)
+	res.Body.Close()
+	s.Equal("Proto Product", product["name"])
+	s.Equal("15.5", product["price"])
+	s.Equal(4.0, product["version"])
+
+	res = send("text/plain", "", []byte("name=Plain Product"))
+	s.Equal(http.StatusUnsupportedMediaType, res.StatusCode)
+	s.Equal(model.ProblemContentType, res.Header.Get("Content-Type"))
+	res.Body.Close()
+
+	res = send("application/json", "text/html", []byte(`{"id": "`+id+`", "name": "HTML Product"}`))
+	s.Equal(http.StatusNotAcceptable, res.StatusCode)
+	s.Equal(model.ProblemContentType, res.Header.Get("Content-Type"))
+	res.Body.Close()
+
+	res = send("application/json", "", []byte(`{"name": "Anonymous Product"}`))
+	s.Equal(http.StatusBadRequest, res.StatusCode)
+	s.Equal("id", s.getMap(res.Body)["errors"].([]any)[0].(map[string]any)["field"])
+	res.Body.Close()
+
+	res = send("application/json", "", []byte(`{"id": "`+id+`", "name": "JSON Product", "weight": 1}`))
+	s.Equal(http.StatusBadRequest, res.StatusCode, "Unknown fields should be rejected")
+	res.Body.Close()
+}
+
+func (s *HTTPSuite) Test_ProductETag() {
+	send := func(method string, path string, header string, etag string, body string) *http.Response {
+		req, err := http.NewRequest(method, "http://app-test:8000"+path, bytes.NewBufferString(body))
+		s.NoError(err)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if header != "" {
+			req.Header.Set(header, etag)
+		}
+		res, err := http.DefaultClient.Do(req)
+		s.NoError(err)
+		return res
+	}
+
+	res := send(http.MethodPost, "/products", "", "",
+		url.Values{"name": {"Tagged Product"}, "price": {"5.00"}, "currency": {"USD"}}.Encode())
+	s.Equal(http.StatusCreated, res.StatusCode)
+	id := s.getMap(res.Body)["id"].(string)
+	res.Body.Close()
+
+	res = send(http.MethodGet, "/get?id="+id, "", "", "")
+	s.Equal(http.StatusOK, res.StatusCode)
+	res.Body.Close()
+	etag := res.Header.Get(model.ETagHeader)
+	s.Regexp(`^"[^"]+"$`, etag)
+
+	res = send(http.MethodGet, "/products/"+id, "", "", "")
+	s.Equal(http.StatusOK, res.StatusCode)
+	res.Body.Close()
+	s.Equal(etag, res.Header.Get(model.ETagHeader))
+
+	for _, val := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		res = send(http.MethodGet, "/get?id="+id, model.IfNoneMatchHeader, val, "")
+		s.Equal(http.StatusNotModified, res.StatusCode, val)
+		s.Equal(etag, res.Header.Get(model.ETagHeader), val)
+		data, err := io.ReadAll(res.Body)
+		s.NoError(err)
+		s.Empty(data)
+		res.Body.Close()
+	}
+
+	for _, path := range []string{"/get_all?name=tagged", "/products?name=tagged"} {
+		res = send(http.MethodGet, path, "", "", "")
+		s.Equal(http.StatusOK, res.StatusCode, path)
+		res.Body.Close()
+		pageTag := res.Header.Get(model.ETagHeader)
+		s.NotEmpty(pageTag, path)
+		res = send(http.MethodGet, path, model.IfNoneMatchHeader, pageTag, "")
+		s.Equal(http.StatusNotModified, res.StatusCode, path)
+		res.Body.Close()
+	}
+
+	update := url.Values{"id": {id}, "name": {"Retagged Product"}}.Encode()
+	for _, val := range []string{`"stale"`, "W/" + etag} {
+		res = send(http.MethodPut, "/update", model.IfMatchHeader, val, update)
+		s.Equal(http.StatusPreconditionFailed, res.StatusCode, val)
+		s.Equal(model.ProblemContentType, res.Header.Get("Content-Type"), val)
+		res.Body.Close()
+	}
+	res = send(http.MethodPut, "/update", model.IfMatchHeader, etag, update)
+	s.Equal(http.StatusOK, res.StatusCode)
+	res.Body.Close()
+
+	res = send(http.MethodGet, "/get?id="+id, model.IfNoneMatchHeader, etag, "")
+	s.Equal(http.StatusOK, res.StatusCode)
+	s.Equal("Retagged Product", s.getMap(res.Body)["name"])
+	res.Body.Close()
+	newTag := res.Header.Get(model.ETagHeader)
+	s.NotEqual(etag, newTag)
+
+	res = send(http.MethodDelete, "/delete?id="+id, model.IfMatchHeader, etag, "")
+	s.Equal(http.StatusPreconditionFailed, res.StatusCode)
+	res.Body.Close()
+	res = send(http.MethodDelete, "/products/"+id, model.IfMatchHeader, newTag, "")
+	s.Equal(http.StatusNoContent, res.StatusCode)
+	res.Body.Close()
+	res = send(http.MethodDelete, "/products/"+id, model.IfMatchHeader, "*", "")
+	s.Equal(http.StatusPreconditionFailed, res.StatusCode)
+	res.Body.Close()
+}
+
+func (s *HTTPSuite) Test_ProductEvents() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := s.streamEvents(ctx, "")
+
+	res, err := http.PostForm("http://app-test:8000/products",
+		url.Values{"name": {"Streamed Product"}, "price": {"1.00"}, "currency": {"USD"}})
+	s.Require().NoError(err)
+	s.Equal(http.StatusCreated, res.StatusCode)
+	id := s.getMap(res.Body)["id"].(string)
+	res.Body.Close()
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		req, err := http.NewRequest(method, "http://app-test:8000/products/"+id,
+			bytes.NewBufferString(url.Values{"name": {"Streamed Renamed"}, "price": {"2.00"}, "currency": {"USD"}}.Encode()))
+		s.Require().NoError(err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		res, err = http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		s.Less(res.StatusCode, 300, method)
+		res.Body.Close()
+	}
+
+	var created serverEvent
+	s.Run("Live Events", func() {
+		created = s.readEvent(stream)
+		s.Equal("created", created.name)
+		s.Equal(id, created.data["id"])
+		s.Equal("Streamed Product", created.data["name"])
+
+		updated := s.readEvent(stream)
+		s.Equal(created.id+1, updated.id)
+		s.Equal("updated", updated.name)
+		s.Equal("Streamed Renamed", updated.data["name"])
+		s.Equal("2", updated.data["price"])
+
+		deleted := s.readEvent(stream)
+		s.Equal(created.id+2, deleted.id)
+		s.Equal("deleted", deleted.name)
+		s.NotZero(deleted.data["deleted_at"])
+	})
+
+	s.Run("Resume", func() {
+		resumed := s.streamEvents(ctx, fmt.Sprint(created.id))
+		for _, name := range []string{"updated", "deleted"} {
+			event := s.readEvent(resumed)
+			s.Equal(name, event.name)
+			s.Equal(id, event.data["id"])
+		}
+	})
+
+	s.Run("Invalid Last Event ID", func() {
+		for _, val := range []string{"first", fmt.Sprint(uint64(math.MaxUint64))} {
+			req, err := http.NewRequest(http.MethodGet, "http://app-test:8000/products/events", nil)
+			s.Require().NoError(err)
+			req.Header.Set(model.LastEventIDHeader, val)
+			res, err := http.DefaultClient.Do(req)
+			s.Require().NoError(err)
+			s.Equal(http.StatusBadRequest, res.StatusCode, val)
+			s.Equal(model.ProblemContentType, res.Header.Get("Content-Type"), val)
+			res.Body.Close()
+		}
+	})
+}
+
+type serverEvent struct {
+	id   uint64
+	name string
+	data map[string]any
+}
+
+// streamEvents opens the change feed and waits for it to start
+func (s *HTTPSuite) streamEvents(ctx context.Context, lastEventID string) *bufio.Reader {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://app-test:8000/products/events", nil)
+	s.Require().NoError(err)
+	if lastEventID != "" {
+		req.Header.Set(model.LastEventIDHeader, lastEventID)
+	}
+	res, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	s.T().Cleanup(func() {
+		_ = res.Body.Close()
+	})
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	s.Equal(model.EventStreamContentType, res.Header.Get("Content-Type"))
+	ret := bufio.NewReader(res.Body)
+	line, err := ret.ReadString('\n')
+	s.Require().NoError(err)
+	s.Equal(": subscribed\n", line)
+	return ret
+}
+
+// readEvent skips the heartbeats
+func (s *HTTPSuite) readEvent(stream *bufio.Reader) serverEvent {
+	var ret serverEvent
+	for {
+		line, err := stream.ReadString('\n')
+		s.Require().NoError(err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && ret.name != "":
+			return ret
+		case strings.HasPrefix(line, "id: "):
+			ret.id, err = strconv.ParseUint(strings.TrimPrefix(line, "id: "), 10, 64)
+			s.Require().NoError(err)
+		case strings.HasPrefix(line, "event: "):
+			ret.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			s.Require().NoError(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ret.data))
+		}
+	}
+}
+
 func (s *HTTPSuite) getMap(body io.Reader) map[string]any {
 	result := make(map[string]any)
 	if err := json.NewDecoder(body).Decode(&result); err != nil {
@@ -253,8 +1419,34 @@ func (s *HTTPSuite) getMap(body io.Reader) map[string]any {
 	return result
 }
 
-func (s *HTTPSuite) getSlice(body io.Reader) []map[string]any {
-	var result []map[string]any
+func (s *HTTPSuite) getBatch(body io.Reader) []map[string]any {
+	var result struct {
+		Results []map[string]any `json:"results"`
+	}
+	if err := json.NewDecoder(body).Decode(&result); err != nil {
+		s.FailNow(err.Error())
+	}
+	return result.Results
+}
+
+func (s *HTTPSuite) getHistory(body io.Reader) []map[string]any {
+	var result struct {
+		Entries []map[string]any `json:"entries"`
+	}
+	if err := json.NewDecoder(body).Decode(&result); err != nil {
+		s.FailNow(err.Error())
+	}
+	return result.Entries
+}
+
+type productsPage struct {
+	Items      []map[string]any `json:"items"`
+	NextCursor string           `json:"next_cursor"`
+	HasMore    bool             `json:"has_more"`
+}
+
+func (s *HTTPSuite) getPage(body io.Reader) productsPage {
+	var result productsPage
 	if err := json.NewDecoder(body).Decode(&result); err != nil {
 		s.FailNow(err.Error())
 	}
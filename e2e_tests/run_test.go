@@ -14,5 +14,7 @@ func TestRun(t *testing.T) {
 		suite.Run(t, &GraphSuite{})
 	} else if os.Getenv("TYPE") == "grpc" {
 		suite.Run(t, &GrpcSuite{})
+	} else if os.Getenv("TYPE") == "gateway" {
+		suite.Run(t, &GatewaySuite{})
 	}
 }
@@ -49,5 +49,20 @@ for compose_file in tests/docker-compose*.yml; do
   fi
 done
 
+echo "Testing GRPC gateway"
+for compose_file in tests/docker-compose*.yml; do
+  filename=$(basename "$compose_file" .yml)
+  test_name=${filename#docker-compose-}
+  echo "Running GRPC gateway.$test_name"
+
+  SERVER="grpc_gateway" TESTER_SERVER="gateway" docker-compose -p "go_template_e2e_testing" -f "$compose_file" up --abort-on-container-exit --exit-code-from tester
+  EXIT_CODE=$(docker inspect "tester" --format='{{.State.ExitCode}}')
+  SERVER="grpc_gateway" TESTER_SERVER="gateway" docker-compose -p "go_template_e2e_testing" -f "$compose_file" down -v
+  if [ "$EXIT_CODE" -ne 0 ]; then
+    echo "Test GRPC gateway.$test_name failed (exit code $EXIT_CODE)"
+    exit 1
+  fi
+done
+
 echo "All tests passed"
 
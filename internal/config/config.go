@@ -2,12 +2,15 @@ package config
 
 type Config struct {
 	HttpGrpc   Server
+	Grpc       Grpc
+	Events     Events
+	GraphQL    GraphQL
 	SqLite     Sqlite
 	MySQL      MySQL
 	Postgres   Postgres
 	Mongo      Mongo
 	Clickhouse Clickhouse
 
-	Db     string `env:"DB,required"`
-	Server string `env:"SERVER,required"`
+	Db      string     `env:"DB,required"`
+	Servers []Listener `env:"SERVER,required"`
 }
@@ -14,5 +14,13 @@ func New() (Config, error) {
 	if cfg.SqLite.Path == "" {
 		cfg.SqLite.Path = os.TempDir() + "/db"
 	}
+	for i := range cfg.Servers {
+		if cfg.Servers[i].IP == "" {
+			cfg.Servers[i].IP = cfg.HttpGrpc.IP
+		}
+		if cfg.Servers[i].Port == "" {
+			cfg.Servers[i].Port = cfg.HttpGrpc.Port
+		}
+	}
 	return cfg, nil
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 
@@ -46,6 +47,51 @@ func (s *TestSuite) TestParse_OK() {
 	s.NotEmpty(cfg.SqLite.Path)
 	s.True(strings.HasSuffix(cfg.SqLite.Path, "/db"))
 	s.DirExists(strings.TrimSuffix(cfg.SqLite.Path, "/db"))
+	s.Equal([]config.Listener{{Type: "server", IP: s.variables["IP"], Port: s.variables["PORT"]}}, cfg.Servers)
+	s.False(cfg.Grpc.Channelz)
+	s.Equal(15*time.Second, cfg.Events.Heartbeat)
+	s.Equal(config.GraphQL{
+		Complexity:    10000,
+		Depth:         10,
+		Aliases:       100,
+		Timeout:       30 * time.Second,
+		Introspection: true,
+		Playground:    true,
+	}, cfg.GraphQL)
+}
+
+func (s *TestSuite) TestParse_Servers() {
+	if err := os.Setenv("SERVER", "net_http@0.0.0.0:80,grpc@:9090,graphql"); err != nil {
+		s.Fail(err.Error())
+	}
+	cfg, err := config.New()
+	s.NoError(err)
+	s.Equal([]config.Listener{
+		{Type: "net_http", IP: "0.0.0.0", Port: "80"},
+		{Type: "grpc", IP: s.variables["IP"], Port: "9090"},
+		{Type: "graphql", IP: s.variables["IP"], Port: s.variables["PORT"]},
+	}, cfg.Servers)
+	s.Equal("0.0.0.0:80", cfg.Servers[0].Addr())
+}
+
+func (s *TestSuite) TestParse_SharedListener() {
+	if err := os.Setenv("SERVER", "grpc+net_http+graphql@:9000"); err != nil {
+		s.Fail(err.Error())
+	}
+	cfg, err := config.New()
+	s.NoError(err)
+	s.Equal([]config.Listener{{Type: "grpc+net_http+graphql", IP: s.variables["IP"], Port: "9000"}}, cfg.Servers)
+	s.Equal([]string{"grpc", "net_http", "graphql"}, cfg.Servers[0].Types())
+}
+
+func (s *TestSuite) TestParse_InvalidServer() {
+	for _, val := range []string{"grpc@9090", "net_http,@:80", "grpc+@:80"} {
+		if err := os.Setenv("SERVER", val); err != nil {
+			s.Fail(err.Error())
+		}
+		_, err := config.New()
+		s.Error(err, val)
+	}
 }
 
 func TestParse(t *testing.T) {
@@ -1,6 +1,72 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
 type Server struct {
 	IP   string `env:"IP" envDefault:"127.0.0.1"`
 	Port string `env:"PORT" envDefault:"8000"`
 }
+
+// Grpc configures the gRPC servers
+type Grpc struct {
+	// Channelz exposes the channel internals to anyone able to call the server
+	Channelz bool `env:"GRPC_CHANNELZ" envDefault:"false"`
+}
+
+type Events struct {
+	// Heartbeat keeps the idle feeds open through the proxies, 0 disables it
+	Heartbeat time.Duration `env:"EVENTS_HEARTBEAT" envDefault:"15s"`
+}
+
+// GraphQL configures the limits of the GraphQL server, 0 disables a limit
+type GraphQL struct {
+	Complexity int `env:"GRAPHQL_COMPLEXITY" envDefault:"10000"`
+	// Depth doesn't count the fields of the introspection
+	Depth   int `env:"GRAPHQL_DEPTH" envDefault:"10"`
+	Aliases int `env:"GRAPHQL_ALIASES" envDefault:"100"`
+	// Timeout doesn't apply to the subscriptions
+	Timeout       time.Duration `env:"GRAPHQL_TIMEOUT" envDefault:"30s"`
+	Introspection bool          `env:"GRAPHQL_INTROSPECTION" envDefault:"true"`
+	Playground    bool          `env:"GRAPHQL_PLAYGROUND" envDefault:"true"`
+}
+
+// Listener is one of the servers of SERVER, e.g. grpc+net_http+graphql@:8000 shares the port
+type Listener struct {
+	Type string
+	IP   string
+	Port string
+}
+
+func (l Listener) Addr() string {
+	return net.JoinHostPort(l.IP, l.Port)
+}
+
+func (l Listener) Types() []string {
+	return strings.Split(l.Type, "+")
+}
+
+func (l *Listener) UnmarshalText(text []byte) error {
+	typ, addr, found := strings.Cut(strings.TrimSpace(string(text)), "@")
+	if typ == "" {
+		return errors.New("empty server type")
+	}
+	if slices.Contains(strings.Split(typ, "+"), "") {
+		return fmt.Errorf("empty server type in %q", typ)
+	}
+	l.Type = typ
+	if found {
+		ip, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return fmt.Errorf("invalid address of %s server: %w", typ, err)
+		}
+		l.IP, l.Port = ip, port
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const MaxBatchSize = 1000
+
+// BatchResult is the outcome of one item of a batch
+type BatchResult struct {
+	ID      string   `json:"id"`
+	Product *Product `json:"product,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// IsBatchItemError reports whether the error fails only its item and not the whole batch
+func IsBatchItemError(err error) bool {
+	switch KindOf(err) {
+	case NotFound, InvalidArgument, Conflict:
+		return true
+	default:
+		return false
+	}
+}
+
+func ValidateBatch(size int) error {
+	if size == 0 {
+		return fmt.Errorf("%w: no items", ErrorInvalidBatch)
+	}
+	if size > MaxBatchSize {
+		return fmt.Errorf("%w: more than %d items", ErrorInvalidBatch, MaxBatchSize)
+	}
+	return nil
+}
+
+// ValidateIDs checks the number of the products read at once
+func ValidateIDs(ids []string) error {
+	if len(ids) > MaxBatchSize {
+		return fmt.Errorf("%w: more than %d ids", ErrorInvalidBatch, MaxBatchSize)
+	}
+	return nil
+}
+
+// BatchItem is an item of the batch writes
+type BatchItem interface {
+	Product | ProductUpdate
+}
+
+func batchItemID[T BatchItem](val T) string {
+	switch val := any(val).(type) {
+	case Product:
+		return val.ID
+	case ProductUpdate:
+		return val.ID
+	}
+	return ""
+}
+
+// ParseBatch reads the JSON array sent to the REST batch endpoints
+func ParseBatch[T BatchItem](r io.Reader) ([]T, error) {
+	var ret []T
+	if err := json.NewDecoder(r).Decode(&ret); err != nil {
+		return nil, fmt.Errorf("%w: body must be a JSON array of products", ErrorInvalidBatch)
+	}
+	return ret, ValidateBatch(len(ret))
+}
+
+// RunBatch applies the write to every item, any error but the item ones stops the batch
+func RunBatch[T BatchItem](vals []T, write func(val T) (*Product, error)) ([]BatchResult, error) {
+	if err := ValidateBatch(len(vals)); err != nil {
+		return nil, err
+	}
+	ret := make([]BatchResult, 0, len(vals))
+	for _, val := range vals {
+		prod, err := write(val)
+		if err != nil && !IsBatchItemError(err) {
+			return nil, err
+		}
+		ret = append(ret, NewBatchResult(batchItemID(val), prod, err))
+	}
+	return ret, nil
+}
+
+func NewBatchResult(id string, prod *Product, err error) BatchResult {
+	ret := BatchResult{
+		ID: id,
+	}
+	if err != nil {
+		ret.Error = err.Error()
+	} else if prod != nil {
+		ret.ID = prod.ID
+		ret.Product = prod
+	}
+	return ret
+}
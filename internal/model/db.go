@@ -2,44 +2,168 @@ package model
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
 	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaswdr/faker/v2"
+	"github.com/shopspring/decimal"
 )
 
 const TableName = "products"
 
 type Product struct {
-	ID        string  `json:"id" bson:"id" db:"id"`
-	Name      string  `json:"name,omitempty" bson:"name" db:"name"`
-	Price     float64 `json:"price,omitempty" bson:"price" db:"price"`
-	CreatedAt uint32  `json:"created_at,omitempty" bson:"created_at" db:"created_at"`
+	ID   string `json:"id" bson:"id" db:"id"`
+	Name string `json:"name,omitempty" bson:"name" db:"name"`
+	// Price is a string in JSON so the clients don't read it into a float
+	Price     decimal.Decimal `json:"price" bson:"price" db:"price" gorm:"type:decimal(18,4)"`
+	Currency  string          `json:"currency,omitempty" bson:"currency" db:"currency" gorm:"size:3;not null;default:USD"`
+	CreatedAt uint32          `json:"created_at,omitempty" bson:"created_at" db:"created_at"`
+	// Version starts at 1 and is incremented by every write
+	Version uint64 `json:"version,omitempty" bson:"version" db:"version" gorm:"not null;default:1"`
+	// DeletedAt is zero for the live products
+	DeletedAt uint32 `json:"deleted_at,omitempty" bson:"deleted_at" db:"deleted_at" gorm:"not null;default:0"`
+}
+
+// ProductLocation returns the path of the product resource
+func ProductLocation(id string) string {
+	return "/products/" + url.PathEscape(id)
 }
 
-func ParseProduct(id string, name string, priceStr string) (Product, error) {
-	if id == "" {
-		return Product{}, errors.New("invalid id")
+// ParseNewProduct builds a product to be created, id may be empty
+func ParseNewProduct(id string, name string, priceStr string, currency string) (Product, error) {
+	if priceStr == "" {
+		return Product{}, InvalidField("price", fmt.Errorf("%w: price is required", ErrorInvalidProduct))
+	}
+	price, err := ParsePrice(priceStr)
+	if err != nil {
+		return Product{}, err
 	}
 	ret := Product{
-		ID: id,
+		ID:       id,
+		Name:     name,
+		Price:    price,
+		Currency: currency,
+	}
+	return ret, ret.Validate()
+}
+
+// Validate checks the client supplied fields, limits follow the MySQL schema
+func (p Product) Validate() error {
+	if len(p.ID) > 36 {
+		return InvalidField("id", fmt.Errorf("%w: id must not be longer than 36 characters", ErrorInvalidProduct))
+	}
+	if p.Name == "" {
+		return InvalidField("name", fmt.Errorf("%w: name is required", ErrorInvalidProduct))
 	}
-	price, err := strconv.ParseFloat(priceStr, 64)
-	if err == nil {
-		ret.Price = price
+	if len(p.Name) > 255 {
+		return InvalidField("name", fmt.Errorf("%w: name must not be longer than 255 characters", ErrorInvalidProduct))
+	}
+	if err := ValidatePrice(p.Price); err != nil {
+		return err
+	}
+	return ValidateCurrency(p.Currency)
+}
+
+// PrepareProduct validates the product and fills the fields owned by the storage
+func PrepareProduct(val Product) (Product, error) {
+	if err := val.Validate(); err != nil {
+		return Product{}, err
+	}
+	if val.ID == "" {
+		val.ID = uuid.NewString()
+	}
+	val.CreatedAt = uint32(time.Now().Unix())
+	val.Version = 1
+	val.DeletedAt = 0
+	return val, nil
+}
+
+// PrepareUpsert is PrepareProduct for the client supplied ID
+func PrepareUpsert(val Product) (Product, error) {
+	if val.ID == "" {
+		return Product{}, InvalidField("id", fmt.Errorf("%w: id is required", ErrorInvalidProduct))
 	}
-	if name != "" {
-		ret.Name = name
+	return PrepareProduct(val)
+}
+
+// Unchanged reports whether upserting p is a no-op
+func (p Product) Unchanged(stored Product) bool {
+	return stored.DeletedAt == 0 && p.Name == stored.Name && p.Price.Equal(stored.Price) &&
+		p.Currency == stored.Currency
+}
+
+// Replace returns the stored product with the fields of p and the next version
+func (p Product) Replace(stored Product) Product {
+	stored.Name = p.Name
+	stored.Price = p.Price
+	stored.Currency = p.Currency
+	stored.Version++
+	stored.DeletedAt = 0
+	return stored
+}
+
+// ParseVersion reads the expected version, empty string means any version
+func ParseVersion(str string) (uint64, error) {
+	if str == "" {
+		return 0, nil
 	}
-	if ret.Name == "" && ret.Price == 0 {
-		return Product{}, ErrorNoUpdateParams
+	ret, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return 0, InvalidField("version", NewError(InvalidArgument, "invalid version"))
 	}
 	return ret, nil
 }
 
+type PurgeResult struct {
+	Purged int64 `json:"purged"`
+}
+
+func ParsePurgeDays(str string) (uint32, error) {
+	ret, err := strconv.ParseUint(str, 10, 32)
+	if err != nil {
+		return 0, InvalidField("days", NewError(InvalidArgument, "invalid days"))
+	}
+	return uint32(ret), nil
+}
+
+// PurgeBefore returns the cutoff for DB.Purge
+func PurgeBefore(days uint32) uint32 {
+	return uint32(time.Now().Add(-time.Duration(days) * 24 * time.Hour).Unix())
+}
+
+func SampleProduct() Product {
+	f := faker.NewWithSeed(rand.NewPCG(uint64(time.Now().Unix()), uint64(time.Now().UnixNano())))
+	return Product{
+		Name:     f.Food().Fruit(),
+		Price:    decimal.New(int64(f.IntBetween(100, 10000)), -2),
+		Currency: DefaultCurrency,
+	}
+}
+
+// DB is implemented by every storage backend. Zero version skips the version check, Delete is soft
 type DB interface {
-	Add(ctx context.Context) (string, error)
-	Update(ctx context.Context, val Product) error
-	Delete(ctx context.Context, id string) error
+	Add(ctx context.Context, val Product) (Product, error)
+	// Update, Delete and Restore return the product as the write has left it
+	Update(ctx context.Context, val ProductUpdate) (Product, error)
+	// Upsert reports whether the product was created
+	Upsert(ctx context.Context, val Product) (Product, bool, error)
+	Delete(ctx context.Context, id string, version uint64) (Product, error)
+	AddBatch(ctx context.Context, vals []Product) ([]BatchResult, error)
+	UpdateBatch(ctx context.Context, vals []ProductUpdate) ([]BatchResult, error)
+	DeleteBatch(ctx context.Context, vals []Product) ([]BatchResult, error)
+	Restore(ctx context.Context, id string) (Product, error)
+	// Purge removes the products soft deleted at or before the time along with their history
+	Purge(ctx context.Context, before uint32) (int64, error)
+	History(ctx context.Context, id string) ([]HistoryEntry, error)
 	Get(ctx context.Context, id string) (Product, error)
-	GetAll(ctx context.Context) ([]Product, error)
+	// GetMany returns the live products found, in no particular order
+	GetMany(ctx context.Context, ids []string) ([]Product, error)
+	GetAll(ctx context.Context, query ProductQuery, page PageRequest) (ProductPage, error)
+	Search(ctx context.Context, query SearchQuery) ([]Product, error)
+	Ping(ctx context.Context) error
 	Start() error
 }
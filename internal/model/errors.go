@@ -1,7 +1,157 @@
 package model
 
-import "errors"
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
 
-var ErrorNoRowsUpdated = errors.New("no rows updated")
-var ErrorNoRowsDeleted = errors.New("no rows deleted")
-var ErrorNoUpdateParams = errors.New("no parameters were passed to be updated")
+	"google.golang.org/grpc/codes"
+)
+
+// Kind classifies the errors for the clients, errors.Is(err, NotFound) tells the kind
+type Kind string
+
+const (
+	NotFound        Kind = "not found"
+	InvalidArgument Kind = "invalid argument"
+	Conflict        Kind = "conflict"
+	Unavailable     Kind = "unavailable"
+	Internal        Kind = "internal"
+)
+
+func (k Kind) Error() string {
+	return string(k)
+}
+
+// Error is the typed error of the storage and the request parsing
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+func NewError(kind Kind, msg string) error {
+	return &Error{Kind: kind, Err: errors.New(msg)}
+}
+
+// Errorf returns the error of the kind, %w wraps the errors
+func Errorf(kind Kind, format string, args ...any) error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+var ErrorNotFound = NewError(NotFound, "product not found")
+var ErrorNoRowsUpdated = NewError(NotFound, "no rows updated")
+var ErrorNoRowsDeleted = NewError(NotFound, "no rows deleted")
+var ErrorNoRowsRestored = NewError(NotFound, "no rows restored")
+var ErrorNoUpdateParams = NewError(InvalidArgument, "no parameters were passed to be updated")
+var ErrorInvalidProduct = NewError(InvalidArgument, "invalid product")
+var ErrorAlreadyExists = NewError(Conflict, "product already exists")
+var ErrorInvalidPage = NewError(InvalidArgument, "invalid page request")
+var ErrorInvalidQuery = NewError(InvalidArgument, "invalid product query")
+var ErrorInvalidBatch = NewError(InvalidArgument, "invalid batch")
+var ErrorVersionConflict = NewError(Conflict, "product version conflict")
+var ErrorUnsupportedMediaType = NewError(InvalidArgument, "unsupported media type")
+var ErrorNotAcceptable = NewError(InvalidArgument, "not acceptable")
+var ErrorPreconditionFailed = NewError(Conflict, "product etag mismatch")
+var ErrorEventsExpired = NewError(InvalidArgument, "events after the resume sequence are no longer kept")
+var ErrorEventsAhead = NewError(InvalidArgument, "resume sequence is ahead of the events")
+var ErrorEventsLagging = NewError(Unavailable, "subscriber fell behind the events, resume from the last one")
+var ErrorEventsUnsupported = NewError(Unavailable, "storage doesn't publish its changes")
+
+// KindOf returns the kind of the error, the untyped errors are Internal
+func KindOf(err error) Kind {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed.Kind
+	}
+	return Internal
+}
+
+// Typed classifies the untyped errors: the network errors and the timeouts are Unavailable
+func Typed(err error) error {
+	var typed *Error
+	if err == nil || errors.As(err, &typed) {
+		return err
+	}
+	var netErr net.Error
+	// The mongo driver labels the network errors instead of wrapping them
+	var labeled interface{ HasErrorLabel(label string) bool }
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) ||
+		errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.As(err, &netErr) ||
+		(errors.As(err, &labeled) && labeled.HasErrorLabel("NetworkError")) {
+		return &Error{Kind: Unavailable, Err: err}
+	}
+	return &Error{Kind: Internal, Err: err}
+}
+
+// HTTPStatus maps the kind of the error to the HTTP status code
+func HTTPStatus(err error) int {
+	switch KindOf(err) {
+	case NotFound:
+		return http.StatusNotFound
+	case InvalidArgument:
+		if errors.Is(err, ErrorUnsupportedMediaType) {
+			return http.StatusUnsupportedMediaType
+		}
+		if errors.Is(err, ErrorNotAcceptable) {
+			return http.StatusNotAcceptable
+		}
+		return http.StatusBadRequest
+	case Conflict:
+		if errors.Is(err, ErrorPreconditionFailed) {
+			return http.StatusPreconditionFailed
+		}
+		return http.StatusConflict
+	case Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GRPCCode maps the kind of the error to the gRPC status code
+func GRPCCode(err error) codes.Code {
+	switch KindOf(err) {
+	case NotFound:
+		return codes.NotFound
+	case InvalidArgument:
+		return codes.InvalidArgument
+	case Conflict:
+		if errors.Is(err, ErrorAlreadyExists) {
+			return codes.AlreadyExists
+		}
+		return codes.FailedPrecondition
+	case Unavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+func GraphQLCode(err error) string {
+	switch KindOf(err) {
+	case NotFound:
+		return "NOT_FOUND"
+	case InvalidArgument:
+		return "INVALID_ARGUMENT"
+	case Conflict:
+		return "CONFLICT"
+	case Unavailable:
+		return "UNAVAILABLE"
+	default:
+		return "INTERNAL"
+	}
+}
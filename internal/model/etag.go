@@ -0,0 +1,75 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+const ETagHeader = "ETag"
+
+const IfNoneMatchHeader = "If-None-Match"
+
+const IfMatchHeader = "If-Match"
+
+// ETag returns the strong entity tag of the value, the hash of its JSON
+func ETag(val any) (string, error) {
+	b, err := json.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// NoneMatch reports whether the If-None-Match header matches the tag, weakly as RFC 9110 requires
+func NoneMatch(header string, etag string) bool {
+	return matchETag(header, etag, true)
+}
+
+// CheckIfMatch checks the If-Match header and returns the version the write has to expect
+func CheckIfMatch(ctx context.Context, db DB, id string, version uint64, header string) (uint64, error) {
+	if header == "" {
+		return version, nil
+	}
+	val, err := db.Get(ctx, id)
+	if errors.Is(err, NotFound) {
+		return 0, ErrorPreconditionFailed
+	}
+	if err != nil {
+		return 0, err
+	}
+	etag, err := ETag(val)
+	if err != nil {
+		return 0, err
+	}
+	if !matchETag(header, etag, false) {
+		return 0, ErrorPreconditionFailed
+	}
+	if version != 0 {
+		return version, nil
+	}
+	return val.Version, nil
+}
+
+func matchETag(header string, etag string, weak bool) bool {
+	for _, val := range strings.Split(header, ",") {
+		val = strings.TrimSpace(val)
+		if val == "*" {
+			return true
+		}
+		if strings.HasPrefix(val, "W/") {
+			if !weak {
+				continue
+			}
+			val = strings.TrimPrefix(val, "W/")
+		}
+		if val == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package model
+
+import (
+	"context"
+	"sync"
+)
+
+// EventType tells what happened to the product, the restored products are updated
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+// EventHistory is the number of the recent events kept for the resumed subscriptions
+const EventHistory = 1024
+
+// eventBuffer is how far a subscriber can fall behind before it's dropped
+const eventBuffer = 256
+
+// ProductEvent is the change of the product, the sequence numbers start at 1
+type ProductEvent struct {
+	Sequence uint64    `json:"sequence"`
+	Type     EventType `json:"type"`
+	Product  Product   `json:"product"`
+}
+
+type EventSource interface {
+	// Subscribe returns the events after the given sequence number, or only the new ones without it
+	Subscribe(ctx context.Context, after *uint64) (*Subscription, error)
+}
+
+// EventBus passes the events to the subscribers in-process and keeps the recent ones
+type EventBus struct {
+	mu      sync.Mutex
+	seq     uint64
+	history int
+	recent  []ProductEvent
+	subs    map[*Subscription]struct{}
+}
+
+// Subscription receives the events until it's closed, Err tells why
+type Subscription struct {
+	ch  chan ProductEvent
+	err error
+}
+
+func NewEventBus(history int) *EventBus {
+	return &EventBus{
+		history: history,
+		subs:    make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends the event to the subscribers, the lagging ones are closed instead of blocking
+func (b *EventBus) Publish(typ EventType, product Product) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	event := ProductEvent{Sequence: b.seq, Type: typ, Product: product}
+	if len(b.recent) == b.history {
+		b.recent = append(b.recent[:0], b.recent[1:]...)
+	}
+	if b.history > 0 {
+		b.recent = append(b.recent, event)
+	}
+	for sub := range b.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			b.close(sub, ErrorEventsLagging)
+		}
+	}
+}
+
+func (b *EventBus) Subscribe(ctx context.Context, after *uint64) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var replay []ProductEvent
+	if after != nil {
+		switch {
+		case *after > b.seq:
+			return nil, ErrorEventsAhead
+		case *after < b.seq && (len(b.recent) == 0 || b.recent[0].Sequence > *after+1):
+			return nil, ErrorEventsExpired
+		}
+		for _, event := range b.recent {
+			if event.Sequence > *after {
+				replay = append(replay, event)
+			}
+		}
+	}
+	sub := &Subscription{ch: make(chan ProductEvent, len(replay)+eventBuffer)}
+	for _, event := range replay {
+		sub.ch <- event
+	}
+	b.subs[sub] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.close(sub, ctx.Err())
+	}()
+	return sub, nil
+}
+
+func (b *EventBus) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// close has to be called with the mutex held
+func (b *EventBus) close(sub *Subscription, err error) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	sub.err = err
+	close(sub.ch)
+}
+
+func (s *Subscription) Events() <-chan ProductEvent {
+	return s.ch
+}
+
+// Err tells why the subscription has ended once Events is closed
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// eventDB publishes the successful writes of the backend
+type eventDB struct {
+	db  DB
+	bus *EventBus
+}
+
+// WithEvents wraps the backend into an EventSource
+func WithEvents(db DB, bus *EventBus) DB {
+	return eventDB{db: db, bus: bus}
+}
+
+func (e eventDB) Subscribe(ctx context.Context, after *uint64) (*Subscription, error) {
+	return e.bus.Subscribe(ctx, after)
+}
+
+func (e eventDB) Add(ctx context.Context, val Product) (Product, error) {
+	ret, err := e.db.Add(ctx, val)
+	if err == nil {
+		e.bus.Publish(EventCreated, ret)
+	}
+	return ret, err
+}
+
+func (e eventDB) Update(ctx context.Context, val ProductUpdate) (Product, error) {
+	ret, err := e.db.Update(ctx, val)
+	if err == nil {
+		e.bus.Publish(EventUpdated, ret)
+	}
+	return ret, err
+}
+
+func (e eventDB) Upsert(ctx context.Context, val Product) (Product, bool, error) {
+	ret, created, err := e.db.Upsert(ctx, val)
+	if err == nil {
+		typ := EventUpdated
+		if created {
+			typ = EventCreated
+		}
+		e.bus.Publish(typ, ret)
+	}
+	return ret, created, err
+}
+
+func (e eventDB) Delete(ctx context.Context, id string, version uint64) (Product, error) {
+	ret, err := e.db.Delete(ctx, id, version)
+	if err == nil {
+		e.bus.Publish(EventDeleted, ret)
+	}
+	return ret, err
+}
+
+func (e eventDB) AddBatch(ctx context.Context, vals []Product) ([]BatchResult, error) {
+	ret, err := e.db.AddBatch(ctx, vals)
+	e.publishBatch(EventCreated, ret)
+	return ret, err
+}
+
+func (e eventDB) UpdateBatch(ctx context.Context, vals []ProductUpdate) ([]BatchResult, error) {
+	ret, err := e.db.UpdateBatch(ctx, vals)
+	e.publishBatch(EventUpdated, ret)
+	return ret, err
+}
+
+func (e eventDB) DeleteBatch(ctx context.Context, vals []Product) ([]BatchResult, error) {
+	ret, err := e.db.DeleteBatch(ctx, vals)
+	e.publishBatch(EventDeleted, ret)
+	return ret, err
+}
+
+func (e eventDB) Restore(ctx context.Context, id string) (Product, error) {
+	ret, err := e.db.Restore(ctx, id)
+	if err == nil {
+		e.bus.Publish(EventUpdated, ret)
+	}
+	return ret, err
+}
+
+// Purge removes the products deleted already, there is nothing to publish
+func (e eventDB) Purge(ctx context.Context, before uint32) (int64, error) {
+	return e.db.Purge(ctx, before)
+}
+
+func (e eventDB) History(ctx context.Context, id string) ([]HistoryEntry, error) {
+	return e.db.History(ctx, id)
+}
+
+func (e eventDB) Get(ctx context.Context, id string) (Product, error) {
+	return e.db.Get(ctx, id)
+}
+
+func (e eventDB) GetMany(ctx context.Context, ids []string) ([]Product, error) {
+	return e.db.GetMany(ctx, ids)
+}
+
+func (e eventDB) GetAll(ctx context.Context, query ProductQuery, page PageRequest) (ProductPage, error) {
+	return e.db.GetAll(ctx, query, page)
+}
+
+func (e eventDB) Search(ctx context.Context, query SearchQuery) ([]Product, error) {
+	return e.db.Search(ctx, query)
+}
+
+func (e eventDB) Ping(ctx context.Context) error {
+	return e.db.Ping(ctx)
+}
+
+func (e eventDB) Start() error {
+	return e.db.Start()
+}
+
+func (e eventDB) publishBatch(typ EventType, results []BatchResult) {
+	for _, res := range results {
+		if res.Error == "" && res.Product != nil {
+			e.bus.Publish(typ, *res.Product)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const EventStreamContentType = "text/event-stream"
+
+const LastEventIDHeader = "Last-Event-ID"
+
+// Feed is the change feed of one client of the REST servers
+type Feed struct {
+	sub       *Subscription
+	cancel    context.CancelFunc
+	heartbeat time.Duration
+}
+
+// ParseLastEventID returns nil without the header
+func ParseLastEventID(header string) (*uint64, error) {
+	if header == "" {
+		return nil, nil
+	}
+	ret, err := strconv.ParseUint(strings.TrimSpace(header), 10, 64)
+	if err != nil {
+		return nil, InvalidField(LastEventIDHeader, err)
+	}
+	return &ret, nil
+}
+
+// Feeds opens the change feeds of a REST server, Stop ends them on the shutdown
+type Feeds struct {
+	db        DB
+	heartbeat time.Duration
+	stop      context.Context
+	cancel    context.CancelFunc
+}
+
+func NewFeeds(db DB, heartbeat time.Duration) *Feeds {
+	ret := &Feeds{db: db, heartbeat: heartbeat}
+	ret.stop, ret.cancel = context.WithCancel(context.Background())
+	return ret
+}
+
+// Open subscribes to the events after the given sequence number
+func (f *Feeds) Open(ctx context.Context, after *uint64) (*Feed, error) {
+	source, ok := f.db.(EventSource)
+	if !ok {
+		return nil, ErrorEventsUnsupported
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stopped := context.AfterFunc(f.stop, cancel)
+	sub, err := source.Subscribe(ctx, after)
+	if err != nil {
+		stopped()
+		cancel()
+		return nil, err
+	}
+	return &Feed{
+		sub: sub,
+		cancel: func() {
+			stopped()
+			cancel()
+		},
+		heartbeat: f.heartbeat,
+	}, nil
+}
+
+func (f *Feeds) Stop() {
+	f.cancel()
+}
+
+// ServeHTTP streams the feed to the net/http clients
+func (f *Feeds) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	after, err := ParseLastEventID(r.Header.Get(LastEventIDHeader))
+	if err == nil {
+		var feed *Feed
+		if feed, err = f.Open(r.Context(), after); err == nil {
+			w.Header().Set("Content-Type", EventStreamContentType)
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusOK)
+			_ = feed.Write(w, http.NewResponseController(w).Flush)
+			return
+		}
+	}
+	problem := NewProblem(r.Context(), err, r.URL.RequestURI())
+	b, _ := json.Marshal(problem)
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	_, _ = w.Write(b)
+}
+
+// Write sends the events until the feed ends or the client is gone, the idle stream gets heartbeats
+func (f *Feed) Write(w io.Writer, flush func() error) error {
+	defer f.cancel()
+	var heartbeat <-chan time.Time
+	if f.heartbeat > 0 {
+		tick := time.NewTicker(f.heartbeat)
+		defer tick.Stop()
+		heartbeat = tick.C
+	}
+	if err := writeEvent(w, flush, ": subscribed\n\n"); err != nil {
+		return err
+	}
+	for {
+		select {
+		case event, ok := <-f.sub.Events():
+			if !ok {
+				return nil
+			}
+			data, err := json.Marshal(event.Product)
+			if err != nil {
+				return err
+			}
+			if err = writeEvent(w, flush, fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", event.Sequence, event.Type,
+				data)); err != nil {
+				return err
+			}
+		case <-heartbeat:
+			if err := writeEvent(w, flush, ": heartbeat\n\n"); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeEvent(w io.Writer, flush func() error, event string) error {
+	if _, err := io.WriteString(w, event); err != nil {
+		return err
+	}
+	return flush()
+}
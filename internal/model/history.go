@@ -0,0 +1,144 @@
+package model
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const HistoryTableName = "product_history"
+
+// ActorHeader carries the caller identity recorded in the history
+const ActorHeader = "X-Actor"
+
+type Operation string
+
+const (
+	OperationAdd     Operation = "add"
+	OperationUpdate  Operation = "update"
+	OperationDelete  Operation = "delete"
+	OperationRestore Operation = "restore"
+)
+
+// HistoryEntry records a single change, Before is filled when the history is read (see LinkHistory)
+type HistoryEntry struct {
+	ProductID string    `json:"product_id" bson:"product_id"`
+	Version   uint64    `json:"version" bson:"version"`
+	Operation Operation `json:"operation" bson:"operation"`
+	ChangedAt uint32    `json:"changed_at" bson:"changed_at"`
+	Actor     string    `json:"actor,omitempty" bson:"actor"`
+	Before    *Product  `json:"before,omitempty" bson:"-"`
+	After     Product   `json:"after" bson:"after"`
+}
+
+type HistoryResponse struct {
+	Entries []HistoryEntry `json:"entries"`
+}
+
+// NewHistoryEntry records the change, the caller is taken from the context
+func NewHistoryEntry(ctx context.Context, op Operation, after Product) HistoryEntry {
+	return HistoryEntry{
+		ProductID: after.ID,
+		Version:   after.Version,
+		Operation: op,
+		ChangedAt: uint32(time.Now().Unix()),
+		Actor:     ActorFromContext(ctx),
+		After:     after,
+	}
+}
+
+// LinkHistory fills Before of the entries sorted by version
+func LinkHistory(entries []HistoryEntry) []HistoryEntry {
+	if entries == nil {
+		return []HistoryEntry{}
+	}
+	for i := 1; i < len(entries); i++ {
+		before := entries[i-1].After
+		entries[i].Before = &before
+	}
+	return entries
+}
+
+// AsOf returns the product as it was at the given time
+func AsOf(entries []HistoryEntry, at uint32) (Product, error) {
+	var ret *Product
+	for i := range entries {
+		if entries[i].ChangedAt > at {
+			break
+		}
+		ret = &entries[i].After
+	}
+	if ret == nil || ret.DeletedAt != 0 {
+		return Product{}, ErrorNotFound
+	}
+	return *ret, nil
+}
+
+// GetAsOf reads the product as it was at the given time, or the current one without it
+func GetAsOf(ctx context.Context, db DB, id string, at *uint32) (Product, error) {
+	if at == nil {
+		return db.Get(ctx, id)
+	}
+	entries, err := db.History(ctx, id)
+	if err != nil {
+		return Product{}, err
+	}
+	return AsOf(entries, *at)
+}
+
+func ParseAsOf(str string) (*uint32, error) {
+	if str == "" {
+		return nil, nil
+	}
+	val, err := strconv.ParseUint(str, 10, 32)
+	if err != nil {
+		return nil, InvalidField("as_of", NewError(InvalidArgument, "invalid as of"))
+	}
+	ret := uint32(val)
+	return &ret, nil
+}
+
+type actorKey struct{}
+
+// MaxActorLength follows the MySQL history schema
+const MaxActorLength = 255
+
+// WithActor stores the caller identity in the context, the long ones are truncated
+func WithActor(ctx context.Context, actor string) context.Context {
+	if actor == "" {
+		return ctx
+	}
+	if len(actor) > MaxActorLength {
+		actor = strings.ToValidUTF8(actor[:MaxActorLength], "")
+	}
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	ret, _ := ctx.Value(actorKey{}).(string)
+	return ret
+}
+
+// HistoryColumns lists the columns of the SQL history tables
+const HistoryColumns = "product_id, version, operation, changed_at, actor, name, price, currency, created_at, " +
+	"deleted_at"
+
+func (e HistoryEntry) Values() []any {
+	return []any{e.ProductID, e.Version, string(e.Operation), e.ChangedAt, e.Actor, e.After.Name, e.After.Price,
+		e.After.Currency, e.After.CreatedAt, e.After.DeletedAt}
+}
+
+func ScanHistoryEntry(scan func(dest ...any) error) (HistoryEntry, error) {
+	var ret HistoryEntry
+	var op string
+	err := scan(&ret.ProductID, &ret.Version, &op, &ret.ChangedAt, &ret.Actor, &ret.After.Name, &ret.After.Price,
+		&ret.After.Currency, &ret.After.CreatedAt, &ret.After.DeletedAt)
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+	ret.Operation = Operation(op)
+	ret.After.ID = ret.ProductID
+	ret.After.Version = ret.Version
+	return ret, nil
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// PricePrecision and PriceScale describe the NUMERIC(18, 4) price columns
+	PricePrecision = 18
+	PriceScale     = 4
+	// DefaultCurrency is assumed for the products stored without a currency
+	DefaultCurrency = "USD"
+)
+
+var maxPrice = decimal.New(1, PricePrecision-PriceScale)
+
+func ParsePrice(str string) (decimal.Decimal, error) {
+	ret, err := decimal.NewFromString(str)
+	if err != nil {
+		return decimal.Decimal{}, InvalidField("price", fmt.Errorf("%w: price is not a decimal number", ErrorInvalidProduct))
+	}
+	return ret, nil
+}
+
+func ValidatePrice(price decimal.Decimal) error {
+	if price.IsNegative() {
+		return InvalidField("price", fmt.Errorf("%w: price must not be negative", ErrorInvalidProduct))
+	}
+	if !price.Equal(price.Truncate(PriceScale)) {
+		return InvalidField("price", fmt.Errorf("%w: price must not have more than %d decimal places",
+			ErrorInvalidProduct, PriceScale))
+	}
+	if price.GreaterThanOrEqual(maxPrice) {
+		return InvalidField("price", fmt.Errorf("%w: price must be less than %s", ErrorInvalidProduct, maxPrice))
+	}
+	return nil
+}
+
+// ValidateCurrency checks the format of the ISO 4217 code, not the list of the codes
+func ValidateCurrency(code string) error {
+	if len(code) != 3 {
+		return InvalidField("currency", fmt.Errorf("%w: currency must be a 3-letter code", ErrorInvalidProduct))
+	}
+	for _, c := range []byte(code) {
+		if c < 'A' || c > 'Z' {
+			return InvalidField("currency", fmt.Errorf("%w: currency must be a 3-letter code", ErrorInvalidProduct))
+		}
+	}
+	return nil
+}
+
+// PriceUnits splits the price the way google.type.Money does
+func PriceUnits(price decimal.Decimal) (int64, int32) {
+	units := price.Truncate(0)
+	return units.IntPart(), int32(price.Sub(units).Shift(9).IntPart())
+}
+
+func PriceFromUnits(units int64, nanos int32) (decimal.Decimal, error) {
+	if nanos <= -1e9 || nanos >= 1e9 || (units > 0 && nanos < 0) || (units < 0 && nanos > 0) {
+		return decimal.Decimal{}, InvalidField("price", fmt.Errorf(
+			"%w: nanos must be within a unit and have the sign of the units", ErrorInvalidProduct))
+	}
+	return decimal.New(units, 0).Add(decimal.New(int64(nanos), -9)), nil
+}
+
+// ScaledPrice returns the price in ten-thousandths, clamped to the range of the products
+func ScaledPrice(price decimal.Decimal) int64 {
+	return decimal.Min(decimal.Max(price, maxPrice.Neg()), maxPrice).Shift(PriceScale).IntPart()
+}
+
+// SQLiteScaledPrice compares the sqlite prices as integers instead of floats
+const SQLiteScaledPrice = "(CAST(price AS INTEGER) * 10000 + CASE instr(price, '.') WHEN 0 THEN 0 " +
+	"ELSE CAST(substr(price || '0000', instr(price, '.') + 1, 4) AS INTEGER) END)"
+
+// PriceKey encodes the validated price into 8 bytes sorting in the order of the prices
+func PriceKey(price decimal.Decimal) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(price.Shift(PriceScale).IntPart())^(1<<63))
+}
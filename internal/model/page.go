@@ -0,0 +1,111 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+type PageRequest struct {
+	Size   int
+	Cursor string
+}
+
+type ProductPage struct {
+	Items      []Product `json:"items"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	HasMore    bool      `json:"has_more"`
+}
+
+func ParsePageRequest(sizeStr string, cursor string) (PageRequest, error) {
+	ret := PageRequest{
+		Cursor: cursor,
+	}
+	if sizeStr != "" {
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil {
+			return PageRequest{}, InvalidField("page_size", fmt.Errorf("%w: page size is not a number",
+				ErrorInvalidPage))
+		}
+		ret.Size = size
+	}
+	return ret, nil
+}
+
+// Prepare validates the request, it returns the page size and the last product of the previous page
+func (p PageRequest) Prepare(query *ProductQuery) (int, *Product, error) {
+	if err := query.Validate(); err != nil {
+		return 0, nil, err
+	}
+	size := p.Size
+	if size < 0 {
+		return 0, nil, InvalidField("page_size", fmt.Errorf("%w: page size must not be negative", ErrorInvalidPage))
+	}
+	if size == 0 {
+		size = DefaultPageSize
+	}
+	size = min(size, MaxPageSize)
+	if p.Cursor == "" {
+		return size, nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return 0, nil, InvalidField("cursor", fmt.Errorf("%w: malformed cursor", ErrorInvalidPage))
+	}
+	var c cursor
+	if err = json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return 0, nil, InvalidField("cursor", fmt.Errorf("%w: malformed cursor", ErrorInvalidPage))
+	}
+	if c.Sort != query.Sort {
+		return 0, nil, InvalidField("cursor", fmt.Errorf("%w: cursor belongs to a listing with another sort order",
+			ErrorInvalidPage))
+	}
+	return size, &c.Product, nil
+}
+
+// NewProductPage builds the page out of up to size+1 products
+func NewProductPage(items []Product, size int, sort ProductSort) ProductPage {
+	ret := ProductPage{
+		Items: items,
+	}
+	if ret.Items == nil {
+		ret.Items = []Product{}
+	}
+	if len(ret.Items) > size {
+		ret.Items = ret.Items[:size]
+		ret.HasMore = true
+		ret.NextCursor = EncodeCursor(ret.Items[size-1], sort)
+	}
+	return ret
+}
+
+// cursor keeps its sort order, so it can't be replayed against another one
+type cursor struct {
+	Sort    ProductSort `json:"sort"`
+	Product `json:"key"`
+}
+
+func EncodeCursor(p Product, sort ProductSort) string {
+	c := cursor{
+		Sort: sort,
+		Product: Product{
+			ID: p.ID,
+		},
+	}
+	switch sort.Field {
+	case SortByName:
+		c.Name = p.Name
+	case SortByPrice:
+		c.Price = p.Price
+	case SortByCreatedAt:
+		c.CreatedAt = p.CreatedAt
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const ProblemContentType = "application/problem+json"
+
+const ProblemTypePrefix = "urn:problem-type:"
+
+// RequestIDHeader is generated when the client hasn't supplied it
+const RequestIDHeader = "X-Request-ID"
+
+const MaxRequestIDLength = 128
+
+// Problem is the RFC 9457 body of the HTTP error responses
+type Problem struct {
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Status    int            `json:"status"`
+	Detail    string         `json:"detail"`
+	Instance  string         `json:"instance,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+	Errors    []ProblemField `json:"errors,omitempty"`
+}
+
+type ProblemField struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// NewProblem describes the error of the request, the request ID is taken from the context
+func NewProblem(ctx context.Context, err error, instance string) Problem {
+	status := HTTPStatus(err)
+	return Problem{
+		Type:      ProblemTypePrefix + strings.ReplaceAll(string(KindOf(err)), " ", "-"),
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    err.Error(),
+		Instance:  instance,
+		RequestID: RequestIDFromContext(ctx),
+		Errors:    problemFields(err, nil),
+	}
+}
+
+func problemFields(err error, fields []ProblemField) []ProblemField {
+	switch e := err.(type) {
+	case *FieldError:
+		return append(fields, ProblemField{Field: e.Field, Detail: e.Error()})
+	case interface{ Unwrap() error }:
+		return problemFields(e.Unwrap(), fields)
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			fields = problemFields(inner, fields)
+		}
+	}
+	return fields
+}
+
+// FieldError tells which request field the error is about
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+// InvalidField returns the error of the field, the untyped errors become InvalidArgument
+func InvalidField(field string, err error) error {
+	var typed *Error
+	if !errors.As(err, &typed) {
+		err = &Error{Kind: InvalidArgument, Err: err}
+	}
+	return &FieldError{Field: field, Err: err}
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+type requestIDKey struct{}
+
+// WithRequestID stores the ID of the request, the invalid ones are replaced with the generated ones
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" || len(id) > MaxRequestIDLength {
+		id = uuid.NewString()
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	ret, _ := ctx.Value(requestIDKey{}).(string)
+	return ret
+}
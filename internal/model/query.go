@@ -0,0 +1,286 @@
+package model
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+type SortField string
+
+const (
+	SortByID        SortField = "id"
+	SortByName      SortField = "name"
+	SortByPrice     SortField = "price"
+	SortByCreatedAt SortField = "created_at"
+)
+
+// ProductFilter narrows the listing, zero values mean no restriction and bounds are inclusive
+type ProductFilter struct {
+	NameContains   string
+	MinPrice       *decimal.Decimal
+	MaxPrice       *decimal.Decimal
+	CreatedFrom    *uint32
+	CreatedTo      *uint32
+	IncludeDeleted bool
+}
+
+// ProductSort orders the listing, ties are broken by id
+type ProductSort struct {
+	Field SortField
+	Desc  bool
+}
+
+type ProductQuery struct {
+	Filter ProductFilter
+	Sort   ProductSort
+}
+
+func ParseProductQuery(name string, minPriceStr string, maxPriceStr string, createdFromStr string,
+	createdToStr string, includeDeletedStr string, sortStr string) (ProductQuery, error) {
+	ret := ProductQuery{
+		Filter: ProductFilter{
+			NameContains: name,
+		},
+	}
+	var err error
+	if ret.Filter.MinPrice, err = ParsePriceBound(minPriceStr, "min price"); err != nil {
+		return ProductQuery{}, err
+	}
+	if ret.Filter.MaxPrice, err = ParsePriceBound(maxPriceStr, "max price"); err != nil {
+		return ProductQuery{}, err
+	}
+	if ret.Filter.CreatedFrom, err = parseUintParam(createdFromStr, "created from"); err != nil {
+		return ProductQuery{}, err
+	}
+	if ret.Filter.CreatedTo, err = parseUintParam(createdToStr, "created to"); err != nil {
+		return ProductQuery{}, err
+	}
+	if includeDeletedStr != "" {
+		if ret.Filter.IncludeDeleted, err = strconv.ParseBool(includeDeletedStr); err != nil {
+			return ProductQuery{}, InvalidField("include_deleted", fmt.Errorf("%w: include deleted is not a boolean",
+				ErrorInvalidQuery))
+		}
+	}
+	ret.Sort = ParseSort(sortStr)
+	return ret, ret.Validate()
+}
+
+// ParseSort reads a field name optionally prefixed with "-" for descending order
+func ParseSort(str string) ProductSort {
+	return ProductSort{
+		Field: SortField(strings.TrimPrefix(str, "-")),
+		Desc:  strings.HasPrefix(str, "-"),
+	}
+}
+
+func ParsePriceBound(str string, name string) (*decimal.Decimal, error) {
+	if str == "" {
+		return nil, nil
+	}
+	val, err := decimal.NewFromString(str)
+	if err != nil {
+		return nil, InvalidField(paramField(name), fmt.Errorf("%w: %s is not a decimal number", ErrorInvalidQuery, name))
+	}
+	return &val, nil
+}
+
+func parseUintParam(str string, name string) (*uint32, error) {
+	if str == "" {
+		return nil, nil
+	}
+	val, err := strconv.ParseUint(str, 10, 32)
+	if err != nil {
+		return nil, InvalidField(paramField(name), fmt.Errorf("%w: %s is not a timestamp", ErrorInvalidQuery, name))
+	}
+	ret := uint32(val)
+	return &ret, nil
+}
+
+func paramField(name string) string {
+	return strings.ReplaceAll(name, " ", "_")
+}
+
+// Validate checks the query and defaults the sort field to id
+func (q *ProductQuery) Validate() error {
+	switch q.Sort.Field {
+	case "":
+		q.Sort.Field = SortByID
+	case SortByID, SortByName, SortByPrice, SortByCreatedAt:
+	default:
+		return InvalidField("sort", fmt.Errorf("%w: unknown sort field %q", ErrorInvalidQuery, q.Sort.Field))
+	}
+	f := q.Filter
+	if f.MinPrice != nil && f.MaxPrice != nil && f.MinPrice.GreaterThan(*f.MaxPrice) {
+		return fmt.Errorf("%w: min price is greater than max price", ErrorInvalidQuery)
+	}
+	if f.CreatedFrom != nil && f.CreatedTo != nil && *f.CreatedFrom > *f.CreatedTo {
+		return fmt.Errorf("%w: created from is later than created to", ErrorInvalidQuery)
+	}
+	return nil
+}
+
+// Match reports whether the product passes the filter
+func (f ProductFilter) Match(p Product) bool {
+	if !f.IncludeDeleted && p.DeletedAt != 0 {
+		return false
+	}
+	if f.NameContains != "" && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(f.NameContains)) {
+		return false
+	}
+	if f.MinPrice != nil && p.Price.LessThan(*f.MinPrice) {
+		return false
+	}
+	if f.MaxPrice != nil && p.Price.GreaterThan(*f.MaxPrice) {
+		return false
+	}
+	if f.CreatedFrom != nil && p.CreatedAt < *f.CreatedFrom {
+		return false
+	}
+	if f.CreatedTo != nil && p.CreatedAt > *f.CreatedTo {
+		return false
+	}
+	return true
+}
+
+func (s ProductSort) Key(p Product) any {
+	switch s.Field {
+	case SortByName:
+		return p.Name
+	case SortByPrice:
+		return p.Price
+	case SortByCreatedAt:
+		return p.CreatedAt
+	default:
+		return p.ID
+	}
+}
+
+// Compare orders the products the way the listing does
+func (s ProductSort) Compare(a Product, b Product) int {
+	var ret int
+	switch s.Field {
+	case SortByName:
+		ret = cmp.Compare(a.Name, b.Name)
+	case SortByPrice:
+		ret = a.Price.Cmp(b.Price)
+	case SortByCreatedAt:
+		ret = cmp.Compare(a.CreatedAt, b.CreatedAt)
+	}
+	if ret == 0 {
+		ret = cmp.Compare(a.ID, b.ID)
+	}
+	if s.Desc {
+		return -ret
+	}
+	return ret
+}
+
+// SQLDialect describes how the SQL backends differ
+type SQLDialect struct {
+	// Placeholder returns the bind variable of the 1-based position
+	Placeholder func(n int) string
+	LikeEscape  string
+	// PriceColumn is the price column when empty
+	PriceColumn string
+	PriceValue  func(placeholder string) string
+	// PriceArg converts the price bound, it's rounded onto the price scale first
+	PriceArg func(price decimal.Decimal) any
+}
+
+func (d SQLDialect) column(field SortField) string {
+	if field == SortByPrice && d.PriceColumn != "" {
+		return d.PriceColumn
+	}
+	return string(field)
+}
+
+func (d SQLDialect) arg(field SortField, val any) any {
+	if price, ok := val.(decimal.Decimal); ok && field == SortByPrice && d.PriceArg != nil {
+		return d.PriceArg(price)
+	}
+	return val
+}
+
+func (d SQLDialect) value(field SortField, placeholder string) string {
+	if field == SortByPrice && d.PriceValue != nil {
+		return d.PriceValue(placeholder)
+	}
+	return placeholder
+}
+
+func (d SQLDialect) In(column string, vals []string) (string, []any) {
+	placeholders := make([]string, len(vals))
+	args := make([]any, len(vals))
+	for i, val := range vals {
+		placeholders[i] = d.Placeholder(i + 1)
+		args[i] = val
+	}
+	return column + " IN (" + strings.Join(placeholders, ", ") + ")", args
+}
+
+var QuestionPlaceholder = func(int) string { return "?" }
+
+// SQL returns the WHERE and ORDER BY clauses, after is the last product of the previous page or nil
+func (q ProductQuery) SQL(d SQLDialect, after *Product) (string, string, []any) {
+	var conds []string
+	var args []any
+	bind := func(val any) string {
+		args = append(args, val)
+		return d.Placeholder(len(args))
+	}
+
+	f := q.Filter
+	if !f.IncludeDeleted {
+		conds = append(conds, "deleted_at = 0")
+	}
+	if f.NameContains != "" {
+		conds = append(conds, "LOWER(name) LIKE "+bind(LikePattern(strings.ToLower(f.NameContains)))+d.LikeEscape)
+	}
+	if f.MinPrice != nil {
+		conds = append(conds, d.column(SortByPrice)+" >= "+
+			d.value(SortByPrice, bind(d.arg(SortByPrice, f.MinPrice.RoundCeil(PriceScale)))))
+	}
+	if f.MaxPrice != nil {
+		conds = append(conds, d.column(SortByPrice)+" <= "+
+			d.value(SortByPrice, bind(d.arg(SortByPrice, f.MaxPrice.RoundFloor(PriceScale)))))
+	}
+	if f.CreatedFrom != nil {
+		conds = append(conds, "created_at >= "+bind(*f.CreatedFrom))
+	}
+	if f.CreatedTo != nil {
+		conds = append(conds, "created_at <= "+bind(*f.CreatedTo))
+	}
+
+	op, dir := ">", "ASC"
+	if q.Sort.Desc {
+		op, dir = "<", "DESC"
+	}
+	field := d.column(q.Sort.Field)
+	if after != nil {
+		if q.Sort.Field == SortByID {
+			conds = append(conds, "id "+op+" "+bind(after.ID))
+		} else {
+			key := d.arg(q.Sort.Field, q.Sort.Key(*after))
+			conds = append(conds, fmt.Sprintf("(%s %s %s OR (%s = %s AND id %s %s))", field, op,
+				d.value(q.Sort.Field, bind(key)), field, d.value(q.Sort.Field, bind(key)), op, bind(after.ID)))
+		}
+	}
+
+	where := "1 = 1"
+	if len(conds) > 0 {
+		where = strings.Join(conds, " AND ")
+	}
+	order := "id " + dir
+	if q.Sort.Field != SortByID {
+		order = field + " " + dir + ", " + order
+	}
+	return where, order, args
+}
+
+func LikePattern(val string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(val) + "%"
+}
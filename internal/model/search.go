@@ -0,0 +1,127 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+	MaxSearchTerms     = 16
+)
+
+// SearchQuery matches every term to the beginning of a word of the name
+type SearchQuery struct {
+	Terms []string
+	Limit int
+}
+
+type SearchResponse struct {
+	Items []Product `json:"items"`
+}
+
+func ParseSearchQuery(text string, limitStr string) (SearchQuery, error) {
+	var limit int
+	if limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil {
+			return SearchQuery{}, InvalidField("limit", fmt.Errorf("%w: limit is not a number", ErrorInvalidQuery))
+		}
+	}
+	return NewSearchQuery(text, limit)
+}
+
+func NewSearchQuery(text string, limit int) (SearchQuery, error) {
+	ret := SearchQuery{
+		Terms: Tokenize(text),
+		Limit: limit,
+	}
+	if len(ret.Terms) == 0 {
+		return SearchQuery{}, InvalidField("q", fmt.Errorf("%w: search text has no words", ErrorInvalidQuery))
+	}
+	if len(ret.Terms) > MaxSearchTerms {
+		return SearchQuery{}, InvalidField("q", fmt.Errorf("%w: search text has more than %d words",
+			ErrorInvalidQuery, MaxSearchTerms))
+	}
+	if ret.Limit < 0 {
+		return SearchQuery{}, InvalidField("limit", fmt.Errorf("%w: limit must not be negative", ErrorInvalidQuery))
+	}
+	if ret.Limit == 0 {
+		ret.Limit = DefaultSearchLimit
+	}
+	ret.Limit = min(ret.Limit, MaxSearchLimit)
+	return ret, nil
+}
+
+// Tokenize splits the text into lowercase words the way the full-text indexes do
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Prefixes returns the distinct beginnings of the words of the name
+func Prefixes(name string) []string {
+	ret := []string{}
+	seen := map[string]bool{}
+	add := func(prefix string) {
+		if !seen[prefix] {
+			seen[prefix] = true
+			ret = append(ret, prefix)
+		}
+	}
+	for _, word := range Tokenize(name) {
+		for i := range word {
+			if i > 0 {
+				add(word[:i])
+			}
+		}
+		add(word)
+	}
+	return ret
+}
+
+// Score rates the name for the backends without a native ranking, zero means no match
+func (q SearchQuery) Score(words []string) float64 {
+	var ret float64
+	for _, term := range q.Terms {
+		var best float64
+		for _, word := range words {
+			if strings.HasPrefix(word, term) {
+				best = max(best, float64(len(term))/float64(len(word)))
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		ret += best
+	}
+	return ret / float64(len(words))
+}
+
+func (q SearchQuery) FTS5() string {
+	parts := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		parts[i] = `"` + term + `"*`
+	}
+	return strings.Join(parts, " ")
+}
+
+func (q SearchQuery) TSQuery() string {
+	parts := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		parts[i] = term + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+func (q SearchQuery) BooleanMode() string {
+	parts := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		parts[i] = "+" + term + "*"
+	}
+	return strings.Join(parts, " ")
+}
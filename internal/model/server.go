@@ -1,7 +1,48 @@
 package model
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
 
 type Server interface {
 	Start(ctx context.Context) error
 }
+
+// Handler is implemented by the servers sharing the listener, Stop ends their streams
+type Handler interface {
+	Handler() http.Handler
+	Stop()
+}
+
+// Serve runs serve until the context is done, or returns its error when it stops on its own
+func Serve(ctx context.Context, name string, serve func() error, shutdown func() error) error {
+	log.Info().Msgf("starting %s server", name)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- serve()
+	}()
+	select {
+	case err := <-errs:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("%s server failed: %w", name, err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+	log.Info().Msgf("gracefully shutting down %s server", name)
+	return shutdown()
+}
+
+// RequestContext sets the request ID and the caller identity
+func RequestContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithRequestID(r.Context(), r.Header.Get(RequestIDHeader))
+		w.Header().Set(RequestIDHeader, RequestIDFromContext(ctx))
+		next.ServeHTTP(w, r.WithContext(WithActor(ctx, r.Header.Get(ActorHeader))))
+	})
+}
@@ -0,0 +1,90 @@
+package model
+
+import "context"
+
+// typedDB classifies the untyped errors of the backend
+type typedDB struct {
+	db DB
+}
+
+func TypedDB(db DB) DB {
+	return typedDB{db: db}
+}
+
+func (t typedDB) Add(ctx context.Context, val Product) (Product, error) {
+	ret, err := t.db.Add(ctx, val)
+	return ret, Typed(err)
+}
+
+func (t typedDB) Update(ctx context.Context, val ProductUpdate) (Product, error) {
+	ret, err := t.db.Update(ctx, val)
+	return ret, Typed(err)
+}
+
+func (t typedDB) Upsert(ctx context.Context, val Product) (Product, bool, error) {
+	ret, created, err := t.db.Upsert(ctx, val)
+	return ret, created, Typed(err)
+}
+
+func (t typedDB) Delete(ctx context.Context, id string, version uint64) (Product, error) {
+	ret, err := t.db.Delete(ctx, id, version)
+	return ret, Typed(err)
+}
+
+func (t typedDB) AddBatch(ctx context.Context, vals []Product) ([]BatchResult, error) {
+	ret, err := t.db.AddBatch(ctx, vals)
+	return ret, Typed(err)
+}
+
+func (t typedDB) UpdateBatch(ctx context.Context, vals []ProductUpdate) ([]BatchResult, error) {
+	ret, err := t.db.UpdateBatch(ctx, vals)
+	return ret, Typed(err)
+}
+
+func (t typedDB) DeleteBatch(ctx context.Context, vals []Product) ([]BatchResult, error) {
+	ret, err := t.db.DeleteBatch(ctx, vals)
+	return ret, Typed(err)
+}
+
+func (t typedDB) Restore(ctx context.Context, id string) (Product, error) {
+	ret, err := t.db.Restore(ctx, id)
+	return ret, Typed(err)
+}
+
+func (t typedDB) Purge(ctx context.Context, before uint32) (int64, error) {
+	ret, err := t.db.Purge(ctx, before)
+	return ret, Typed(err)
+}
+
+func (t typedDB) History(ctx context.Context, id string) ([]HistoryEntry, error) {
+	ret, err := t.db.History(ctx, id)
+	return ret, Typed(err)
+}
+
+func (t typedDB) Get(ctx context.Context, id string) (Product, error) {
+	ret, err := t.db.Get(ctx, id)
+	return ret, Typed(err)
+}
+
+func (t typedDB) GetMany(ctx context.Context, ids []string) ([]Product, error) {
+	ret, err := t.db.GetMany(ctx, ids)
+	return ret, Typed(err)
+}
+
+func (t typedDB) GetAll(ctx context.Context, query ProductQuery, page PageRequest) (ProductPage, error) {
+	ret, err := t.db.GetAll(ctx, query, page)
+	return ret, Typed(err)
+}
+
+func (t typedDB) Search(ctx context.Context, query SearchQuery) ([]Product, error) {
+	ret, err := t.db.Search(ctx, query)
+	return ret, Typed(err)
+}
+
+func (t typedDB) Ping(ctx context.Context) error {
+	return Typed(t.db.Ping(ctx))
+}
+
+func (t typedDB) Start() error {
+	return t.db.Start()
+}
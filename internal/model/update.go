@@ -0,0 +1,160 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+const MergePatchContentType = "application/merge-patch+json"
+
+// Optional is a field of a partial update: unchanged unless Set, cleared when Null
+type Optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func Value[T any](val T) Optional[T] {
+	return Optional[T]{Set: true, Value: val}
+}
+
+func Null[T any]() Optional[T] {
+	return Optional[T]{Set: true, Null: true}
+}
+
+func (o Optional[T]) Get() T {
+	if o.Null {
+		var zero T
+		return zero
+	}
+	return o.Value
+}
+
+// UnmarshalJSON is only called for the present members, so the missing ones stay unset
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*o = Null[T]()
+		return nil
+	}
+	var val T
+	if err := json.Unmarshal(data, &val); err != nil {
+		return err
+	}
+	*o = Value(val)
+	return nil
+}
+
+// ProductUpdate changes the set fields of the product, the currency can't be cleared
+type ProductUpdate struct {
+	ID       string                    `json:"id"`
+	Version  uint64                    `json:"version,omitempty"`
+	Name     Optional[string]          `json:"name"`
+	Price    Optional[decimal.Decimal] `json:"price"`
+	Currency Optional[string]          `json:"currency"`
+}
+
+// ParseUpdate builds the update out of the form values, the empty ones are left as they are
+func ParseUpdate(id string, name string, priceStr string, currency string) (ProductUpdate, error) {
+	if id == "" {
+		return ProductUpdate{}, InvalidField("id", NewError(InvalidArgument, "invalid id"))
+	}
+	ret := ProductUpdate{
+		ID: id,
+	}
+	if name != "" {
+		ret.Name = Value(name)
+	}
+	if priceStr != "" {
+		price, err := ParsePrice(priceStr)
+		if err != nil {
+			return ProductUpdate{}, err
+		}
+		ret.Price = Value(price)
+	}
+	if currency != "" {
+		ret.Currency = Value(currency)
+	}
+	return ret, ret.Validate()
+}
+
+// ParseMergePatch reads the JSON merge patch of the product, null clears the field
+func ParseMergePatch(id string, r io.Reader) (ProductUpdate, error) {
+	if id == "" {
+		return ProductUpdate{}, InvalidField("id", NewError(InvalidArgument, "invalid id"))
+	}
+	var ret ProductUpdate
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&ret); err != nil {
+		return ProductUpdate{}, fmt.Errorf("%w: body must be a JSON merge patch of name, price and currency",
+			ErrorInvalidProduct)
+	}
+	ret.ID = id
+	return ret, ret.Validate()
+}
+
+func IsMergePatch(contentType string) bool {
+	return strings.HasPrefix(contentType, MergePatchContentType)
+}
+
+// Validate checks the new values, an update has to change at least one field
+func (u ProductUpdate) Validate() error {
+	if !u.Name.Set && !u.Price.Set && !u.Currency.Set {
+		return ErrorNoUpdateParams
+	}
+	if len(u.Name.Get()) > 255 {
+		return InvalidField("name", fmt.Errorf("%w: name must not be longer than 255 characters", ErrorInvalidProduct))
+	}
+	if err := ValidatePrice(u.Price.Get()); err != nil {
+		return err
+	}
+	if u.Currency.Null {
+		return InvalidField("currency", fmt.Errorf("%w: currency can't be cleared", ErrorInvalidProduct))
+	}
+	if u.Currency.Set {
+		return ValidateCurrency(u.Currency.Value)
+	}
+	return nil
+}
+
+// Apply returns the product with the fields of the update set
+func (u ProductUpdate) Apply(p Product) Product {
+	if u.Name.Set {
+		p.Name = u.Name.Get()
+	}
+	if u.Price.Set {
+		p.Price = u.Price.Get()
+	}
+	if u.Currency.Set {
+		p.Currency = u.Currency.Get()
+	}
+	return p
+}
+
+func (u ProductUpdate) Columns() ([]string, []any) {
+	var cols []string
+	var vals []any
+	if u.Name.Set {
+		cols, vals = append(cols, "name"), append(vals, u.Name.Get())
+	}
+	if u.Price.Set {
+		cols, vals = append(cols, "price"), append(vals, u.Price.Get())
+	}
+	if u.Currency.Set {
+		cols, vals = append(cols, "currency"), append(vals, u.Currency.Get())
+	}
+	return cols, vals
+}
+
+// SQL returns the assignments of the SET clause, the placeholders are numbered from 1
+func (u ProductUpdate) SQL(d SQLDialect) (string, []any) {
+	cols, vals := u.Columns()
+	for i, col := range cols {
+		cols[i] = col + " = " + d.Placeholder(i+1)
+	}
+	return strings.Join(cols, ", "), vals
+}
@@ -4,13 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"math/rand/v2"
+	"fmt"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ClickHouse/clickhouse-go/v2"
-	"github.com/google/uuid"
-	"github.com/jaswdr/faker/v2"
 
 	"github.com/aleksandrzhukovskii/go-template/internal/config"
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
@@ -19,6 +19,9 @@ import (
 type Service struct {
 	opt *clickhouse.Options
 	db  clickhouse.Conn
+	// The mutations don't report the affected rows and MergeTree doesn't enforce the unique ids,
+	// so the writes are serialized here and conditioned on the version
+	mu sync.Mutex
 }
 
 func New(cfg config.Config) (model.DB, error) {
@@ -48,6 +51,10 @@ func New(cfg config.Config) (model.DB, error) {
 	}, nil
 }
 
+func (s *Service) Ping(ctx context.Context) error {
+	return s.db.Ping(ctx)
+}
+
 func (s *Service) Start() error {
 	conn, err := clickhouse.Open(s.opt)
 	if err != nil {
@@ -67,82 +74,570 @@ func (s *Service) Start() error {
 func (s *Service) migrate() error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
 	defer cancel()
-	return s.db.Exec(ctx, `
+	if err := s.db.Exec(ctx, `
 		CREATE TABLE IF NOT EXISTS products (
 			id String,
 			name String,
-			price Float64,
-			created_at UInt32
+			price Decimal(18, 4),
+			created_at UInt32,
+			version UInt64 DEFAULT 1,
+			deleted_at UInt32 DEFAULT 0
 		) ENGINE = MergeTree()
 		ORDER BY id
-	`)
+	`); err != nil {
+		return err
+	}
+	if err := s.db.Exec(ctx, "ALTER TABLE products ADD COLUMN IF NOT EXISTS version UInt64 DEFAULT 1"); err != nil {
+		return err
+	}
+	if err := s.db.Exec(ctx, "ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at UInt32 DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := s.db.Exec(ctx, "ALTER TABLE products ADD COLUMN IF NOT EXISTS currency String DEFAULT 'USD'"); err != nil {
+		return err
+	}
+	// The prefixes of the words of the name, hasToken looks them up in the bloom filter
+	if err := s.db.Exec(ctx, "ALTER TABLE products ADD COLUMN IF NOT EXISTS name_prefixes String MATERIALIZED "+
+		"arrayStringConcat(arrayDistinct(arrayFlatten(arrayMap(w -> arrayMap(i -> substringUTF8(w, 1, i), "+
+		"range(1, lengthUTF8(w) + 1)), splitByNonAlpha(lowerUTF8(name))))), ' ')"); err != nil {
+		return err
+	}
+	if err := s.db.Exec(ctx, "ALTER TABLE products ADD INDEX IF NOT EXISTS name_prefixes_tokens name_prefixes "+
+		"TYPE tokenbf_v1(10240, 3, 0) GRANULARITY 4"); err != nil {
+		return err
+	}
+	if err := s.db.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS product_history (
+			product_id String,
+			version UInt64,
+			operation String,
+			changed_at UInt32,
+			actor String,
+			name String,
+			price Decimal(18, 4),
+			currency String DEFAULT 'USD',
+			created_at UInt32,
+			deleted_at UInt32
+		) ENGINE = MergeTree()
+		ORDER BY (product_id, version)
+	`); err != nil {
+		return err
+	}
+	err := s.db.Exec(ctx, "ALTER TABLE product_history ADD COLUMN IF NOT EXISTS currency String DEFAULT 'USD'")
+	if err != nil {
+		return err
+	}
+	// Tables created when the prices were floats
+	for _, table := range []string{model.TableName, model.HistoryTableName} {
+		var typ string
+		err = s.db.QueryRow(ctx, "SELECT type FROM system.columns "+
+			"WHERE database = currentDatabase() AND table = ? AND name = 'price'", table).Scan(&typ)
+		if err != nil {
+			return err
+		}
+		if typ == "Float64" {
+			if err = s.db.Exec(ctx, "ALTER TABLE "+table+" MODIFY COLUMN price Decimal(18, 4)"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
-func (s *Service) Add(ctx context.Context) (string, error) {
-	f := faker.NewWithSeed(rand.NewPCG(uint64(time.Now().Unix()), uint64(time.Now().UnixNano())))
-	id := uuid.NewString()
-	return id, s.db.Exec(ctx, "INSERT INTO products (id, name, price, created_at) VALUES (?, ?, ?, ?)",
-		id, f.Food().Fruit(), f.Float64(2, 1, 100), uint32(time.Now().Unix()))
-}
+func (s *Service) Add(ctx context.Context, val model.Product) (model.Product, error) {
+	val, err := model.PrepareProduct(val)
+	if err != nil {
+		return model.Product{}, err
+	}
 
-func (s *Service) Update(ctx context.Context, val model.Product) error {
+	// MergeTree doesn't enforce primary key uniqueness, so it has to be checked manually
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	var exists uint8
-	err := s.db.QueryRow(ctx, "SELECT 1 FROM products WHERE id = ? LIMIT 1", val.ID).Scan(&exists)
+	err = s.db.QueryRow(ctx, "SELECT 1 FROM products WHERE id = ? LIMIT 1", val.ID).Scan(&exists)
+	if err == nil {
+		return model.Product{}, model.ErrorAlreadyExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return model.Product{}, err
+	}
+
+	if err = s.insert(ctx, val); err != nil {
+		return model.Product{}, err
+	}
+	return val, s.record(ctx, model.NewHistoryEntry(ctx, model.OperationAdd, val))
+}
+
+func (s *Service) insert(ctx context.Context, val model.Product) error {
+	return s.db.Exec(ctx, "INSERT INTO products (id, name, price, currency, created_at, version, deleted_at) "+
+		"VALUES (?, ?, toDecimal64(?, 4), ?, ?, ?, ?)", val.ID, val.Name, val.Price, val.Currency, val.CreatedAt,
+		val.Version, val.DeletedAt)
+}
+
+// Upsert inserts the next version of the row and deletes the previous ones right away
+func (s *Service) Upsert(ctx context.Context, val model.Product) (model.Product, bool, error) {
+	val, err := model.PrepareUpsert(val)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return model.ErrorNoRowsUpdated
+		return model.Product{}, false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current model.Product
+	err = s.db.QueryRow(ctx, "SELECT id, name, price, created_at, version, deleted_at, currency FROM products "+
+		"WHERE id = ? ORDER BY version DESC LIMIT 1", val.ID).
+		Scan(&current.ID, &current.Name, &current.Price, &current.CreatedAt, &current.Version, &current.DeletedAt,
+			&current.Currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err = s.insert(ctx, val); err != nil {
+			return model.Product{}, false, err
 		}
-		return err
+		return val, true, s.record(ctx, model.NewHistoryEntry(ctx, model.OperationAdd, val))
+	}
+	if err != nil {
+		return model.Product{}, false, err
+	}
+	if val.Unchanged(current) {
+		return current, false, nil
 	}
 
-	if val.Name != "" && val.Price != 0 {
-		err = s.db.Exec(ctx, "ALTER TABLE products UPDATE name = ?, price = ? WHERE id = ?", val.Name, val.Price, val.ID)
-	} else if val.Price != 0 {
-		err = s.db.Exec(ctx, "ALTER TABLE products UPDATE price = ? WHERE id = ?", val.Price, val.ID)
-	} else if val.Name != "" {
-		err = s.db.Exec(ctx, "ALTER TABLE products UPDATE name = ? WHERE id = ?", val.Name, val.ID)
-	} else {
-		return model.ErrorNoUpdateParams
+	ret := val.Replace(current)
+	if err = s.insert(ctx, ret); err != nil {
+		return model.Product{}, false, err
 	}
-	return err
+	if err = s.db.Exec(ctx, "ALTER TABLE products DELETE WHERE id = ? AND version < ?", ret.ID,
+		ret.Version); err != nil {
+		return model.Product{}, false, err
+	}
+	return ret, false, s.record(ctx, model.NewHistoryEntry(ctx, model.OperationUpdate, ret))
 }
 
-func (s *Service) Delete(ctx context.Context, id string) error {
-	var exists uint8
-	err := s.db.QueryRow(ctx, "SELECT 1 FROM products WHERE id = ? LIMIT 1", id).Scan(&exists)
+func (s *Service) Update(ctx context.Context, val model.ProductUpdate) (model.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.update(ctx, val)
+}
+
+func (s *Service) update(ctx context.Context, val model.ProductUpdate) (model.Product, error) {
+	if err := val.Validate(); err != nil {
+		return model.Product{}, err
+	}
+
+	current, err := s.checkVersion(ctx, val.ID, val.Version, model.ErrorNoRowsUpdated)
+	if err != nil {
+		return model.Product{}, err
+	}
+
+	cols, args := val.Columns()
+	for i, col := range cols {
+		if col == "price" {
+			cols[i] = "price = toDecimal64(?, 4)"
+		} else {
+			cols[i] = col + " = ?"
+		}
+	}
+	err = s.db.Exec(ctx, "ALTER TABLE products UPDATE "+strings.Join(cols, ", ")+", version = version + 1 "+
+		"WHERE id = ? AND version = ?", append(args, val.ID, current)...)
+	if err != nil {
+		return model.Product{}, err
+	}
+	return s.recordChange(ctx, model.OperationUpdate, val.ID)
+}
+
+func (s *Service) Delete(ctx context.Context, id string, version uint64) (model.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, err := s.checkVersion(ctx, id, version, model.ErrorNoRowsDeleted)
+	if err != nil {
+		return model.Product{}, err
+	}
+
+	err = s.db.Exec(ctx, "ALTER TABLE products UPDATE deleted_at = ?, version = version + 1 "+
+		"WHERE id = ? AND version = ?", uint32(time.Now().Unix()), id, current)
+	if err != nil {
+		return model.Product{}, err
+	}
+	return s.recordChange(ctx, model.OperationDelete, id)
+}
+
+func (s *Service) AddBatch(ctx context.Context, vals []model.Product) ([]model.BatchResult, error) {
+	if err := model.ValidateBatch(len(vals)); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, err := s.stored(ctx, vals)
+	if err != nil {
+		return nil, err
+	}
+	batch, err := s.db.PrepareBatch(ctx, "INSERT INTO products (id, name, price, currency, created_at, version)")
+	if err != nil {
+		return nil, err
+	}
+	ret, err := model.RunBatch(vals, func(val model.Product) (*model.Product, error) {
+		val, err := model.PrepareProduct(val)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := stored[val.ID]; ok {
+			return nil, model.ErrorAlreadyExists
+		}
+		// The following items with the same id have to fail as well
+		stored[val.ID] = val
+		if err = batch.Append(val.ID, val.Name, val.Price, val.Currency, val.CreatedAt, val.Version); err != nil {
+			return nil, err
+		}
+		return &val, nil
+	})
+	if err != nil || batch.Rows() == 0 {
+		_ = batch.Abort()
+		return ret, err
+	}
+	if err = batch.Send(); err != nil {
+		return nil, err
+	}
+	var entries []model.HistoryEntry
+	for _, res := range ret {
+		if res.Product != nil {
+			entries = append(entries, model.NewHistoryEntry(ctx, model.OperationAdd, *res.Product))
+		}
+	}
+	if err = s.record(ctx, entries...); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (s *Service) UpdateBatch(ctx context.Context, vals []model.ProductUpdate) ([]model.BatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return model.RunBatch(vals, func(val model.ProductUpdate) (*model.Product, error) {
+		prod, err := s.update(ctx, val)
+		return &prod, err
+	})
+}
+
+func (s *Service) DeleteBatch(ctx context.Context, vals []model.Product) ([]model.BatchResult, error) {
+	if err := model.ValidateBatch(len(vals)); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, err := s.stored(ctx, vals)
+	if err != nil {
+		return nil, err
+	}
+	var matched []clickhouse.GroupSet
+	var ids []any
+	ret, err := model.RunBatch(vals, func(val model.Product) (*model.Product, error) {
+		current, ok := stored[val.ID]
+		if !ok || current.DeletedAt != 0 {
+			return nil, model.ErrorNoRowsDeleted
+		}
+		if val.Version != 0 && val.Version != current.Version {
+			return nil, model.ErrorVersionConflict
+		}
+		// The following items with the same id have to fail as already deleted
+		delete(stored, val.ID)
+		matched = append(matched, clickhouse.GroupSet{Value: []any{val.ID, current.Version}})
+		ids = append(ids, val.ID)
+		return nil, nil
+	})
+	if err != nil || len(matched) == 0 {
+		return ret, err
+	}
+	err = s.db.Exec(ctx, "ALTER TABLE products UPDATE deleted_at = ?, version = version + 1 "+
+		"WHERE (id, version) IN (?)", uint32(time.Now().Unix()), matched)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := s.recordChanges(ctx, model.OperationDelete, ids...)
+	if err != nil {
+		return nil, err
+	}
+	for i, res := range ret {
+		if prod, ok := changed[res.ID]; ok && res.Error == "" {
+			ret[i].Product = &prod
+		}
+	}
+	return ret, nil
+}
+
+// stored only reads the id, version and deleted_at of the products
+func (s *Service) stored(ctx context.Context, vals []model.Product) (map[string]model.Product, error) {
+	ids := make([]any, 0, len(vals))
+	for _, val := range vals {
+		if val.ID != "" {
+			ids = append(ids, val.ID)
+		}
+	}
+	ret := make(map[string]model.Product, len(ids))
+	if len(ids) == 0 {
+		return ret, nil
+	}
+	rows, err := s.db.Query(ctx, "SELECT id, version, deleted_at FROM products WHERE id IN ?",
+		clickhouse.GroupSet{Value: ids})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var elem model.Product
+		if err = rows.Scan(&elem.ID, &elem.Version, &elem.DeletedAt); err != nil {
+			return nil, err
+		}
+		ret[elem.ID] = elem
+	}
+	return ret, rows.Err()
+}
+
+func (s *Service) Restore(ctx context.Context, id string) (model.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var current uint64
+	err := s.db.QueryRow(ctx, "SELECT version FROM products WHERE id = ? AND deleted_at <> 0 LIMIT 1", id).
+		Scan(&current)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return model.ErrorNoRowsDeleted
+			return model.Product{}, model.ErrorNoRowsRestored
 		}
+		return model.Product{}, err
+	}
+
+	err = s.db.Exec(ctx, "ALTER TABLE products UPDATE deleted_at = 0, version = version + 1 "+
+		"WHERE id = ? AND version = ?", id, current)
+	if err != nil {
+		return model.Product{}, err
+	}
+	return s.recordChange(ctx, model.OperationRestore, id)
+}
+
+func (s *Service) Purge(ctx context.Context, before uint32) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var cnt uint64
+	err := s.db.QueryRow(ctx, "SELECT count() FROM products WHERE deleted_at <> 0 AND deleted_at <= ?", before).
+		Scan(&cnt)
+	if err != nil || cnt == 0 {
+		return 0, err
+	}
+
+	err = s.db.Exec(ctx, "ALTER TABLE product_history DELETE WHERE product_id IN "+
+		"(SELECT id FROM products WHERE deleted_at <> 0 AND deleted_at <= ?)", before)
+	if err != nil {
+		return 0, err
+	}
+	err = s.db.Exec(ctx, "ALTER TABLE products DELETE WHERE deleted_at <> 0 AND deleted_at <= ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return int64(cnt), nil
+}
+
+func (s *Service) record(ctx context.Context, entries ...model.HistoryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	batch, err := s.db.PrepareBatch(ctx, "INSERT INTO product_history ("+model.HistoryColumns+")")
+	if err != nil {
 		return err
 	}
+	for _, entry := range entries {
+		if err = batch.Append(entry.Values()...); err != nil {
+			_ = batch.Abort()
+			return err
+		}
+	}
+	return batch.Send()
+}
+
+// recordChanges relies on mutations_sync to read the values the mutation has written
+func (s *Service) recordChanges(ctx context.Context, op model.Operation, ids ...any) (map[string]model.Product, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := s.db.Query(ctx, "SELECT id, name, price, created_at, version, deleted_at, currency FROM products "+
+		"WHERE id IN ?",
+		clickhouse.GroupSet{Value: ids})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []model.HistoryEntry
+	ret := make(map[string]model.Product, len(ids))
+	for rows.Next() {
+		var val model.Product
+		err = rows.Scan(&val.ID, &val.Name, &val.Price, &val.CreatedAt, &val.Version, &val.DeletedAt, &val.Currency)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, model.NewHistoryEntry(ctx, op, val))
+		ret[val.ID] = val
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, s.record(ctx, entries...)
+}
 
-	return s.db.Exec(ctx, "ALTER TABLE products DELETE WHERE id = ?", id)
+func (s *Service) recordChange(ctx context.Context, op model.Operation, id string) (model.Product, error) {
+	changed, err := s.recordChanges(ctx, op, id)
+	if err != nil {
+		return model.Product{}, err
+	}
+	ret, ok := changed[id]
+	if !ok {
+		return model.Product{}, model.ErrorNotFound
+	}
+	return ret, nil
+}
+
+func (s *Service) History(ctx context.Context, id string) ([]model.HistoryEntry, error) {
+	rows, err := s.db.Query(ctx, "SELECT "+model.HistoryColumns+" FROM product_history WHERE product_id = ? "+
+		"ORDER BY version", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ret []model.HistoryEntry
+	for rows.Next() {
+		elem, err := model.ScanHistoryEntry(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, elem)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return model.LinkHistory(ret), nil
+}
+
+func (s *Service) checkVersion(ctx context.Context, id string, version uint64, notFound error) (uint64, error) {
+	var current uint64
+	err := s.db.QueryRow(ctx, "SELECT version FROM products WHERE id = ? AND deleted_at = 0 LIMIT 1", id).Scan(&current)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, notFound
+		}
+		return 0, err
+	}
+	if version != 0 && version != current {
+		return 0, model.ErrorVersionConflict
+	}
+	return current, nil
 }
 
 func (s *Service) Get(ctx context.Context, id string) (model.Product, error) {
 	var ret model.Product
-	row := s.db.QueryRow(ctx, "SELECT id, name, price, created_at FROM products WHERE id = ? LIMIT 1", id)
-	if err := row.Scan(&ret.ID, &ret.Name, &ret.Price, &ret.CreatedAt); err != nil {
+	row := s.db.QueryRow(ctx, "SELECT id, name, price, created_at, version, deleted_at, currency FROM products "+
+		"WHERE id = ? AND deleted_at = 0 LIMIT 1", id)
+	err := row.Scan(&ret.ID, &ret.Name, &ret.Price, &ret.CreatedAt, &ret.Version, &ret.DeletedAt, &ret.Currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Product{}, model.ErrorNotFound
+	}
+	if err != nil {
 		return model.Product{}, err
 	}
 	return ret, nil
 }
 
-func (s *Service) GetAll(ctx context.Context) ([]model.Product, error) {
-	rows, err := s.db.Query(ctx, "SELECT id, name, price, created_at FROM products")
+// ClickHouse doesn't compare the decimals to strings
+var dialect = model.SQLDialect{
+	Placeholder: model.QuestionPlaceholder,
+	PriceValue:  func(placeholder string) string { return "toDecimal128(" + placeholder + ", 18)" },
+}
+
+// The id isn't unique, so the products are taken once the way Get does
+func (s *Service) GetMany(ctx context.Context, ids []string) ([]model.Product, error) {
+	if len(ids) == 0 {
+		return []model.Product{}, nil
+	}
+	in, args := dialect.In("id", ids)
+	rows, err := s.db.Query(ctx, "SELECT id, name, price, created_at, version, deleted_at, currency FROM products "+
+		"WHERE "+in+" AND deleted_at = 0 LIMIT 1 BY id", args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
+	ret := []model.Product{}
+	for rows.Next() {
+		var elem model.Product
+		err = rows.Scan(&elem.ID, &elem.Name, &elem.Price, &elem.CreatedAt, &elem.Version, &elem.DeletedAt,
+			&elem.Currency)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, elem)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (s *Service) GetAll(ctx context.Context, query model.ProductQuery, page model.PageRequest) (model.ProductPage, error) {
+	size, after, err := page.Prepare(&query)
+	if err != nil {
+		return model.ProductPage{}, err
+	}
+	// The table is ordered by id, so sorting by id uses the primary index
+	where, order, args := query.SQL(dialect, after)
+	rows, err := s.db.Query(ctx, fmt.Sprintf(
+		"SELECT id, name, price, created_at, version, deleted_at, currency FROM products WHERE %s ORDER BY %s LIMIT %d",
+		where, order, size+1),
+		args...)
+	if err != nil {
+		return model.ProductPage{}, err
+	}
+	defer rows.Close()
 
 	var ret []model.Product
 	for rows.Next() {
 		var elem model.Product
-		if err = rows.Scan(&elem.ID, &elem.Name, &elem.Price, &elem.CreatedAt); err != nil {
+		err = rows.Scan(&elem.ID, &elem.Name, &elem.Price, &elem.CreatedAt, &elem.Version, &elem.DeletedAt,
+			&elem.Currency)
+		if err != nil {
+			return model.ProductPage{}, err
+		}
+		ret = append(ret, elem)
+	}
+	if err = rows.Err(); err != nil {
+		return model.ProductPage{}, err
+	}
+	return model.NewProductPage(ret, size, query.Sort), nil
+}
+
+// Search ranks the matches the way model.SearchQuery.Score does
+func (s *Service) Search(ctx context.Context, query model.SearchQuery) ([]model.Product, error) {
+	conds := []string{"deleted_at = 0"}
+	var args []any
+	for _, term := range query.Terms {
+		conds = append(conds, "hasToken(name_prefixes, ?)")
+		args = append(args, term)
+	}
+	args = append(args, query.Terms, query.Limit)
+	rows, err := s.db.Query(ctx, "WITH splitByNonAlpha(lowerUTF8(name)) AS words "+
+		"SELECT id, name, price, created_at, version, deleted_at, currency FROM products WHERE "+strings.Join(conds, " AND ")+
+		" ORDER BY arraySum(t -> arrayMax(w -> if(startsWith(w, t), length(t) / length(w), 0), words), ?) / "+
+		"length(words) DESC, id LIMIT ?", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := []model.Product{}
+	for rows.Next() {
+		var elem model.Product
+		err = rows.Scan(&elem.ID, &elem.Name, &elem.Price, &elem.CreatedAt, &elem.Version, &elem.DeletedAt,
+			&elem.Currency)
+		if err != nil {
 			return nil, err
 		}
 		ret = append(ret, elem)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 	return ret, nil
 }
@@ -0,0 +1,169 @@
+package content
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+
+	"github.com/aleksandrzhukovskii/go-template/internal/model"
+	"github.com/aleksandrzhukovskii/go-template/internal/service/grpc"
+)
+
+// Format is the canonical media type of the body
+type Format string
+
+const (
+	Form       Format = "application/x-www-form-urlencoded"
+	MergePatch Format = model.MergePatchContentType
+	JSON       Format = "application/json"
+	MsgPack    Format = "application/msgpack"
+	Protobuf   Format = "application/x-protobuf"
+)
+
+var formats = map[string]Format{
+	string(Form):                      Form,
+	"multipart/form-data":             Form,
+	string(MergePatch):                MergePatch,
+	string(JSON):                      JSON,
+	string(MsgPack):                   MsgPack,
+	"application/x-msgpack":           MsgPack,
+	"application/vnd.msgpack":         MsgPack,
+	string(Protobuf):                  Protobuf,
+	"application/protobuf":            Protobuf,
+	"application/vnd.google.protobuf": Protobuf,
+}
+
+var msgpack = func() *codec.MsgpackHandle {
+	ret := &codec.MsgpackHandle{WriteExt: true}
+	ret.MapType = reflect.TypeOf(map[string]any(nil))
+	ret.RawToString = true
+	return ret
+}()
+
+// RequestFormat returns the format of the body, the body without the type is a form
+func RequestFormat(contentType string) (Format, error) {
+	if contentType == "" {
+		return Form, nil
+	}
+	typ, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid Content-Type %q", model.ErrorUnsupportedMediaType, contentType)
+	}
+	if ret, ok := formats[typ]; ok {
+		return ret, nil
+	}
+	return "", fmt.Errorf("%w: %q, the body must be a form, JSON, MessagePack or protobuf",
+		model.ErrorUnsupportedMediaType, typ)
+}
+
+// ResponseFormat picks the format by the Accept header, JSON by default
+func ResponseFormat(accept string) (Format, error) {
+	if accept == "" {
+		return JSON, nil
+	}
+	type mediaRange struct {
+		typ string
+		q   float64
+	}
+	var ranges []mediaRange
+	for _, val := range strings.Split(accept, ",") {
+		typ, params, err := mime.ParseMediaType(strings.TrimSpace(val))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if val, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(val, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{typ: typ, q: q})
+		}
+	}
+	slices.SortStableFunc(ranges, func(a, b mediaRange) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+	for _, r := range ranges {
+		if r.typ == "*/*" || r.typ == "application/*" {
+			return JSON, nil
+		}
+		if ret, ok := formats[r.typ]; ok && ret != Form && ret != MergePatch {
+			return ret, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q, the response can be JSON, MessagePack or protobuf", model.ErrorNotAcceptable, accept)
+}
+
+// DecodeUpdate reads the JSON, MessagePack or protobuf update, the body carries the id and the version
+func DecodeUpdate(format Format, r io.Reader) (model.ProductUpdate, error) {
+	var ret model.ProductUpdate
+	switch format {
+	case JSON:
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&ret); err != nil {
+			return model.ProductUpdate{}, fmt.Errorf(
+				"%w: body must be a JSON object of id, version, name, price and currency", model.ErrorInvalidProduct)
+		}
+	case MsgPack:
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return model.ProductUpdate{}, err
+		}
+		var val map[string]any
+		if err := codec.NewDecoderBytes(b, msgpack).Decode(&val); err != nil {
+			return model.ProductUpdate{}, fmt.Errorf(
+				"%w: body must be a MessagePack map of id, version, name, price and currency", model.ErrorInvalidProduct)
+		}
+		// The map is read as JSON to have the same null handling
+		b, err = json.Marshal(val)
+		if err != nil {
+			return model.ProductUpdate{}, fmt.Errorf("%w: %w", model.ErrorInvalidProduct, err)
+		}
+		return DecodeUpdate(JSON, bytes.NewReader(b))
+	case Protobuf:
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return model.ProductUpdate{}, err
+		}
+		if ret, err = grpc.UnmarshalUpdate(b); err != nil {
+			return model.ProductUpdate{}, err
+		}
+	default:
+		return model.ProductUpdate{}, fmt.Errorf("%w: %q", model.ErrorUnsupportedMediaType, format)
+	}
+	if ret.ID == "" {
+		return model.ProductUpdate{}, model.InvalidField("id", model.NewError(model.InvalidArgument, "invalid id"))
+	}
+	return ret, ret.Validate()
+}
+
+func EncodeUpdateResponse(format Format, msg string) ([]byte, error) {
+	switch format {
+	case JSON:
+		return json.Marshal(map[string]string{"msg": msg})
+	case MsgPack:
+		var ret []byte
+		err := codec.NewEncoderBytes(&ret, msgpack).Encode(map[string]string{"msg": msg})
+		return ret, err
+	case Protobuf:
+		return grpc.MarshalUpdateResponse(msg)
+	default:
+		return nil, fmt.Errorf("%w: %q", model.ErrorNotAcceptable, format)
+	}
+}
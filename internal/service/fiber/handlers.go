@@ -1,14 +1,15 @@
 package fiber
 
 import (
+	"bufio"
 	"bytes"
-	"database/sql"
-	"errors"
+	"context"
 	"fmt"
 
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
+	"github.com/aleksandrzhukovskii/go-template/internal/service/content"
 )
 
 func (s *Service) Main(c *fiber.Ctx) error {
@@ -32,58 +33,273 @@ func (s *Service) Main(c *fiber.Ctx) error {
 }
 
 func (s *Service) AddProduct(c *fiber.Ctx) error {
-	id, err := s.db.Add(c.Context())
+	val, err := s.addProduct(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return s.sendError(c, err)
 	}
-	return c.Status(fiber.StatusOK).JSON(&model.Product{ID: id})
+	return c.Status(fiber.StatusOK).JSON(&val)
 }
 
+func (s *Service) CreateProduct(c *fiber.Ctx) error {
+	val, err := s.addProduct(c)
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	c.Location(model.ProductLocation(val.ID))
+	return c.Status(fiber.StatusCreated).JSON(&val)
+}
+
+func (s *Service) addProduct(c *fiber.Ctx) (model.Product, error) {
+	prod := model.SampleProduct()
+	if c.FormValue("sample") != "true" {
+		var err error
+		prod, err = model.ParseNewProduct(c.FormValue("id"), c.FormValue("name"), c.FormValue("price"),
+			c.FormValue("currency"))
+		if err != nil {
+			return model.Product{}, err
+		}
+	}
+	return s.db.Add(c.UserContext(), prod)
+}
+
+// UpdateProduct reads the body by its Content-Type and answers by the Accept header
 func (s *Service) UpdateProduct(c *fiber.Ctx) error {
-	prod, err := model.ParseProduct(c.FormValue("id"), c.FormValue("name"), c.FormValue("price"))
+	respFormat, err := content.ResponseFormat(c.Get(fiber.HeaderAccept))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return s.sendError(c, err)
 	}
-	err = s.db.Update(c.Context(), prod)
+	reqFormat, err := content.RequestFormat(c.Get(fiber.HeaderContentType))
 	if err != nil {
-		status := fiber.StatusInternalServerError
-		if errors.Is(err, model.ErrorNoRowsUpdated) {
-			status = fiber.StatusBadRequest
-		}
-		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		return s.sendError(c, err)
+	}
+	var prod model.ProductUpdate
+	var version string
+	switch reqFormat {
+	case content.Form:
+		version = c.FormValue("version")
+		prod, err = model.ParseUpdate(c.FormValue("id"), c.FormValue("name"), c.FormValue("price"),
+			c.FormValue("currency"))
+	case content.MergePatch:
+		version = c.Query("version")
+		prod, err = model.ParseMergePatch(c.Query("id"), bytes.NewReader(c.Body()))
+	default:
+		prod, err = content.DecodeUpdate(reqFormat, bytes.NewReader(c.Body()))
+	}
+	if err == nil && (reqFormat == content.Form || reqFormat == content.MergePatch) {
+		prod.Version, err = model.ParseVersion(version)
+	}
+	if err == nil {
+		prod.Version, err = model.CheckIfMatch(c.UserContext(), s.db, prod.ID, prod.Version,
+			c.Get(fiber.HeaderIfMatch))
+	}
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	_, err = s.db.Update(c.UserContext(), prod)
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	b, err := content.EncodeUpdateResponse(respFormat, "Product updated")
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	c.Set(fiber.HeaderContentType, string(respFormat))
+	return c.Status(fiber.StatusOK).Send(b)
+}
+
+func (s *Service) UpsertProduct(c *fiber.Ctx) error {
+	prod, err := model.ParseNewProduct(c.Params("id"), c.FormValue("name"), c.FormValue("price"),
+		c.FormValue("currency"))
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	val, created, err := s.db.Upsert(c.UserContext(), prod)
+	if err != nil {
+		return s.sendError(c, err)
 	}
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{"msg": "Product updated"})
+	status := fiber.StatusOK
+	if created {
+		status = fiber.StatusCreated
+	}
+	return c.Status(status).JSON(&val)
+}
+
+func (s *Service) PatchProductByID(c *fiber.Ctx) error {
+	prod, err := model.ParseMergePatch(c.Params("id"), bytes.NewReader(c.Body()))
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	if prod.Version, err = model.ParseVersion(c.Query("version")); err != nil {
+		return s.sendError(c, err)
+	}
+	prod.Version, err = model.CheckIfMatch(c.UserContext(), s.db, prod.ID, prod.Version, c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	val, err := s.db.Update(c.UserContext(), prod)
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(&val)
 }
 
 func (s *Service) DeleteProduct(c *fiber.Ctx) error {
-	id := c.FormValue("id")
-	if err := s.db.Delete(c.Context(), id); err != nil {
-		status := fiber.StatusInternalServerError
-		if errors.Is(err, model.ErrorNoRowsDeleted) {
-			status = fiber.StatusBadRequest
-		}
-		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	if err := s.deleteProduct(c, c.FormValue("id")); err != nil {
+		return s.sendError(c, err)
 	}
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"msg": "Product deleted"})
 }
 
+func (s *Service) DeleteProductByID(c *fiber.Ctx) error {
+	if err := s.deleteProduct(c, c.Params("id")); err != nil {
+		return s.sendError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (s *Service) deleteProduct(c *fiber.Ctx, id string) error {
+	version, err := model.ParseVersion(c.FormValue("version"))
+	if err != nil {
+		return err
+	}
+	version, err = model.CheckIfMatch(c.UserContext(), s.db, id, version, c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Delete(c.UserContext(), id, version)
+	return err
+}
+
+func (s *Service) AddProducts(c *fiber.Ctx) error {
+	return batch(s, c, s.db.AddBatch)
+}
+
+func (s *Service) UpdateProducts(c *fiber.Ctx) error {
+	return batch(s, c, s.db.UpdateBatch)
+}
+
+func (s *Service) DeleteProducts(c *fiber.Ctx) error {
+	return batch(s, c, s.db.DeleteBatch)
+}
+
+func batch[T model.BatchItem](s *Service, c *fiber.Ctx,
+	run func(ctx context.Context, vals []T) ([]model.BatchResult, error)) error {
+	vals, err := model.ParseBatch[T](bytes.NewReader(c.Body()))
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	val, err := run(c.UserContext(), vals)
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(model.BatchResponse{Results: val})
+}
+
+func (s *Service) RestoreProduct(c *fiber.Ctx) error {
+	if _, err := s.db.Restore(c.UserContext(), c.FormValue("id")); err != nil {
+		return s.sendError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"msg": "Product restored"})
+}
+
+func (s *Service) PurgeProducts(c *fiber.Ctx) error {
+	days, err := model.ParsePurgeDays(c.Query("days"))
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	cnt, err := s.db.Purge(c.UserContext(), model.PurgeBefore(days))
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(model.PurgeResult{Purged: cnt})
+}
+
+// GetProduct serves both the resource route and the legacy one
 func (s *Service) GetProduct(c *fiber.Ctx) error {
-	id := c.FormValue("id")
-	val, err := s.db.Get(c.Context(), id)
+	id := c.Params("id")
+	if id == "" {
+		id = c.FormValue("id")
+	}
+	asOf, err := model.ParseAsOf(c.FormValue("as_of"))
 	if err != nil {
-		status := fiber.StatusInternalServerError
-		if errors.Is(err, sql.ErrNoRows) {
-			status = fiber.StatusBadRequest
-		}
-		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		return s.sendError(c, err)
 	}
-	return c.Status(fiber.StatusOK).JSON(&val)
+	val, err := model.GetAsOf(c.UserContext(), s.db, id, asOf)
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	return s.sendTagged(c, val)
+}
+
+func (s *Service) GetProductHistory(c *fiber.Ctx) error {
+	entries, err := s.db.History(c.UserContext(), c.FormValue("id"))
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(model.HistoryResponse{Entries: entries})
 }
 
 func (s *Service) GetProducts(c *fiber.Ctx) error {
-	val, err := s.db.GetAll(c.Context())
+	query, err := model.ParseProductQuery(c.Query("name"), c.Query("min_price"), c.Query("max_price"),
+		c.Query("created_from"), c.Query("created_to"), c.Query("include_deleted"), c.Query("sort"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return s.sendError(c, err)
 	}
-	return c.Status(fiber.StatusOK).JSON(&val)
+	page, err := model.ParsePageRequest(c.Query("page_size"), c.Query("cursor"))
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	val, err := s.db.GetAll(c.UserContext(), query, page)
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	return s.sendTagged(c, val)
+}
+
+func (s *Service) SearchProducts(c *fiber.Ctx) error {
+	query, err := model.ParseSearchQuery(c.Query("q"), c.Query("limit"))
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	val, err := s.db.Search(c.UserContext(), query)
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(model.SearchResponse{Items: val})
+}
+
+// StreamProductEvents writes the events after the handler returns
+func (s *Service) StreamProductEvents(c *fiber.Ctx) error {
+	after, err := model.ParseLastEventID(c.Get(model.LastEventIDHeader))
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	feed, err := s.feeds.Open(c.UserContext(), after)
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	c.Set(fiber.HeaderContentType, model.EventStreamContentType)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Status(fiber.StatusOK).Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		_ = feed.Write(w, w.Flush)
+	})
+	return nil
+}
+
+func (s *Service) sendError(c *fiber.Ctx, err error) error {
+	problem := model.NewProblem(c.UserContext(), err, c.OriginalURL())
+	return c.Status(problem.Status).JSON(problem, model.ProblemContentType)
+}
+
+// sendTagged answers 304 when the If-None-Match header matches the tag
+func (s *Service) sendTagged(c *fiber.Ctx, val any) error {
+	etag, err := model.ETag(val)
+	if err != nil {
+		return s.sendError(c, err)
+	}
+	c.Set(fiber.HeaderETag, etag)
+	if model.NoneMatch(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return c.Status(fiber.StatusOK).JSON(val)
 }
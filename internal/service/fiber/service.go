@@ -2,16 +2,18 @@ package fiber
 
 import (
 	"context"
-	"errors"
 	"net"
 	"net/http"
+	"net/netip"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/filesystem"
-	"github.com/rs/zerolog/log"
 
 	"github.com/aleksandrzhukovskii/go-template/api"
+	"github.com/aleksandrzhukovskii/go-template/internal/config"
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
 	"github.com/aleksandrzhukovskii/go-template/web/swagger"
 )
@@ -20,22 +22,48 @@ type Service struct {
 	server *fiber.App
 	db     model.DB
 	lis    net.Listener
+	feeds  *model.Feeds
 }
 
-func New(db model.DB, lis net.Listener) (model.Server, error) {
+func New(cfg config.Config, db model.DB, lis net.Listener) (model.Server, error) {
 	ret := &Service{
 		server: fiber.New(fiber.Config{
 			DisableStartupMessage: true,
+			// The in-memory storages keep the strings, they mustn't point to the reused buffers
+			Immutable: true,
 		}),
-		db:  db,
-		lis: lis,
+		db:    db,
+		lis:   lis,
+		feeds: model.NewFeeds(db, cfg.Events.Heartbeat),
 	}
+	// The handlers pass the user context to the storage
+	ret.server.Use(func(c *fiber.Ctx) error {
+		ctx := model.WithRequestID(c.UserContext(), c.Get(model.RequestIDHeader))
+		c.Set(model.RequestIDHeader, model.RequestIDFromContext(ctx))
+		c.SetUserContext(model.WithActor(ctx, c.Get(model.ActorHeader)))
+		return c.Next()
+	})
 	ret.server.All("/", ret.Main)
 	ret.server.Post("/add", ret.AddProduct)
 	ret.server.Put("/update", ret.UpdateProduct)
+	ret.server.Patch("/update", ret.UpdateProduct)
 	ret.server.Delete("/delete", ret.DeleteProduct)
+	ret.server.Post("/batch", ret.AddProducts)
+	ret.server.Put("/batch", ret.UpdateProducts)
+	ret.server.Delete("/batch", ret.DeleteProducts)
+	ret.server.Post("/restore", ret.RestoreProduct)
+	ret.server.Delete("/purge", ret.PurgeProducts)
 	ret.server.Get("/get", ret.GetProduct)
+	ret.server.Get("/history", ret.GetProductHistory)
+	ret.server.Get("/search", ret.SearchProducts)
 	ret.server.Get("/get_all", ret.GetProducts)
+	ret.server.Post("/products", ret.CreateProduct)
+	ret.server.Get("/products", ret.GetProducts)
+	ret.server.Get("/products/events", ret.StreamProductEvents)
+	ret.server.Get("/products/:id", ret.GetProduct)
+	ret.server.Put("/products/:id", ret.UpsertProduct)
+	ret.server.Patch("/products/:id", ret.PatchProductByID)
+	ret.server.Delete("/products/:id", ret.DeleteProductByID)
 	ret.server.Get("/swagger.yaml", func(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusOK).Type("text/yaml").Send(api.SwaggerConfig)
 	})
@@ -45,14 +73,36 @@ func New(db model.DB, lis net.Listener) (model.Server, error) {
 	return ret, nil
 }
 
+// Handler serves the event streams by net/http, the adaptor buffers the responses
+func (s *Service) Handler() http.Handler {
+	handler := adaptor.FiberApp(s.server)
+	events := model.RequestContext(s.feeds)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/products/events" {
+			events.ServeHTTP(w, r)
+			return
+		}
+		// The adaptor fails on the non-TCP remote addresses, like the ones of bufconn
+		if _, err := netip.ParseAddrPort(r.RemoteAddr); err != nil {
+			r = r.WithContext(r.Context())
+			r.RemoteAddr = "0.0.0.0:0"
+		}
+		handler(w, r)
+	})
+}
+
+func (s *Service) Stop() {
+	s.feeds.Stop()
+}
+
 func (s *Service) Start(ctx context.Context) error {
-	log.Info().Msg("starting fiber server")
-	go func() {
-		if err := s.server.Listener(s.lis); err != nil && !errors.Is(err, http.ErrServerClosed) && !strings.Contains(err.Error(), "closed") {
-			log.Fatal().Err(err).Msg("failed to start fiber server")
+	return model.Serve(ctx, "fiber", func() error {
+		if err := s.server.Listener(s.lis); err != nil && !strings.Contains(err.Error(), "closed") {
+			return err
 		}
-	}()
-	<-ctx.Done()
-	log.Info().Msg("gracefully shutting down fiber server")
-	return s.server.Shutdown()
+		return nil
+	}, func() error {
+		s.Stop()
+		return s.server.ShutdownWithTimeout(time.Second * 30)
+	})
 }
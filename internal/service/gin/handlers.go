@@ -2,20 +2,21 @@ package gin
 
 import (
 	"bytes"
-	"database/sql"
-	"errors"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
+	"github.com/aleksandrzhukovskii/go-template/internal/service/content"
 )
 
 func (s *Service) Main(ctx *gin.Context) {
 	body, err := ctx.GetRawData()
 	if err != nil {
-		s.sendError(ctx, http.StatusInternalServerError, err)
+		s.sendError(ctx, err)
 	}
 
 	var response bytes.Buffer
@@ -38,82 +39,319 @@ func (s *Service) Main(ctx *gin.Context) {
 }
 
 func (s *Service) AddProduct(ctx *gin.Context) {
-	id, err := s.db.Add(ctx)
+	val, err := s.addProduct(ctx)
 	if err != nil {
-		s.sendError(ctx, http.StatusInternalServerError, err)
+		s.sendError(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, model.Product{ID: id})
+	ctx.JSON(http.StatusOK, val)
 }
 
+func (s *Service) CreateProduct(ctx *gin.Context) {
+	val, err := s.addProduct(ctx)
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	ctx.Header("Location", model.ProductLocation(val.ID))
+	ctx.JSON(http.StatusCreated, val)
+}
+
+func (s *Service) addProduct(ctx *gin.Context) (model.Product, error) {
+	prod := model.SampleProduct()
+	if sample, _ := ctx.GetPostForm("sample"); sample != "true" {
+		id, _ := ctx.GetPostForm("id")
+		name, _ := ctx.GetPostForm("name")
+		price, _ := ctx.GetPostForm("price")
+		currency, _ := ctx.GetPostForm("currency")
+		var err error
+		prod, err = model.ParseNewProduct(id, name, price, currency)
+		if err != nil {
+			return model.Product{}, err
+		}
+	}
+	return s.db.Add(ctx, prod)
+}
+
+// UpdateProduct reads the body by its Content-Type and answers by the Accept header
 func (s *Service) UpdateProduct(ctx *gin.Context) {
-	id, _ := ctx.GetPostForm("id")
+	respFormat, err := content.ResponseFormat(ctx.GetHeader("Accept"))
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	reqFormat, err := content.RequestFormat(ctx.GetHeader("Content-Type"))
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	var prod model.ProductUpdate
+	var version string
+	switch reqFormat {
+	case content.Form:
+		id, _ := ctx.GetPostForm("id")
+		name, _ := ctx.GetPostForm("name")
+		price, _ := ctx.GetPostForm("price")
+		currency, _ := ctx.GetPostForm("currency")
+		version, _ = ctx.GetPostForm("version")
+		prod, err = model.ParseUpdate(id, name, price, currency)
+	case content.MergePatch:
+		version = ctx.Query("version")
+		prod, err = model.ParseMergePatch(ctx.Query("id"), ctx.Request.Body)
+	default:
+		prod, err = content.DecodeUpdate(reqFormat, ctx.Request.Body)
+	}
+	if err == nil && (reqFormat == content.Form || reqFormat == content.MergePatch) {
+		prod.Version, err = model.ParseVersion(version)
+	}
+	if err == nil {
+		prod.Version, err = model.CheckIfMatch(ctx, s.db, prod.ID, prod.Version, ctx.GetHeader(model.IfMatchHeader))
+	}
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	_, err = s.db.Update(ctx, prod)
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	b, err := content.EncodeUpdateResponse(respFormat, "Product updated")
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	ctx.Data(http.StatusOK, string(respFormat), b)
+}
+
+func (s *Service) UpsertProduct(ctx *gin.Context) {
 	name, _ := ctx.GetPostForm("name")
 	price, _ := ctx.GetPostForm("price")
-	prod, err := model.ParseProduct(id, name, price)
+	currency, _ := ctx.GetPostForm("currency")
+	prod, err := model.ParseNewProduct(ctx.Param("id"), name, price, currency)
 	if err != nil {
-		s.sendError(ctx, http.StatusBadRequest, err)
+		s.sendError(ctx, err)
 		return
 	}
-	err = s.db.Update(ctx, prod)
+	val, created, err := s.db.Upsert(ctx, prod)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, model.ErrorNoRowsUpdated) {
-			status = http.StatusBadRequest
-		}
-		s.sendError(ctx, status, err)
+		s.sendError(ctx, err)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	ctx.JSON(status, val)
+}
+
+func (s *Service) PatchProductByID(ctx *gin.Context) {
+	prod, err := model.ParseMergePatch(ctx.Param("id"), ctx.Request.Body)
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	if prod.Version, err = model.ParseVersion(ctx.Query("version")); err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	prod.Version, err = model.CheckIfMatch(ctx, s.db, prod.ID, prod.Version, ctx.GetHeader(model.IfMatchHeader))
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	val, err := s.db.Update(ctx, prod)
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, val)
+}
+
+func (s *Service) DeleteProduct(ctx *gin.Context) {
+	if err := s.deleteProduct(ctx, ctx.Query("id")); err != nil {
+		s.sendError(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, struct {
 		Msg string `json:"msg"`
 	}{
-		Msg: "Product updated",
+		Msg: "Product deleted",
 	})
 }
 
-func (s *Service) DeleteProduct(ctx *gin.Context) {
-	id, _ := ctx.GetQuery("id")
-	if err := s.db.Delete(ctx, id); err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, model.ErrorNoRowsDeleted) {
-			status = http.StatusBadRequest
-		}
-		s.sendError(ctx, status, err)
+func (s *Service) DeleteProductByID(ctx *gin.Context) {
+	if err := s.deleteProduct(ctx, ctx.Param("id")); err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (s *Service) deleteProduct(ctx *gin.Context, id string) error {
+	version, err := model.ParseVersion(ctx.Query("version"))
+	if err != nil {
+		return err
+	}
+	if version, err = model.CheckIfMatch(ctx, s.db, id, version, ctx.GetHeader(model.IfMatchHeader)); err != nil {
+		return err
+	}
+	_, err = s.db.Delete(ctx, id, version)
+	return err
+}
+
+func (s *Service) AddProducts(ctx *gin.Context) {
+	batch(s, ctx, s.db.AddBatch)
+}
+
+func (s *Service) UpdateProducts(ctx *gin.Context) {
+	batch(s, ctx, s.db.UpdateBatch)
+}
+
+func (s *Service) DeleteProducts(ctx *gin.Context) {
+	batch(s, ctx, s.db.DeleteBatch)
+}
+
+func batch[T model.BatchItem](s *Service, ctx *gin.Context,
+	run func(ctx context.Context, vals []T) ([]model.BatchResult, error)) {
+	vals, err := model.ParseBatch[T](ctx.Request.Body)
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	val, err := run(ctx, vals)
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, model.BatchResponse{Results: val})
+}
+
+func (s *Service) RestoreProduct(ctx *gin.Context) {
+	id, _ := ctx.GetPostForm("id")
+	if _, err := s.db.Restore(ctx, id); err != nil {
+		s.sendError(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, struct {
 		Msg string `json:"msg"`
 	}{
-		Msg: "Product deleted",
+		Msg: "Product restored",
 	})
 }
 
+func (s *Service) PurgeProducts(ctx *gin.Context) {
+	days, err := model.ParsePurgeDays(ctx.Query("days"))
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	cnt, err := s.db.Purge(ctx, model.PurgeBefore(days))
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, model.PurgeResult{Purged: cnt})
+}
+
+// GetProduct serves both the resource route and the legacy one
 func (s *Service) GetProduct(ctx *gin.Context) {
-	id, _ := ctx.GetQuery("id")
-	val, err := s.db.Get(ctx, id)
+	id := ctx.Param("id")
+	if id == "" {
+		id = ctx.Query("id")
+	}
+	asOf, err := model.ParseAsOf(ctx.Query("as_of"))
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, sql.ErrNoRows) {
-			status = http.StatusBadRequest
-		}
-		s.sendError(ctx, status, err)
+		s.sendError(ctx, err)
 		return
 	}
+	val, err := model.GetAsOf(ctx, s.db, id, asOf)
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	s.sendTagged(ctx, val)
+}
 
-	ctx.JSON(http.StatusOK, val)
+func (s *Service) GetProductHistory(ctx *gin.Context) {
+	entries, err := s.db.History(ctx, ctx.Query("id"))
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, model.HistoryResponse{Entries: entries})
 }
 
 func (s *Service) GetProducts(ctx *gin.Context) {
-	val, err := s.db.GetAll(ctx)
+	query, err := model.ParseProductQuery(ctx.Query("name"), ctx.Query("min_price"), ctx.Query("max_price"),
+		ctx.Query("created_from"), ctx.Query("created_to"), ctx.Query("include_deleted"), ctx.Query("sort"))
 	if err != nil {
-		s.sendError(ctx, http.StatusInternalServerError, err)
+		s.sendError(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, val)
+	size, _ := ctx.GetQuery("page_size")
+	cursor, _ := ctx.GetQuery("cursor")
+	page, err := model.ParsePageRequest(size, cursor)
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	val, err := s.db.GetAll(ctx, query, page)
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	s.sendTagged(ctx, val)
 }
 
-func (s *Service) sendError(ctx *gin.Context, status int, err error) {
-	ctx.JSON(status, gin.H{
-		"error": err.Error(),
-	})
+func (s *Service) SearchProducts(ctx *gin.Context) {
+	query, err := model.ParseSearchQuery(ctx.Query("q"), ctx.Query("limit"))
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	val, err := s.db.Search(ctx, query)
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, model.SearchResponse{Items: val})
+}
+
+func (s *Service) StreamProductEvents(ctx *gin.Context) {
+	after, err := model.ParseLastEventID(ctx.GetHeader(model.LastEventIDHeader))
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	feed, err := s.feeds.Open(ctx.Request.Context(), after)
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	ctx.Header("Content-Type", model.EventStreamContentType)
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Status(http.StatusOK)
+	_ = feed.Write(ctx.Writer, http.NewResponseController(ctx.Writer).Flush)
+}
+
+func (s *Service) sendError(ctx *gin.Context, err error) {
+	problem := model.NewProblem(ctx.Request.Context(), err, ctx.Request.URL.RequestURI())
+	b, _ := json.Marshal(problem)
+	ctx.Data(problem.Status, model.ProblemContentType, b)
+}
+
+// sendTagged answers 304 when the If-None-Match header matches the tag
+func (s *Service) sendTagged(ctx *gin.Context, val any) {
+	etag, err := model.ETag(val)
+	if err != nil {
+		s.sendError(ctx, err)
+		return
+	}
+	ctx.Header(model.ETagHeader, etag)
+	if model.NoneMatch(ctx.GetHeader(model.IfNoneMatchHeader), etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.JSON(http.StatusOK, val)
 }
@@ -2,15 +2,14 @@ package gin
 
 import (
 	"context"
-	"errors"
 	"net"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/rs/zerolog/log"
 
 	"github.com/aleksandrzhukovskii/go-template/api"
+	"github.com/aleksandrzhukovskii/go-template/internal/config"
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
 	"github.com/aleksandrzhukovskii/go-template/web/swagger"
 )
@@ -19,14 +18,24 @@ type Service struct {
 	server *http.Server
 	db     model.DB
 	lis    net.Listener
+	feeds  *model.Feeds
 }
 
-func New(db model.DB, lis net.Listener) (model.Server, error) {
+func New(cfg config.Config, db model.DB, lis net.Listener) (model.Server, error) {
 	gin.SetMode(gin.ReleaseMode)
 	mux := gin.New()
+	// The fallback lets the storage see the values of the request context
+	mux.ContextWithFallback = true
+	mux.Use(func(ctx *gin.Context) {
+		reqCtx := model.WithRequestID(ctx.Request.Context(), ctx.GetHeader(model.RequestIDHeader))
+		ctx.Header(model.RequestIDHeader, model.RequestIDFromContext(reqCtx))
+		ctx.Request = ctx.Request.WithContext(model.WithActor(reqCtx, ctx.GetHeader(model.ActorHeader)))
+		ctx.Next()
+	})
 	ret := &Service{
-		db:  db,
-		lis: lis,
+		db:    db,
+		lis:   lis,
+		feeds: model.NewFeeds(db, cfg.Events.Heartbeat),
 	}
 	ret.server = &http.Server{
 		Handler: mux.Handler(),
@@ -34,9 +43,24 @@ func New(db model.DB, lis net.Listener) (model.Server, error) {
 	mux.Any("/", ret.Main)
 	mux.POST("/add", ret.AddProduct)
 	mux.PUT("/update", ret.UpdateProduct)
+	mux.PATCH("/update", ret.UpdateProduct)
 	mux.DELETE("/delete", ret.DeleteProduct)
+	mux.POST("/batch", ret.AddProducts)
+	mux.PUT("/batch", ret.UpdateProducts)
+	mux.DELETE("/batch", ret.DeleteProducts)
+	mux.POST("/restore", ret.RestoreProduct)
+	mux.DELETE("/purge", ret.PurgeProducts)
 	mux.GET("/get", ret.GetProduct)
+	mux.GET("/history", ret.GetProductHistory)
+	mux.GET("/search", ret.SearchProducts)
 	mux.GET("/get_all", ret.GetProducts)
+	mux.POST("/products", ret.CreateProduct)
+	mux.GET("/products", ret.GetProducts)
+	mux.GET("/products/events", ret.StreamProductEvents)
+	mux.GET("/products/:id", ret.GetProduct)
+	mux.PUT("/products/:id", ret.UpsertProduct)
+	mux.PATCH("/products/:id", ret.PatchProductByID)
+	mux.DELETE("/products/:id", ret.DeleteProductByID)
 	mux.GET("/swagger.yaml", func(ctx *gin.Context) {
 		ctx.Data(http.StatusOK, "text/yaml", api.SwaggerConfig)
 	})
@@ -45,16 +69,21 @@ func New(db model.DB, lis net.Listener) (model.Server, error) {
 	return ret, nil
 }
 
+func (s *Service) Handler() http.Handler {
+	return s.server.Handler
+}
+
+func (s *Service) Stop() {
+	s.feeds.Stop()
+}
+
 func (s *Service) Start(ctx context.Context) error {
-	log.Info().Msg("starting gin server")
-	go func() {
-		if err := s.server.Serve(s.lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatal().Err(err).Msg("failed to start gin server")
-		}
-	}()
-	<-ctx.Done()
-	log.Info().Msg("gracefully shutting down gin server")
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
-	return s.server.Shutdown(ctx)
+	return model.Serve(ctx, "gin", func() error {
+		return s.server.Serve(s.lis)
+	}, func() error {
+		s.Stop()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		return s.server.Shutdown(ctx)
+	})
 }
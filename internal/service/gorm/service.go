@@ -2,18 +2,16 @@ package gorm
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
-	"math/rand/v2"
 	"time"
 
-	"github.com/google/uuid"
-	"github.com/jaswdr/faker/v2"
+	"github.com/shopspring/decimal"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 	_ "modernc.org/sqlite"
 
@@ -48,9 +46,18 @@ func New(cfg config.Config) (model.DB, error) {
 	}
 }
 
+func (s *Service) Ping(ctx context.Context) error {
+	db, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return db.PingContext(ctx)
+}
+
 func (s *Service) Start() error {
 	db, err := gorm.Open(s.dial, &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
 	})
 	if err != nil {
 		return err
@@ -61,76 +68,412 @@ func (s *Service) Start() error {
 }
 
 func (s *Service) migrate() error {
-	return s.db.AutoMigrate(&model.Product{})
+	if s.dial.Name() == "sqlite" {
+		if err := s.textPrices(); err != nil {
+			return err
+		}
+	}
+	if err := s.db.AutoMigrate(&model.Product{}, &history{}); err != nil {
+		return err
+	}
+	return s.migrateSearch()
+}
+
+// textPrices stores the sqlite prices as text, the NUMERIC affinity would turn them into floats
+func (s *Service) textPrices() error {
+	for _, val := range []any{&model.Product{}, &history{}} {
+		stmt := &gorm.Statement{DB: s.db}
+		if err := stmt.Parse(val); err != nil {
+			return err
+		}
+		stmt.Schema.LookUpField("price").DataType = "text"
+	}
+	return nil
 }
 
-func (s *Service) Add(ctx context.Context) (string, error) {
-	f := faker.NewWithSeed(rand.NewPCG(uint64(time.Now().Unix()), uint64(time.Now().UnixNano())))
-	id := uuid.NewString()
+func (s *Service) migrateSearch() error {
+	switch s.dial.Name() {
+	case "sqlite":
+		for _, stmt := range sqliteSearchSchema {
+			if err := s.db.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	case "postgres":
+		return s.db.Exec("CREATE INDEX IF NOT EXISTS products_name_search ON products " +
+			"USING GIN (to_tsvector('simple', name))").Error
+	case "mysql":
+		if s.db.Migrator().HasIndex(&model.Product{}, "products_name_search") {
+			return nil
+		}
+		return s.db.Exec("CREATE FULLTEXT INDEX products_name_search ON products(name)").Error
+	default:
+		return fmt.Errorf("unsupported dialect: %s", s.dial.Name())
+	}
+}
+
+// The FTS5 table refers to the rowids, which VACUUM may change, so it's rebuilt on every start
+var sqliteSearchSchema = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS products_fts USING fts5(
+			name, content='products', content_rowid='rowid', tokenize='unicode61 remove_diacritics 0'
+		);`,
+	`CREATE TRIGGER IF NOT EXISTS products_fts_insert AFTER INSERT ON products BEGIN
+			INSERT INTO products_fts(rowid, name) VALUES (new.rowid, new.name);
+		END;`,
+	`CREATE TRIGGER IF NOT EXISTS products_fts_delete AFTER DELETE ON products BEGIN
+			INSERT INTO products_fts(products_fts, rowid, name) VALUES ('delete', old.rowid, old.name);
+		END;`,
+	`CREATE TRIGGER IF NOT EXISTS products_fts_update AFTER UPDATE OF name ON products BEGIN
+			INSERT INTO products_fts(products_fts, rowid, name) VALUES ('delete', old.rowid, old.name);
+			INSERT INTO products_fts(rowid, name) VALUES (new.rowid, new.name);
+		END;`,
+	"INSERT INTO products_fts(products_fts) VALUES ('rebuild');",
+}
+
+type history struct {
+	ProductID string          `gorm:"primaryKey;size:36"`
+	Version   uint64          `gorm:"primaryKey;autoIncrement:false"`
+	Operation string          `gorm:"not null;size:16"`
+	ChangedAt uint32          `gorm:"not null"`
+	Actor     string          `gorm:"not null;size:255"`
+	Name      string          `gorm:"size:255"`
+	Price     decimal.Decimal `gorm:"type:decimal(18,4)"`
+	Currency  string          `gorm:"size:3;not null;default:USD"`
+	CreatedAt uint32
+	DeletedAt uint32 `gorm:"not null"`
+}
+
+func (history) TableName() string {
+	return model.HistoryTableName
+}
+
+func (h history) entry() model.HistoryEntry {
+	return model.HistoryEntry{
+		ProductID: h.ProductID,
+		Version:   h.Version,
+		Operation: model.Operation(h.Operation),
+		ChangedAt: h.ChangedAt,
+		Actor:     h.Actor,
+		After: model.Product{
+			ID:        h.ProductID,
+			Name:      h.Name,
+			Price:     h.Price,
+			Currency:  h.Currency,
+			CreatedAt: h.CreatedAt,
+			Version:   h.Version,
+			DeletedAt: h.DeletedAt,
+		},
+	}
+}
+
+func (s *Service) Add(ctx context.Context, val model.Product) (ret model.Product, err error) {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ret, err = add(tx, val)
+		return err
+	})
+	return ret, err
+}
 
-	product := model.Product{
-		ID:        id,
-		Name:      f.Food().Fruit(),
-		Price:     f.Float64(2, 1, 100),
-		CreatedAt: uint32(time.Now().Unix()),
+func add(db *gorm.DB, val model.Product) (model.Product, error) {
+	val, err := model.PrepareProduct(val)
+	if err != nil {
+		return model.Product{}, err
+	}
+	// A failed statement aborts the whole transaction in postgres, so the conflict is skipped
+	tx := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&val)
+	if tx.Error != nil {
+		return model.Product{}, tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return model.Product{}, model.ErrorAlreadyExists
 	}
+	return val, record(db, model.NewHistoryEntry(db.Statement.Context, model.OperationAdd, val))
+}
 
-	if err := s.db.WithContext(ctx).Create(&product).Error; err != nil {
-		return "", err
+func (s *Service) Upsert(ctx context.Context, val model.Product) (ret model.Product, created bool, err error) {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ret, created, err = upsert(tx, val)
+		return err
+	})
+	return ret, created, err
+}
+
+// Save falls back to an insert when the update matches nothing
+func upsert(db *gorm.DB, val model.Product) (model.Product, bool, error) {
+	val, err := model.PrepareUpsert(val)
+	if err != nil {
+		return model.Product{}, false, err
+	}
+	ret, op := val, model.OperationAdd
+	var current model.Product
+	err = db.Where("id = ?", val.ID).Take(&current).Error
+	if err == nil {
+		if val.Unchanged(current) {
+			return current, false, nil
+		}
+		ret, op = val.Replace(current), model.OperationUpdate
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Product{}, false, err
+	}
+	if err = db.Save(&ret).Error; err != nil {
+		return model.Product{}, false, err
 	}
-	return id, nil
+	return ret, op == model.OperationAdd, record(db, model.NewHistoryEntry(db.Statement.Context, op, ret))
 }
 
-func (s *Service) Update(ctx context.Context, val model.Product) error {
-	if val.Name == "" && val.Price == 0 {
-		return model.ErrorNoUpdateParams
+func (s *Service) Update(ctx context.Context, val model.ProductUpdate) (ret model.Product, err error) {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ret, err = update(tx, val)
+		return err
+	})
+	return ret, err
+}
+
+func update(db *gorm.DB, val model.ProductUpdate) (model.Product, error) {
+	if err := val.Validate(); err != nil {
+		return model.Product{}, err
 	}
 
-	res := s.db.WithContext(ctx).Model(&model.Product{}).Where("id = ?", val.ID)
+	res := db.Model(&model.Product{}).Where("id = ? AND deleted_at = 0", val.ID)
+	if val.Version != 0 {
+		res = res.Where("version = ?", val.Version)
+	}
 
-	updates := map[string]interface{}{}
-	if val.Name != "" {
-		updates["name"] = val.Name
+	updates := map[string]interface{}{
+		"version": gorm.Expr("version + 1"),
 	}
-	if val.Price != 0 {
-		updates["price"] = val.Price
+	// The map writes the cleared fields as well, unlike a struct
+	cols, vals := val.Columns()
+	for i, col := range cols {
+		updates[col] = vals[i]
 	}
 
-	tx := res.WithContext(ctx).Updates(updates)
+	tx := res.Updates(updates)
 	if tx.Error != nil {
-		return tx.Error
+		return model.Product{}, tx.Error
 	}
 	if tx.RowsAffected == 0 {
-		return model.ErrorNoRowsUpdated
+		return model.Product{}, versionError(db, val.ID, val.Version, model.ErrorNoRowsUpdated)
 	}
-	return nil
+	return recordChange(db, model.OperationUpdate, val.ID)
 }
 
-func (s *Service) Delete(ctx context.Context, id string) error {
-	tx := s.db.WithContext(ctx).Delete(&model.Product{}, "id = ?", id)
+func (s *Service) Delete(ctx context.Context, id string, version uint64) (ret model.Product, err error) {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ret, err = softDelete(tx, id, version)
+		return err
+	})
+	return ret, err
+}
+
+func softDelete(db *gorm.DB, id string, version uint64) (model.Product, error) {
+	res := db.Model(&model.Product{}).Where("id = ? AND deleted_at = 0", id)
+	if version != 0 {
+		res = res.Where("version = ?", version)
+	}
+	tx := res.Updates(map[string]interface{}{
+		"deleted_at": uint32(time.Now().Unix()),
+		"version":    gorm.Expr("version + 1"),
+	})
 	if tx.Error != nil {
-		return tx.Error
+		return model.Product{}, tx.Error
 	}
 	if tx.RowsAffected == 0 {
-		return model.ErrorNoRowsDeleted
+		return model.Product{}, versionError(db, id, version, model.ErrorNoRowsDeleted)
 	}
-	return nil
+	return recordChange(db, model.OperationDelete, id)
+}
+
+func (s *Service) AddBatch(ctx context.Context, vals []model.Product) ([]model.BatchResult, error) {
+	return batch(ctx, s, vals, func(tx *gorm.DB, val model.Product) (*model.Product, error) {
+		prod, err := add(tx, val)
+		return &prod, err
+	})
+}
+
+func (s *Service) UpdateBatch(ctx context.Context, vals []model.ProductUpdate) ([]model.BatchResult, error) {
+	return batch(ctx, s, vals, func(tx *gorm.DB, val model.ProductUpdate) (*model.Product, error) {
+		prod, err := update(tx, val)
+		return &prod, err
+	})
+}
+
+func (s *Service) DeleteBatch(ctx context.Context, vals []model.Product) ([]model.BatchResult, error) {
+	return batch(ctx, s, vals, func(tx *gorm.DB, val model.Product) (*model.Product, error) {
+		prod, err := softDelete(tx, val.ID, val.Version)
+		return &prod, err
+	})
+}
+
+func batch[T model.BatchItem](ctx context.Context, s *Service, vals []T,
+	write func(tx *gorm.DB, val T) (*model.Product, error)) ([]model.BatchResult, error) {
+	var ret []model.BatchResult
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		ret, err = model.RunBatch(vals, func(val T) (*model.Product, error) {
+			return write(tx, val)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (s *Service) Restore(ctx context.Context, id string) (ret model.Product, err error) {
+	err = s.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		tx := db.Model(&model.Product{}).Where("id = ? AND deleted_at <> 0", id).
+			Updates(map[string]interface{}{
+				"deleted_at": 0,
+				"version":    gorm.Expr("version + 1"),
+			})
+		if tx.Error != nil {
+			return tx.Error
+		}
+		if tx.RowsAffected == 0 {
+			return model.ErrorNoRowsRestored
+		}
+		ret, err = recordChange(db, model.OperationRestore, id)
+		return err
+	})
+	return ret, err
+}
+
+func (s *Service) Purge(ctx context.Context, before uint32) (cnt int64, err error) {
+	err = s.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		purged := db.Model(&model.Product{}).Select("id").Where("deleted_at <> 0 AND deleted_at <= ?", before)
+		if err := db.Where("product_id IN (?)", purged).Delete(&history{}).Error; err != nil {
+			return err
+		}
+		tx := db.Where("deleted_at <> 0 AND deleted_at <= ?", before).Delete(&model.Product{})
+		cnt = tx.RowsAffected
+		return tx.Error
+	})
+	return cnt, err
+}
+
+func record(db *gorm.DB, entry model.HistoryEntry) error {
+	return db.Create(&history{
+		ProductID: entry.ProductID,
+		Version:   entry.Version,
+		Operation: string(entry.Operation),
+		ChangedAt: entry.ChangedAt,
+		Actor:     entry.Actor,
+		Name:      entry.After.Name,
+		Price:     entry.After.Price,
+		Currency:  entry.After.Currency,
+		CreatedAt: entry.After.CreatedAt,
+		DeletedAt: entry.After.DeletedAt,
+	}).Error
+}
+
+func recordChange(db *gorm.DB, op model.Operation, id string) (model.Product, error) {
+	var val model.Product
+	if err := db.First(&val, "id = ?", id).Error; err != nil {
+		return model.Product{}, err
+	}
+	return val, record(db, model.NewHistoryEntry(db.Statement.Context, op, val))
+}
+
+func (s *Service) History(ctx context.Context, id string) ([]model.HistoryEntry, error) {
+	var rows []history
+	if err := s.db.WithContext(ctx).Where("product_id = ?", id).Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	ret := make([]model.HistoryEntry, 0, len(rows))
+	for _, row := range rows {
+		ret = append(ret, row.entry())
+	}
+	return model.LinkHistory(ret), nil
+}
+
+// versionError tells a version conflict from a missing product
+func versionError(db *gorm.DB, id string, version uint64, notFound error) error {
+	if version == 0 {
+		return notFound
+	}
+	var cnt int64
+	if err := db.Model(&model.Product{}).Where("id = ? AND deleted_at = 0", id).Count(&cnt).Error; err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return notFound
+	}
+	return model.ErrorVersionConflict
 }
 
 func (s *Service) Get(ctx context.Context, id string) (model.Product, error) {
 	var ret model.Product
-	if err := s.db.WithContext(ctx).First(&ret, "id = ?", id).Error; err != nil {
+	if err := s.db.WithContext(ctx).First(&ret, "id = ? AND deleted_at = 0", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return model.Product{}, sql.ErrNoRows
+			return model.Product{}, model.ErrorNotFound
 		}
 		return model.Product{}, err
 	}
 	return ret, nil
 }
 
-func (s *Service) GetAll(ctx context.Context) ([]model.Product, error) {
+func (s *Service) GetMany(ctx context.Context, ids []string) ([]model.Product, error) {
+	ret := []model.Product{}
+	if len(ids) == 0 {
+		return ret, nil
+	}
+	if err := s.db.WithContext(ctx).Where("id IN ? AND deleted_at = 0", ids).Find(&ret).Error; err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (s *Service) GetAll(ctx context.Context, query model.ProductQuery, page model.PageRequest) (model.ProductPage, error) {
+	size, after, err := page.Prepare(&query)
+	if err != nil {
+		return model.ProductPage{}, err
+	}
+	// Only sqlite lacks the default LIKE escape
+	dialect := model.SQLDialect{
+		Placeholder: model.QuestionPlaceholder,
+	}
+	switch s.dial.Name() {
+	case "sqlite":
+		dialect.LikeEscape = ` ESCAPE '\'`
+		dialect.PriceColumn = model.SQLiteScaledPrice
+		dialect.PriceArg = func(price decimal.Decimal) any { return model.ScaledPrice(price) }
+	case "mysql":
+		dialect.PriceValue = func(placeholder string) string { return "CAST(" + placeholder + " AS DECIMAL(65, 30))" }
+	}
+	where, order, args := query.SQL(dialect, after)
 	var ret []model.Product
-	if err := s.db.WithContext(ctx).Find(&ret).Error; err != nil {
+	if err = s.db.WithContext(ctx).Where(where, args...).Order(order).Limit(size + 1).Find(&ret).Error; err != nil {
+		return model.ProductPage{}, err
+	}
+	return model.NewProductPage(ret, size, query.Sort), nil
+}
+
+func (s *Service) Search(ctx context.Context, query model.SearchQuery) ([]model.Product, error) {
+	db := s.db.WithContext(ctx)
+	var order clause.Expr
+	switch s.dial.Name() {
+	case "sqlite":
+		db = db.Table("products_fts").Select("products.*").
+			Joins("JOIN products ON products.rowid = products_fts.rowid").
+			Where("products_fts MATCH ? AND products.deleted_at = 0", query.FTS5())
+		order = clause.Expr{SQL: "products_fts.rank, products.id"}
+	case "postgres":
+		db = db.Where("to_tsvector('simple', name) @@ to_tsquery('simple', ?) AND deleted_at = 0", query.TSQuery())
+		order = clause.Expr{SQL: "ts_rank(to_tsvector('simple', name), to_tsquery('simple', ?), 2) DESC, id",
+			Vars: []any{query.TSQuery()}}
+	case "mysql":
+		db = db.Where("MATCH(name) AGAINST(? IN BOOLEAN MODE) AND deleted_at = 0", query.BooleanMode())
+		order = clause.Expr{SQL: "MATCH(name) AGAINST(? IN BOOLEAN MODE) DESC, id",
+			Vars: []any{query.BooleanMode()}}
+	default:
+		return nil, fmt.Errorf("unsupported dialect: %s", s.dial.Name())
+	}
+	ret := []model.Product{}
+	err := db.Order(clause.OrderBy{Expression: order}).Limit(query.Limit).Find(&ret).Error
+	if err != nil {
 		return nil, err
 	}
 	return ret, nil
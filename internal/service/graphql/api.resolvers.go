@@ -2,7 +2,7 @@ package graphql
 
 // This file will be automatically regenerated based on the schema, any resolver implementations
 // will be copied through when generating and any unknown code will be moved to the end.
-// Code generated by github.com/99designs/gqlgen version v0.17.72
+// Code generated by github.com/99designs/gqlgen version v0.17.78
 
 import (
 	"bytes"
@@ -12,46 +12,146 @@ import (
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
+	"github.com/shopspring/decimal"
 )
 
-// AddProduct is the resolver for the addProduct field.
-func (r *mutationResolver) AddProduct(ctx context.Context) (AddProductResponse, error) {
-	id, err := r.db.Add(ctx)
-	if err != nil {
-		return AddProductResponse{}, err
+// Error is the resolver for the error field.
+func (r *batchResultResolver) Error(ctx context.Context, obj *model.BatchResult) (*string, error) {
+	if obj.Error == "" {
+		return nil, nil
 	}
-	return AddProductResponse{ID: id}, nil
+	return &obj.Error, nil
 }
 
-// UpdateProduct is the resolver for the updateProduct field.
-func (r *mutationResolver) UpdateProduct(ctx context.Context, id string, name *string, price *float64) (MessageResponse, error) {
-	if name == nil && price == nil {
-		return MessageResponse{}, model.ErrorNoUpdateParams
+// Operation is the resolver for the operation field.
+func (r *historyEntryResolver) Operation(ctx context.Context, obj *model.HistoryEntry) (string, error) {
+	return string(obj.Operation), nil
+}
+
+// Actor is the resolver for the actor field.
+func (r *historyEntryResolver) Actor(ctx context.Context, obj *model.HistoryEntry) (*string, error) {
+	if obj.Actor == "" {
+		return nil, nil
 	}
-	prod := model.Product{
-		ID: id,
+	return &obj.Actor, nil
+}
+
+// AddProduct is the resolver for the addProduct field.
+func (r *mutationResolver) AddProduct(ctx context.Context, product *NewProduct, sample *bool) (model.Product, error) {
+	prod := model.SampleProduct()
+	if sample == nil || !*sample {
+		if product == nil {
+			return model.Product{}, fmt.Errorf("%w: product is required", model.ErrorInvalidProduct)
+		}
+		prod = newProduct(*product)
 	}
-	if name != nil {
-		prod.Name = *name
+	return r.db.Add(ctx, prod)
+}
+
+// UpdateProduct is the resolver for the updateProduct field.
+func (r *mutationResolver) UpdateProduct(ctx context.Context, id string, name *string, price *decimal.Decimal, currency *string, version *uint64) (MessageResponse, error) {
+	prod := model.ProductUpdate{
+		ID:       id,
+		Name:     optional(argument(ctx, "name", name)),
+		Price:    optional(argument(ctx, "price", price)),
+		Currency: optional(argument(ctx, "currency", currency)),
 	}
-	if price != nil {
-		prod.Price = *price
+	if version != nil {
+		prod.Version = *version
 	}
-	err := r.db.Update(ctx, prod)
+	_, err := r.db.Update(ctx, prod)
 	if err != nil {
 		return MessageResponse{}, err
 	}
 	return MessageResponse{Msg: "Product updated"}, nil
 }
 
+// UpsertProduct is the resolver for the upsertProduct field.
+func (r *mutationResolver) UpsertProduct(ctx context.Context, id string, name string, price decimal.Decimal, currency string) (UpsertResult, error) {
+	val, created, err := r.db.Upsert(ctx, model.Product{
+		ID:       id,
+		Name:     name,
+		Price:    price,
+		Currency: currency,
+	})
+	if err != nil {
+		return UpsertResult{}, err
+	}
+	return UpsertResult{Product: &val, Created: created}, nil
+}
+
 // DeleteProduct is the resolver for the deleteProduct field.
-func (r *mutationResolver) DeleteProduct(ctx context.Context, id string) (MessageResponse, error) {
-	if err := r.db.Delete(ctx, id); err != nil {
+func (r *mutationResolver) DeleteProduct(ctx context.Context, id string, version *uint64) (MessageResponse, error) {
+	var expected uint64
+	if version != nil {
+		expected = *version
+	}
+	if _, err := r.db.Delete(ctx, id, expected); err != nil {
 		return MessageResponse{}, err
 	}
 	return MessageResponse{Msg: "Product deleted"}, nil
 }
 
+// AddProducts is the resolver for the addProducts field.
+func (r *mutationResolver) AddProducts(ctx context.Context, products []NewProduct) ([]model.BatchResult, error) {
+	vals := make([]model.Product, len(products))
+	for i, product := range products {
+		vals[i] = newProduct(product)
+	}
+	return r.db.AddBatch(ctx, vals)
+}
+
+// UpdateProducts is the resolver for the updateProducts field.
+func (r *mutationResolver) UpdateProducts(ctx context.Context, products []ProductUpdate) ([]model.BatchResult, error) {
+	vals := make([]model.ProductUpdate, len(products))
+	for i, product := range products {
+		vals[i] = model.ProductUpdate{
+			ID:       product.ID,
+			Name:     optional(product.Name),
+			Price:    optional(product.Price),
+			Currency: optional(product.Currency),
+		}
+		if product.Version != nil {
+			vals[i].Version = *product.Version
+		}
+	}
+	return r.db.UpdateBatch(ctx, vals)
+}
+
+// DeleteProducts is the resolver for the deleteProducts field.
+func (r *mutationResolver) DeleteProducts(ctx context.Context, products []ProductDelete) ([]model.BatchResult, error) {
+	vals := make([]model.Product, len(products))
+	for i, product := range products {
+		vals[i].ID = product.ID
+		if product.Version != nil {
+			vals[i].Version = *product.Version
+		}
+	}
+	return r.db.DeleteBatch(ctx, vals)
+}
+
+// RestoreProduct is the resolver for the restoreProduct field.
+func (r *mutationResolver) RestoreProduct(ctx context.Context, id string) (MessageResponse, error) {
+	if _, err := r.db.Restore(ctx, id); err != nil {
+		return MessageResponse{}, err
+	}
+	return MessageResponse{Msg: "Product restored"}, nil
+}
+
+// PurgeProducts is the resolver for the purgeProducts field.
+func (r *mutationResolver) PurgeProducts(ctx context.Context, days uint32) (model.PurgeResult, error) {
+	cnt, err := r.db.Purge(ctx, model.PurgeBefore(days))
+	if err != nil {
+		return model.PurgeResult{}, err
+	}
+	return model.PurgeResult{Purged: cnt}, nil
+}
+
+// Type is the resolver for the type field.
+func (r *productEventResolver) Type(ctx context.Context, obj *model.ProductEvent) (ProductEventType, error) {
+	return eventTypes[obj.Type], nil
+}
+
 // Main is the resolver for the main field.
 func (r *queryResolver) Main(ctx context.Context) (string, error) {
 	oc := graphql.GetOperationContext(ctx)
@@ -75,21 +175,109 @@ func (r *queryResolver) Main(ctx context.Context) (string, error) {
 
 // GetProduct is the resolver for the getProduct field.
 func (r *queryResolver) GetProduct(ctx context.Context, filter ProductFilter) (model.Product, error) {
-	val, err := r.db.Get(ctx, filter.ID)
+	if filter.AsOf == nil {
+		return loadProduct(ctx, r.db, filter.ID)
+	}
+	val, err := model.GetAsOf(ctx, r.db, filter.ID, filter.AsOf)
 	if err != nil {
 		return model.Product{}, err
 	}
 	return val, nil
 }
 
-// GetProducts is the resolver for the getProducts field.
-func (r *queryResolver) GetProducts(ctx context.Context) ([]model.Product, error) {
-	val, err := r.db.GetAll(ctx)
+// Products is the resolver for the products field.
+func (r *queryResolver) Products(ctx context.Context, ids []string) ([]*model.Product, error) {
+	if err := model.ValidateIDs(ids); err != nil {
+		return nil, err
+	}
+	val, err := r.db.GetMany(ctx, ids)
 	if err != nil {
 		return nil, err
 	}
+	found := make(map[string]*model.Product, len(val))
+	for i := range val {
+		found[val[i].ID] = &val[i]
+	}
+	ret := make([]*model.Product, len(ids))
+	for i, id := range ids {
+		ret[i] = found[id]
+	}
+	return ret, nil
+}
 
-	return val, nil
+// GetProductHistory is the resolver for the getProductHistory field.
+func (r *queryResolver) GetProductHistory(ctx context.Context, id string) ([]model.HistoryEntry, error) {
+	return r.db.History(ctx, id)
+}
+
+// GetProducts is the resolver for the getProducts field.
+func (r *queryResolver) GetProducts(ctx context.Context, first *int, after *string, filter *ProductListFilter, orderBy *ProductOrder) (ProductConnection, error) {
+	query := model.ProductQuery{
+		Sort: model.ProductSort{
+			Field: model.SortByID,
+		},
+	}
+	if filter != nil {
+		query.Filter = model.ProductFilter{
+			MinPrice:    filter.MinPrice,
+			MaxPrice:    filter.MaxPrice,
+			CreatedFrom: filter.CreatedFrom,
+			CreatedTo:   filter.CreatedTo,
+		}
+		if filter.NameContains != nil {
+			query.Filter.NameContains = *filter.NameContains
+		}
+		if filter.IncludeDeleted != nil {
+			query.Filter.IncludeDeleted = *filter.IncludeDeleted
+		}
+	}
+	if orderBy != nil {
+		query.Sort = model.ProductSort{
+			Field: sortFields[orderBy.Field],
+			Desc:  orderBy.Direction == SortDirectionDesc,
+		}
+	}
+	var page model.PageRequest
+	if first != nil {
+		page.Size = *first
+	}
+	if after != nil {
+		page.Cursor = *after
+	}
+	val, err := r.db.GetAll(ctx, query, page)
+	if err != nil {
+		return ProductConnection{}, err
+	}
+
+	ret := ProductConnection{
+		Edges: make([]ProductEdge, 0, len(val.Items)),
+		PageInfo: &PageInfo{
+			HasNextPage: val.HasMore,
+		},
+	}
+	for _, item := range val.Items {
+		ret.Edges = append(ret.Edges, ProductEdge{
+			Cursor: model.EncodeCursor(item, query.Sort),
+			Node:   &item,
+		})
+	}
+	if len(ret.Edges) > 0 {
+		ret.PageInfo.EndCursor = &ret.Edges[len(ret.Edges)-1].Cursor
+	}
+	return ret, nil
+}
+
+// SearchProducts is the resolver for the searchProducts field.
+func (r *queryResolver) SearchProducts(ctx context.Context, query string, limit *int) ([]model.Product, error) {
+	var size int
+	if limit != nil {
+		size = *limit
+	}
+	q, err := model.NewSearchQuery(query, size)
+	if err != nil {
+		return nil, err
+	}
+	return r.db.Search(ctx, q)
 }
 
 // Time is the resolver for the time field.
@@ -111,15 +299,37 @@ func (r *subscriptionResolver) Time(ctx context.Context) (<-chan uint32, error)
 	return ret, nil
 }
 
+// ProductChanged is the resolver for the productChanged field.
+func (r *subscriptionResolver) ProductChanged(ctx context.Context, id *string) (<-chan model.ProductEvent, error) {
+	return r.watch(ctx, id)
+}
+
+// ProductsChanged is the resolver for the productsChanged field.
+func (r *subscriptionResolver) ProductsChanged(ctx context.Context) (<-chan model.ProductEvent, error) {
+	return r.watch(ctx, nil)
+}
+
+// BatchResult returns BatchResultResolver implementation.
+func (r *Resolver) BatchResult() BatchResultResolver { return &batchResultResolver{r} }
+
+// HistoryEntry returns HistoryEntryResolver implementation.
+func (r *Resolver) HistoryEntry() HistoryEntryResolver { return &historyEntryResolver{r} }
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
+// ProductEvent returns ProductEventResolver implementation.
+func (r *Resolver) ProductEvent() ProductEventResolver { return &productEventResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
 // Subscription returns SubscriptionResolver implementation.
 func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
 
+type batchResultResolver struct{ *Resolver }
+type historyEntryResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
+type productEventResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
 type subscriptionResolver struct{ *Resolver }
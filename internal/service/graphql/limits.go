@@ -0,0 +1,140 @@
+package graphql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/aleksandrzhukovskii/go-template/internal/model"
+)
+
+const (
+	errDepthLimit = "DEPTH_LIMIT_EXCEEDED"
+	errAliasLimit = "ALIAS_LIMIT_EXCEEDED"
+)
+
+// limits rejects the operations over the depth or the aliases before they're executed
+type limits struct {
+	depth   int
+	aliases int
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = limits{}
+
+func (l limits) ExtensionName() string {
+	return "Limits"
+}
+
+func (l limits) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (l limits) MutateOperationContext(_ context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if l.depth > 0 {
+		if selectionDepth(opCtx.Operation.SelectionSet, l.depth) > l.depth {
+			err := gqlerror.Errorf("operation exceeds the depth limit of %d", l.depth)
+			errcode.Set(err, errDepthLimit)
+			return err
+		}
+	}
+	if l.aliases > 0 {
+		if countAliases(opCtx.Operation.SelectionSet, l.aliases) > l.aliases {
+			err := gqlerror.Errorf("operation exceeds the limit of %d aliases", l.aliases)
+			errcode.Set(err, errAliasLimit)
+			return err
+		}
+	}
+	return nil
+}
+
+// selectionDepth stops past the limit and skips the introspection fields
+func selectionDepth(set ast.SelectionSet, limit int) int {
+	ret := 0
+	for _, selection := range set {
+		var depth int
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			depth = 1
+			if limit > 1 {
+				depth += selectionDepth(s.SelectionSet, limit-1)
+			} else if len(s.SelectionSet) > 0 {
+				depth++
+			}
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				depth = selectionDepth(s.Definition.SelectionSet, limit)
+			}
+		case *ast.InlineFragment:
+			depth = selectionDepth(s.SelectionSet, limit)
+		}
+		ret = max(ret, depth)
+		if ret > limit {
+			return ret
+		}
+	}
+	return ret
+}
+
+func countAliases(set ast.SelectionSet, limit int) int {
+	ret := 0
+	for _, selection := range set {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if s.Alias != s.Name {
+				ret++
+			}
+			ret += countAliases(s.SelectionSet, limit-ret)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				ret += countAliases(s.Definition.SelectionSet, limit-ret)
+			}
+		case *ast.InlineFragment:
+			ret += countAliases(s.SelectionSet, limit-ret)
+		}
+		if ret > limit {
+			return ret
+		}
+	}
+	return ret
+}
+
+func listComplexity(childComplexity int, size *int, def, limit int) int {
+	items := def
+	if size != nil && *size > 0 {
+		items = min(*size, limit)
+	}
+	return items * max(childComplexity, 1)
+}
+
+// setComplexity prices the lists by the number of the items they may return
+func setComplexity(c *ComplexityRoot) {
+	c.Query.Products = func(childComplexity int, ids []string) int {
+		return len(ids) * max(childComplexity, 1)
+	}
+	c.Query.GetProducts = func(childComplexity int, first *int, _ *string, _ *ProductListFilter,
+		_ *ProductOrder) int {
+		return listComplexity(childComplexity, first, model.DefaultPageSize, model.MaxPageSize)
+	}
+	c.Query.SearchProducts = func(childComplexity int, _ string, limit *int) int {
+		return listComplexity(childComplexity, limit, model.DefaultSearchLimit, model.MaxSearchLimit)
+	}
+	c.Mutation.AddProducts = func(childComplexity int, products []NewProduct) int {
+		return len(products) * max(childComplexity, 1)
+	}
+	c.Mutation.UpdateProducts = func(childComplexity int, products []ProductUpdate) int {
+		return len(products) * max(childComplexity, 1)
+	}
+	c.Mutation.DeleteProducts = func(childComplexity int, products []ProductDelete) int {
+		return len(products) * max(childComplexity, 1)
+	}
+}
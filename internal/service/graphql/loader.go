@@ -0,0 +1,106 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aleksandrzhukovskii/go-template/internal/model"
+)
+
+// loaderWait is how long the loader collects the ids before reading them
+const loaderWait = 2 * time.Millisecond
+
+var loaderKey = requestKey("loader")
+
+// productLoader batches the reads of the products by id into a single GetMany, nothing is cached
+type productLoader struct {
+	db    model.DB
+	mu    sync.Mutex
+	batch *productBatch
+}
+
+type productBatch struct {
+	ids      []string
+	once     sync.Once
+	done     chan struct{}
+	products map[string]model.Product
+	err      error
+}
+
+func newProductLoader(db model.DB) *productLoader {
+	return &productLoader{db: db}
+}
+
+func withLoader(ctx context.Context, loader *productLoader) context.Context {
+	return context.WithValue(ctx, loaderKey, loader)
+}
+
+// loadProduct reads the product directly when the context has no loader
+func loadProduct(ctx context.Context, db model.DB, id string) (model.Product, error) {
+	loader, ok := ctx.Value(loaderKey).(*productLoader)
+	if !ok {
+		return db.Get(ctx, id)
+	}
+	return loader.Load(ctx, id)
+}
+
+func (l *productLoader) Load(ctx context.Context, id string) (model.Product, error) {
+	l.mu.Lock()
+	b := l.batch
+	if b == nil {
+		b = &productBatch{done: make(chan struct{})}
+		l.batch = b
+		time.AfterFunc(loaderWait, func() {
+			l.dispatch(ctx, b)
+		})
+	}
+	b.ids = append(b.ids, id)
+	if len(b.ids) == model.MaxBatchSize {
+		l.batch = nil
+		go l.dispatch(ctx, b)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return model.Product{}, ctx.Err()
+	}
+	if b.err != nil {
+		return model.Product{}, b.err
+	}
+	ret, ok := b.products[id]
+	if !ok {
+		return model.Product{}, model.ErrorNotFound
+	}
+	return ret, nil
+}
+
+// dispatch reads the batch once, when the wait is over or when it's full
+func (l *productLoader) dispatch(ctx context.Context, b *productBatch) {
+	b.once.Do(func() {
+		l.mu.Lock()
+		if l.batch == b {
+			l.batch = nil
+		}
+		l.mu.Unlock()
+		defer close(b.done)
+		// The batch outlives the field that has started it, but not the request deadline
+		batchCtx := context.WithoutCancel(ctx)
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			batchCtx, cancel = context.WithDeadline(batchCtx, deadline)
+			defer cancel()
+		}
+		products, err := l.db.GetMany(batchCtx, b.ids)
+		if err != nil {
+			b.err = err
+			return
+		}
+		b.products = make(map[string]model.Product, len(products))
+		for _, val := range products {
+			b.products[val.ID] = val
+		}
+	})
+}
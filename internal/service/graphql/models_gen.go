@@ -2,9 +2,16 @@
 
 package graphql
 
-type AddProductResponse struct {
-	ID string `json:"id"`
-}
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/aleksandrzhukovskii/go-template/internal/model"
+	"github.com/shopspring/decimal"
+)
 
 type MessageResponse struct {
 	Msg string `json:"msg"`
@@ -13,8 +20,62 @@ type MessageResponse struct {
 type Mutation struct {
 }
 
+type NewProduct struct {
+	ID    *string         `json:"id,omitempty"`
+	Name  string          `json:"name"`
+	Price decimal.Decimal `json:"price"`
+	// ISO 4217 code of the price
+	Currency string `json:"currency"`
+}
+
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor,omitempty"`
+}
+
+type ProductConnection struct {
+	Edges    []ProductEdge `json:"edges"`
+	PageInfo *PageInfo     `json:"pageInfo"`
+}
+
+type ProductDelete struct {
+	ID      string  `json:"id"`
+	Version *uint64 `json:"version,omitempty"`
+}
+
+type ProductEdge struct {
+	Cursor string         `json:"cursor"`
+	Node   *model.Product `json:"node"`
+}
+
 type ProductFilter struct {
 	ID string `json:"id"`
+	// Read the product as it was at this timestamp, taken from its history
+	AsOf *uint32 `json:"asOf,omitempty"`
+}
+
+type ProductListFilter struct {
+	NameContains   *string          `json:"nameContains,omitempty"`
+	MinPrice       *decimal.Decimal `json:"minPrice,omitempty"`
+	MaxPrice       *decimal.Decimal `json:"maxPrice,omitempty"`
+	CreatedFrom    *uint32          `json:"createdFrom,omitempty"`
+	CreatedTo      *uint32          `json:"createdTo,omitempty"`
+	IncludeDeleted *bool            `json:"includeDeleted,omitempty"`
+}
+
+type ProductOrder struct {
+	Field     ProductSortField `json:"field"`
+	Direction SortDirection    `json:"direction"`
+}
+
+// Only the passed fields are updated, null clears the name and sets the price to zero
+type ProductUpdate struct {
+	ID    string                              `json:"id"`
+	Name  graphql.Omittable[*string]          `json:"name,omitempty"`
+	Price graphql.Omittable[*decimal.Decimal] `json:"price,omitempty"`
+	// Can't be cleared
+	Currency graphql.Omittable[*string] `json:"currency,omitempty"`
+	Version  *uint64                    `json:"version,omitempty"`
 }
 
 type Query struct {
@@ -22,3 +83,181 @@ type Query struct {
 
 type Subscription struct {
 }
+
+type UpsertResult struct {
+	Product *model.Product `json:"product"`
+	// False when the product has been replaced or left as it is
+	Created bool `json:"created"`
+}
+
+// Restored products are updated
+type ProductEventType string
+
+const (
+	ProductEventTypeCreated ProductEventType = "CREATED"
+	ProductEventTypeUpdated ProductEventType = "UPDATED"
+	ProductEventTypeDeleted ProductEventType = "DELETED"
+)
+
+var AllProductEventType = []ProductEventType{
+	ProductEventTypeCreated,
+	ProductEventTypeUpdated,
+	ProductEventTypeDeleted,
+}
+
+func (e ProductEventType) IsValid() bool {
+	switch e {
+	case ProductEventTypeCreated, ProductEventTypeUpdated, ProductEventTypeDeleted:
+		return true
+	}
+	return false
+}
+
+func (e ProductEventType) String() string {
+	return string(e)
+}
+
+func (e *ProductEventType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ProductEventType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ProductEventType", str)
+	}
+	return nil
+}
+
+func (e ProductEventType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ProductEventType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ProductEventType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ProductSortField string
+
+const (
+	ProductSortFieldID        ProductSortField = "ID"
+	ProductSortFieldName      ProductSortField = "NAME"
+	ProductSortFieldPrice     ProductSortField = "PRICE"
+	ProductSortFieldCreatedAt ProductSortField = "CREATED_AT"
+)
+
+var AllProductSortField = []ProductSortField{
+	ProductSortFieldID,
+	ProductSortFieldName,
+	ProductSortFieldPrice,
+	ProductSortFieldCreatedAt,
+}
+
+func (e ProductSortField) IsValid() bool {
+	switch e {
+	case ProductSortFieldID, ProductSortFieldName, ProductSortFieldPrice, ProductSortFieldCreatedAt:
+		return true
+	}
+	return false
+}
+
+func (e ProductSortField) String() string {
+	return string(e)
+}
+
+func (e *ProductSortField) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ProductSortField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ProductSortField", str)
+	}
+	return nil
+}
+
+func (e ProductSortField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ProductSortField) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ProductSortField) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type SortDirection string
+
+const (
+	SortDirectionAsc  SortDirection = "ASC"
+	SortDirectionDesc SortDirection = "DESC"
+)
+
+var AllSortDirection = []SortDirection{
+	SortDirectionAsc,
+	SortDirectionDesc,
+}
+
+func (e SortDirection) IsValid() bool {
+	switch e {
+	case SortDirectionAsc, SortDirectionDesc:
+		return true
+	}
+	return false
+}
+
+func (e SortDirection) String() string {
+	return string(e)
+}
+
+func (e *SortDirection) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SortDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SortDirection", str)
+	}
+	return nil
+}
+
+func (e SortDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *SortDirection) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e SortDirection) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
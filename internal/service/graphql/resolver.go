@@ -2,9 +2,12 @@ package graphql
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql"
@@ -14,9 +17,11 @@ import (
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/gorilla/websocket"
-	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
 	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 
+	"github.com/aleksandrzhukovskii/go-template/internal/config"
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
 )
 
@@ -32,25 +37,48 @@ var (
 	methodKey = requestKey("method")
 )
 
+var sortFields = map[ProductSortField]model.SortField{
+	ProductSortFieldID:        model.SortByID,
+	ProductSortFieldName:      model.SortByName,
+	ProductSortFieldPrice:     model.SortByPrice,
+	ProductSortFieldCreatedAt: model.SortByCreatedAt,
+}
+
+var eventTypes = map[model.EventType]ProductEventType{
+	model.EventCreated: ProductEventTypeCreated,
+	model.EventUpdated: ProductEventTypeUpdated,
+	model.EventDeleted: ProductEventTypeDeleted,
+}
+
 type Resolver struct {
 	server *http.Server
 	db     model.DB
 	lis    net.Listener
+	// ctx is cancelled on the shutdown to end the subscriptions
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-func New(db model.DB, lis net.Listener) (model.Server, error) {
+// Paths are the routes the multiplexer sends to GraphQL
+var Paths = []string{"/query", "/subscription", "/query_playground", "/subscription_playground"}
+
+func New(cfg config.Config, db model.DB, lis net.Listener) (model.Server, error) {
 	ret := &Resolver{
 		db:  db,
 		lis: lis,
 	}
+	ret.ctx, ret.cancel = context.WithCancel(context.Background())
 	c := Config{Resolvers: ret}
+	setComplexity(&c.Complexity)
 	es := NewExecutableSchema(c)
 	mux := http.NewServeMux()
-	mux.Handle("/query_playground", playground.Handler("Query playground", "/query"))
-	mux.Handle("/subscription_playground", playground.Handler("Subscription playground", "/subscription"))
-	graph := NewServer(es)
-	mux.Handle("/query", middleware(graph))
-	mux.Handle("/subscription", middleware(graph))
+	if cfg.GraphQL.Playground {
+		mux.Handle("/query_playground", playground.Handler("Query playground", "/query"))
+		mux.Handle("/subscription_playground", playground.Handler("Subscription playground", "/subscription"))
+	}
+	graph := NewServer(es, cfg.GraphQL)
+	mux.Handle("/query", middleware(db, graph))
+	mux.Handle("/subscription", middleware(db, graph))
 
 	ret.server = &http.Server{
 		Handler: mux,
@@ -59,7 +87,7 @@ func New(db model.DB, lis net.Listener) (model.Server, error) {
 	return ret, nil
 }
 
-func middleware(next http.Handler) http.Handler {
+func middleware(db model.DB, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := context.WithValue(
 			context.WithValue(
@@ -67,25 +95,146 @@ func middleware(next http.Handler) http.Handler {
 				urlKey, r.URL.String(),
 			), methodKey, r.Method,
 		)
+		ctx = model.WithActor(ctx, r.Header.Get(model.ActorHeader))
+		ctx = withLoader(ctx, newProductLoader(db))
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+func (r *Resolver) Handler() http.Handler {
+	return r.server.Handler
+}
+
+func (r *Resolver) Stop() {
+	r.cancel()
+}
+
 func (r *Resolver) Start(ctx context.Context) error {
-	log.Info().Msg("starting graphql server")
+	return model.Serve(ctx, "graphql", func() error {
+		return r.server.Serve(r.lis)
+	}, func() error {
+		r.Stop()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		return r.server.Shutdown(ctx)
+	})
+}
+
+// watch subscribes to the changes of the product, of every product without the id
+func (r *Resolver) watch(ctx context.Context, id *string) (<-chan model.ProductEvent, error) {
+	source, ok := r.db.(model.EventSource)
+	if !ok {
+		return nil, model.ErrorEventsUnsupported
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(r.ctx, cancel)
+	sub, err := source.Subscribe(ctx, nil)
+	if err != nil {
+		stop()
+		cancel()
+		return nil, err
+	}
+	ret := make(chan model.ProductEvent)
 	go func() {
-		if err := r.server.Serve(r.lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatal().Err(err).Msg("failed to start graphql server")
+		defer close(ret)
+		defer stop()
+		defer cancel()
+		for event := range sub.Events() {
+			if id != nil && event.Product.ID != *id {
+				continue
+			}
+			select {
+			case ret <- event:
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
-	<-ctx.Done()
-	log.Info().Msg("gracefully shutting down graphql server")
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
-	return r.server.Shutdown(ctx)
+	return ret, nil
+}
+
+func newProduct(product NewProduct) model.Product {
+	ret := model.Product{
+		Name:     product.Name,
+		Price:    product.Price,
+		Currency: product.Currency,
+	}
+	if product.ID != nil {
+		ret.ID = *product.ID
+	}
+	return ret
+}
+
+// argument tells the missing arguments from the null ones
+func argument[T any](ctx context.Context, name string, val *T) graphql.Omittable[*T] {
+	arg := graphql.GetFieldContext(ctx).Field.Arguments.ForName(name)
+	if arg == nil {
+		return graphql.Omittable[*T]{}
+	}
+	if arg.Value.Kind == ast.Variable {
+		if _, ok := graphql.GetOperationContext(ctx).Variables[arg.Value.Raw]; !ok {
+			return graphql.Omittable[*T]{}
+		}
+	}
+	return graphql.OmittableOf(val)
+}
+
+func optional[T any](val graphql.Omittable[*T]) model.Optional[T] {
+	ptr, ok := val.ValueOK()
+	if !ok {
+		return model.Optional[T]{}
+	}
+	if ptr == nil {
+		return model.Null[T]()
+	}
+	return model.Value(*ptr)
+}
+
+func MarshalDecimal(val decimal.Decimal) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		_, _ = io.WriteString(w, strconv.Quote(val.String()))
+	})
+}
+
+// UnmarshalDecimal rejects the float literals, they may have been rounded already
+func UnmarshalDecimal(v any) (decimal.Decimal, error) {
+	switch v := v.(type) {
+	case string:
+		return parseDecimal(v)
+	case json.Number:
+		return parseDecimal(v.String())
+	case int:
+		return decimal.NewFromInt(int64(v)), nil
+	case int64:
+		return decimal.NewFromInt(v), nil
+	default:
+		return decimal.Decimal{}, model.Errorf(model.InvalidArgument, "%T is not a decimal, pass the number as a string", v)
+	}
+}
+
+func parseDecimal(str string) (decimal.Decimal, error) {
+	ret, err := decimal.NewFromString(str)
+	if err != nil {
+		return decimal.Decimal{}, model.Errorf(model.InvalidArgument, "%w", err)
+	}
+	return ret, nil
+}
+
+// presentError adds the code of the kind, the errors of gqlgen keep their own codes
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	ret := graphql.DefaultErrorPresenter(ctx, err)
+	var typed *model.Error
+	if errors.As(err, &typed) || errors.Is(err, context.DeadlineExceeded) {
+		if ret.Extensions == nil {
+			ret.Extensions = map[string]any{}
+		}
+		ret.Extensions["code"] = model.GraphQLCode(model.Typed(err))
+	}
+	return ret
 }
 
-func NewServer(es graphql.ExecutableSchema) *handler.Server {
+// NewServer serves the schema with the limits and the timeout of the config
+func NewServer(es graphql.ExecutableSchema, cfg config.GraphQL) *handler.Server {
 	srv := handler.New(es)
 
 	srv.AddTransport(&transport.Websocket{
@@ -99,12 +248,34 @@ func NewServer(es graphql.ExecutableSchema) *handler.Server {
 
 	srv.AddTransport(transport.Options{})
 	srv.AddTransport(transport.GET{})
+	// The graphql-sse requests are POST requests too, so the transport goes first
+	srv.AddTransport(transport.SSE{
+		KeepAlivePingInterval: 15 * time.Second,
+	})
 	srv.AddTransport(transport.POST{})
 	srv.AddTransport(transport.MultipartForm{})
 
 	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	srv.SetErrorPresenter(presentError)
 
-	srv.Use(extension.Introspection{})
+	if cfg.Introspection {
+		srv.Use(extension.Introspection{})
+	}
+	if cfg.Complexity > 0 {
+		srv.Use(extension.FixedComplexityLimit(cfg.Complexity))
+	}
+	srv.Use(limits{depth: cfg.Depth, aliases: cfg.Aliases})
+	if cfg.Timeout > 0 {
+		// The fields are resolved with the context of the response
+		srv.AroundResponses(func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+			if graphql.GetOperationContext(ctx).Operation.Operation == ast.Subscription {
+				return next(ctx)
+			}
+			ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+			defer cancel()
+			return next(ctx)
+		})
+	}
 	srv.Use(extension.AutomaticPersistedQuery{
 		Cache: lru.New[string](100),
 	})
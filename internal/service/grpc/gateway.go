@@ -0,0 +1,95 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/aleksandrzhukovskii/go-template/api"
+	"github.com/aleksandrzhukovskii/go-template/internal/config"
+	"github.com/aleksandrzhukovskii/go-template/internal/model"
+	"github.com/aleksandrzhukovskii/go-template/web/swagger"
+)
+
+// Gateway transcodes the google.api.http routes to the in-process gRPC server
+type Gateway struct {
+	grpc   *Service
+	inner  *bufconn.Listener
+	conn   *grpc.ClientConn
+	server *http.Server
+	lis    net.Listener
+}
+
+func NewGateway(cfg config.Config, db model.DB, lis net.Listener) (model.Server, error) {
+	inner := bufconn.Listen(1024 * 1024)
+	srv, err := New(cfg, db, inner)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient("passthrough:///gateway", grpc.WithContextDialer(
+		func(ctx context.Context, _ string) (net.Conn, error) {
+			return inner.DialContext(ctx)
+		}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	gateway := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(incomingHeader))
+	if err = RegisterProductServiceHandler(context.Background(), gateway, conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", gateway)
+	// The JSON document is YAML as well, so the Swagger UI reads it from the usual path
+	mux.HandleFunc("GET /swagger.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(api.GatewaySwaggerConfig)
+	})
+	mux.Handle("GET /swagger/", http.StripPrefix("/swagger/", http.FileServer(http.FS(swagger.Swagger))))
+	return &Gateway{
+		grpc:   srv.(*Service),
+		inner:  inner,
+		conn:   conn,
+		server: &http.Server{Handler: mux},
+		lis:    lis,
+	}, nil
+}
+
+// incomingHeader passes the caller identity to the metadata
+func incomingHeader(key string) (string, bool) {
+	if strings.EqualFold(key, model.ActorHeader) {
+		return strings.ToLower(model.ActorHeader), true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+func (g *Gateway) Start(ctx context.Context) error {
+	return model.Serve(ctx, "grpc gateway", func() error {
+		errs := make(chan error, 2)
+		go func() {
+			errs <- g.grpc.server.Serve(g.inner)
+		}()
+		go func() {
+			errs <- g.server.Serve(g.lis)
+		}()
+		// The failure of either server stops the other one
+		err := <-errs
+		return errors.Join(err, g.shutdown())
+	}, g.shutdown)
+}
+
+func (g *Gateway) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+	return errors.Join(g.server.Shutdown(ctx), g.grpc.stop(), g.conn.Close())
+}
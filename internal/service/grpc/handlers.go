@@ -3,14 +3,15 @@ package grpc
 import (
 	"bytes"
 	"context"
-	"errors"
 	"fmt"
-	"strconv"
 
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
 )
@@ -39,84 +40,370 @@ func (s Service) GetMain(ctx context.Context, _ *Empty) (*MainInfo, error) {
 	}, nil
 }
 
-func (s Service) AddProduct(ctx context.Context, _ *Empty) (*AddResponse, error) {
-	id, err := s.db.Add(ctx)
+func (s Service) AddProduct(ctx context.Context, req *AddRequest) (*Product, error) {
+	prod, err := newProduct(req)
 	if err != nil {
-		return nil, status.Error(codes.Aborted, err.Error())
+		return nil, statusError(err)
 	}
-	return &AddResponse{
-		Id: id,
-	}, nil
+	val, err := s.db.Add(ctx, prod)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return mapProduct(val), nil
 }
 
 func (s Service) UpdateProduct(ctx context.Context, req *UpdateRequest) (*UpdateResponse, error) {
-	name := ""
-	if req.Name != nil {
-		name = *req.Name
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
 	}
-	price := float64(0)
-	if req.Price != nil {
-		price = *req.Price
-	}
-	prod, err := model.ParseProduct(req.Id, name, strconv.FormatFloat(price, 'f', -1, 64))
+	prod, err := newUpdate(req)
 	if err != nil {
-		return nil, status.Error(codes.Aborted, err.Error())
+		return nil, statusError(err)
 	}
-	err = s.db.Update(ctx, prod)
+	_, err = s.db.Update(ctx, prod)
 	if err != nil {
-		code := codes.Aborted
-		if errors.Is(err, model.ErrorNoRowsUpdated) {
-			code = codes.InvalidArgument
-		}
-		return nil, status.Error(code, err.Error())
+		return nil, statusError(err)
 	}
 	return &UpdateResponse{
 		Msg: "Product updated",
 	}, nil
 }
 
+func (s Service) UpsertProduct(ctx context.Context, req *UpsertRequest) (*UpsertResponse, error) {
+	price, currency, err := newPrice(req.GetPrice())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	val, created, err := s.db.Upsert(ctx, model.Product{
+		ID:       req.GetId(),
+		Name:     req.GetName(),
+		Price:    price,
+		Currency: currency,
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &UpsertResponse{
+		Product: mapProduct(val),
+		Created: created,
+	}, nil
+}
+
 func (s Service) DeleteProduct(ctx context.Context, req *DeleteRequest) (*DeleteResponse, error) {
-	if err := s.db.Delete(ctx, req.Id); err != nil {
-		code := codes.Aborted
-		if errors.Is(err, model.ErrorNoRowsDeleted) {
-			code = codes.InvalidArgument
-		}
-		return nil, status.Error(code, err.Error())
+	if _, err := s.db.Delete(ctx, req.Id, req.GetVersion()); err != nil {
+		return nil, statusError(err)
 	}
 	return &DeleteResponse{
 		Msg: "Product deleted",
 	}, nil
 }
 
-func (s Service) GetProduct(ctx context.Context, req *GetProductRequest) (*Product, error) {
-	val, err := s.db.Get(ctx, req.Id)
+func (s Service) AddProducts(ctx context.Context, req *AddProductsRequest) (*BatchResponse, error) {
+	vals := make([]model.Product, len(req.GetItems()))
+	for i, item := range req.GetItems() {
+		var err error
+		if vals[i], err = newProduct(item); err != nil {
+			return nil, statusError(err)
+		}
+	}
+	return batchResponse(s.db.AddBatch(ctx, vals))
+}
+
+func (s Service) UpdateProducts(ctx context.Context, req *UpdateProductsRequest) (*BatchResponse, error) {
+	vals := make([]model.ProductUpdate, len(req.GetItems()))
+	for i, item := range req.GetItems() {
+		var err error
+		if vals[i], err = newUpdate(item); err != nil {
+			return nil, statusError(err)
+		}
+	}
+	return batchResponse(s.db.UpdateBatch(ctx, vals))
+}
+
+func (s Service) DeleteProducts(ctx context.Context, req *DeleteProductsRequest) (*BatchResponse, error) {
+	vals := make([]model.Product, len(req.GetItems()))
+	for i, item := range req.GetItems() {
+		vals[i] = model.Product{
+			ID:      item.GetId(),
+			Version: item.GetVersion(),
+		}
+	}
+	return batchResponse(s.db.DeleteBatch(ctx, vals))
+}
+
+func batchResponse(results []model.BatchResult, err error) (*BatchResponse, error) {
 	if err != nil {
-		code := codes.Aborted
-		if errors.Is(err, model.ErrorNoRowsDeleted) {
-			code = codes.InvalidArgument
+		return nil, statusError(err)
+	}
+	ret := &BatchResponse{
+		Results: make([]*BatchResult, len(results)),
+	}
+	for i, res := range results {
+		ret.Results[i] = &BatchResult{
+			Id:    res.ID,
+			Error: res.Error,
+		}
+		if res.Product != nil {
+			ret.Results[i].Product = mapProduct(*res.Product)
 		}
-		return nil, status.Error(code, err.Error())
+	}
+	return ret, nil
+}
+
+func (s Service) RestoreProduct(ctx context.Context, req *RestoreRequest) (*RestoreResponse, error) {
+	if _, err := s.db.Restore(ctx, req.Id); err != nil {
+		return nil, statusError(err)
+	}
+	return &RestoreResponse{
+		Msg: "Product restored",
+	}, nil
+}
+
+func (s Service) PurgeProducts(ctx context.Context, req *PurgeRequest) (*PurgeResponse, error) {
+	cnt, err := s.db.Purge(ctx, model.PurgeBefore(req.GetDays()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &PurgeResponse{
+		Purged: cnt,
+	}, nil
+}
+
+func (s Service) GetProduct(ctx context.Context, req *GetProductRequest) (*Product, error) {
+	val, err := model.GetAsOf(ctx, s.db, req.GetId(), req.AsOf)
+	if err != nil {
+		return nil, statusError(err)
 	}
 
 	return mapProduct(val), nil
 }
-func (s Service) GetProducts(ctx context.Context, _ *Empty) (*Products, error) {
-	val, err := s.db.GetAll(ctx)
+
+func (s Service) GetProductHistory(ctx context.Context, req *GetProductHistoryRequest) (*ProductHistory, error) {
+	entries, err := s.db.History(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	ret := &ProductHistory{
+		Entries: make([]*HistoryEntry, len(entries)),
+	}
+	for i, entry := range entries {
+		ret.Entries[i] = &HistoryEntry{
+			ProductId: entry.ProductID,
+			Version:   entry.Version,
+			Operation: string(entry.Operation),
+			ChangedAt: entry.ChangedAt,
+			Actor:     entry.Actor,
+			After:     mapProduct(entry.After),
+		}
+		if entry.Before != nil {
+			ret.Entries[i].Before = mapProduct(*entry.Before)
+		}
+	}
+	return ret, nil
+}
+
+var sortFields = map[ProductSortField]model.SortField{
+	ProductSortField_PRODUCT_SORT_FIELD_ID:         model.SortByID,
+	ProductSortField_PRODUCT_SORT_FIELD_NAME:       model.SortByName,
+	ProductSortField_PRODUCT_SORT_FIELD_PRICE:      model.SortByPrice,
+	ProductSortField_PRODUCT_SORT_FIELD_CREATED_AT: model.SortByCreatedAt,
+}
+
+func (s Service) GetProducts(ctx context.Context, req *GetProductsRequest) (*Products, error) {
+	field, ok := sortFields[req.GetSortBy()]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument,
+			fmt.Sprintf("%s: unknown sort field %d", model.ErrorInvalidQuery, req.GetSortBy()))
+	}
+	minPrice, err := model.ParsePriceBound(req.GetMinPrice(), "min price")
+	if err != nil {
+		return nil, statusError(err)
+	}
+	maxPrice, err := model.ParsePriceBound(req.GetMaxPrice(), "max price")
 	if err != nil {
-		return nil, status.Error(codes.Aborted, err.Error())
+		return nil, statusError(err)
+	}
+	query := model.ProductQuery{
+		Filter: model.ProductFilter{
+			NameContains:   req.GetNameContains(),
+			MinPrice:       minPrice,
+			MaxPrice:       maxPrice,
+			CreatedFrom:    req.CreatedFrom,
+			CreatedTo:      req.CreatedTo,
+			IncludeDeleted: req.GetIncludeDeleted(),
+		},
+		Sort: model.ProductSort{
+			Field: field,
+			Desc:  req.GetDescending(),
+		},
+	}
+	val, err := s.db.GetAll(ctx, query, model.PageRequest{
+		Size:   int(req.GetPageSize()),
+		Cursor: req.GetCursor(),
+	})
+	if err != nil {
+		return nil, statusError(err)
 	}
 
 	return &Products{
-		Items: mapProducts(val),
+		Items:      mapProducts(val.Items),
+		NextCursor: val.NextCursor,
+		HasMore:    val.HasMore,
+	}, nil
+}
+
+func (s Service) SearchProducts(ctx context.Context, req *SearchProductsRequest) (*SearchResult, error) {
+	query, err := model.NewSearchQuery(req.GetQuery(), int(req.GetLimit()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	val, err := s.db.Search(ctx, query)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &SearchResult{Items: mapProducts(val)}, nil
+}
+
+func (s Service) WatchProducts(req *WatchProductsRequest, stream grpc.ServerStreamingServer[ProductEvent]) error {
+	source, ok := s.db.(model.EventSource)
+	if !ok {
+		return status.Error(codes.Unimplemented, "storage doesn't publish its changes")
+	}
+	// The watches would hold the graceful stop otherwise
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	defer context.AfterFunc(s.ctx, cancel)()
+	sub, err := source.Subscribe(ctx, req.ResumeAfter)
+	if err != nil {
+		return statusError(err)
+	}
+	// The headers tell the client the watch has started
+	if err = stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for event := range sub.Events() {
+		if err = stream.Send(&ProductEvent{
+			Sequence: event.Sequence,
+			Type:     eventTypes[event.Type],
+			Product:  mapProduct(event.Product),
+		}); err != nil {
+			return err
+		}
+	}
+	switch {
+	case s.ctx.Err() != nil:
+		return status.Error(codes.Unavailable, "server is stopping")
+	case stream.Context().Err() != nil:
+		return status.FromContextError(stream.Context().Err()).Err()
+	}
+	return statusError(sub.Err())
+}
+
+var eventTypes = map[model.EventType]ProductEventType{
+	model.EventCreated: ProductEventType_PRODUCT_EVENT_TYPE_CREATED,
+	model.EventUpdated: ProductEventType_PRODUCT_EVENT_TYPE_UPDATED,
+	model.EventDeleted: ProductEventType_PRODUCT_EVENT_TYPE_DELETED,
+}
+
+func statusError(err error) error {
+	return status.Error(model.GRPCCode(err), err.Error())
+}
+
+func newProduct(req *AddRequest) (model.Product, error) {
+	if req.GetSample() {
+		return model.SampleProduct(), nil
+	}
+	price, currency, err := newPrice(req.GetPrice())
+	if err != nil {
+		return model.Product{}, err
+	}
+	return model.Product{
+		ID:       req.GetId(),
+		Name:     req.GetName(),
+		Price:    price,
+		Currency: currency,
 	}, nil
 }
 
+// newPrice reads the money, nil money is the zero price without a currency
+func newPrice(money *Money) (decimal.Decimal, string, error) {
+	if money == nil {
+		return decimal.Decimal{}, "", nil
+	}
+	price, err := model.PriceFromUnits(money.GetUnits(), money.GetNanos())
+	if err != nil {
+		return decimal.Decimal{}, "", err
+	}
+	return price, money.GetCurrencyCode(), nil
+}
+
+// newUpdate follows the update mask when it's set
+func newUpdate(req *UpdateRequest) (model.ProductUpdate, error) {
+	ret := model.ProductUpdate{
+		ID:      req.GetId(),
+		Version: req.GetVersion(),
+	}
+	price, currency, err := newPrice(req.GetPrice())
+	if err != nil {
+		return model.ProductUpdate{}, err
+	}
+	if req.GetUpdateMask() == nil {
+		if req.GetName() != "" {
+			ret.Name = model.Value(req.GetName())
+		}
+		if req.GetPrice() != nil {
+			ret.Price = model.Value(price)
+		}
+		if currency != "" {
+			ret.Currency = model.Value(currency)
+		}
+		return ret, nil
+	}
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		switch path {
+		case "name":
+			ret.Name = model.Null[string]()
+			if req.Name != nil {
+				ret.Name = model.Value(req.GetName())
+			}
+		case "price":
+			ret.Price = model.Null[decimal.Decimal]()
+			if req.GetPrice() != nil {
+				ret.Price = model.Value(price)
+			}
+			// The path covers the currency, a missing one is kept as it can't be cleared
+			if currency != "" {
+				ret.Currency = model.Value(currency)
+			}
+		case "price.currency_code":
+			ret.Currency = model.Null[string]()
+			if currency != "" {
+				ret.Currency = model.Value(currency)
+			}
+		default:
+			return model.ProductUpdate{}, fmt.Errorf("%w: %q can't be updated", model.ErrorInvalidProduct, path)
+		}
+	}
+	return ret, nil
+}
+
+func mapMoney(price decimal.Decimal, currency string) *Money {
+	units, nanos := model.PriceUnits(price)
+	return &Money{
+		CurrencyCode: currency,
+		Units:        units,
+		Nanos:        nanos,
+	}
+}
+
 func mapProduct(mod model.Product) *Product {
 	return &Product{
 		Id:        mod.ID,
 		Name:      mod.Name,
-		Price:     mod.Price,
+		Price:     mapMoney(mod.Price, mod.Currency),
 		CreatedAt: mod.CreatedAt,
+		Version:   mod.Version,
+		DeletedAt: mod.DeletedAt,
 	}
 }
 
@@ -127,3 +414,16 @@ func mapProducts(mod []model.Product) []*Product {
 	}
 	return products
 }
+
+// UnmarshalUpdate reads the protobuf body of the HTTP updates
+func UnmarshalUpdate(data []byte) (model.ProductUpdate, error) {
+	var req UpdateRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		return model.ProductUpdate{}, fmt.Errorf("%w: body must be a protobuf UpdateRequest", model.ErrorInvalidProduct)
+	}
+	return newUpdate(&req)
+}
+
+func MarshalUpdateResponse(msg string) ([]byte, error) {
+	return proto.Marshal(&UpdateResponse{Msg: msg})
+}
@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/aleksandrzhukovskii/go-template/internal/model"
+)
+
+const healthTimeout = time.Second * 2
+
+// healthServer reports both the server and ProductService serving while the storage answers the pings
+type healthServer struct {
+	*health.Server
+	db   model.DB
+	stop context.Context
+}
+
+func newHealthServer(stop context.Context, db model.DB) *healthServer {
+	return &healthServer{
+		Server: health.NewServer(),
+		db:     db,
+		stop:   stop,
+	}
+}
+
+func (h *healthServer) Check(ctx context.Context,
+	req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	h.refresh(ctx)
+	return h.Server.Check(ctx, req)
+}
+
+func (h *healthServer) Watch(req *grpc_health_v1.HealthCheckRequest,
+	stream grpc_health_v1.Health_WatchServer) error {
+	h.refresh(stream.Context())
+	// The watches would hold the stop of the server otherwise
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	defer context.AfterFunc(h.stop, cancel)()
+	return h.Server.Watch(req, watchStream{Health_WatchServer: stream, ctx: ctx})
+}
+
+type watchStream struct {
+	grpc_health_v1.Health_WatchServer
+	ctx context.Context
+}
+
+func (w watchStream) Context() context.Context {
+	return w.ctx
+}
+
+// refresh keeps the status NOT_SERVING after the shutdown
+func (h *healthServer) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+	status := grpc_health_v1.HealthCheckResponse_SERVING
+	if err := h.db.Ping(ctx); err != nil {
+		log.Warn().Err(err).Msg("storage doesn't answer the health check")
+		status = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	for _, service := range []string{"", ProductService_ServiceDesc.ServiceName} {
+		h.SetServingStatus(service, status)
+	}
+}
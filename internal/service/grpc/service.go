@@ -2,13 +2,18 @@ package grpc
 
 import (
 	"context"
-	"errors"
 	"net"
 	"net/http"
+	"strings"
+	"time"
 
-	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
+	channelz "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
 
+	"github.com/aleksandrzhukovskii/go-template/internal/config"
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
 )
 
@@ -16,28 +21,67 @@ type Service struct {
 	UnimplementedProductServiceServer
 	db     model.DB
 	server *grpc.Server
+	health *healthServer
 	lis    net.Listener
+	// ctx is cancelled when the server stops, it ends the streams
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-func New(db model.DB, lis net.Listener) (model.Server, error) {
+// New registers ProductService with the health service, the reflection and optionally channelz
+func New(cfg config.Config, db model.DB, lis net.Listener) (model.Server, error) {
 	ret := &Service{
 		db:     db,
-		server: grpc.NewServer(),
+		server: grpc.NewServer(grpc.ChainUnaryInterceptor(actor)),
 		lis:    lis,
 	}
+	ret.ctx, ret.cancel = context.WithCancel(context.Background())
+	ret.health = newHealthServer(ret.ctx, db)
 	RegisterProductServiceServer(ret.server, ret)
+	grpc_health_v1.RegisterHealthServer(ret.server, ret.health)
+	reflection.Register(ret.server)
+	if cfg.Grpc.Channelz {
+		channelz.RegisterChannelzServiceToServer(ret.server)
+	}
 	return ret, nil
 }
 
+func actor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if vals := metadata.ValueFromIncomingContext(ctx, strings.ToLower(model.ActorHeader)); len(vals) > 0 {
+		ctx = model.WithActor(ctx, vals[0])
+	}
+	return handler(ctx, req)
+}
+
+// Handler serves gRPC over the unencrypted HTTP/2 of net/http
+func (s Service) Handler() http.Handler {
+	return s.server
+}
+
 func (s Service) Start(ctx context.Context) error {
-	log.Info().Msgf("starting grpc server")
+	return model.Serve(ctx, "grpc", func() error {
+		return s.server.Serve(s.lis)
+	}, s.stop)
+}
+
+// Stop ends the watches, GracefulStop can't drain the calls of the handler
+func (s Service) Stop() {
+	s.health.Shutdown()
+	s.cancel()
+}
+
+// stop closes the streams still open after 30 seconds
+func (s Service) stop() error {
+	s.Stop()
+	stopped := make(chan struct{})
 	go func() {
-		if err := s.server.Serve(s.lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatal().Err(err).Msg("failed to start grpc server")
-		}
+		s.server.GracefulStop()
+		close(stopped)
 	}()
-	<-ctx.Done()
-	log.Info().Msg("gracefully shutting down grpc server")
-	s.server.GracefulStop()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second * 30):
+		s.server.Stop()
+	}
 	return nil
 }
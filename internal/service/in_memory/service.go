@@ -1,17 +1,17 @@
 package in_memory
 
 import (
+	"cmp"
 	"context"
-	"database/sql"
 	"errors"
-	"math/rand/v2"
+	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
-	"github.com/google/uuid"
 	"github.com/hashicorp/go-memdb"
-	"github.com/jaswdr/faker/v2"
+	"github.com/shopspring/decimal"
 
 	"github.com/aleksandrzhukovskii/go-template/internal/config"
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
@@ -32,6 +32,46 @@ func New(_ config.Config) (model.DB, error) {
 						Unique:  true,
 						Indexer: &memdb.StringFieldIndex{Field: "ID"},
 					},
+					// The sort indexes end with the id, so every position is unique and can be a cursor
+					string(model.SortByName): {
+						Name:    string(model.SortByName),
+						Unique:  true,
+						Indexer: sortIndex(&nameIndex{}),
+					},
+					string(model.SortByPrice): {
+						Name:    string(model.SortByPrice),
+						Unique:  true,
+						Indexer: sortIndex(priceIndex{}),
+					},
+					string(model.SortByCreatedAt): {
+						Name:    string(model.SortByCreatedAt),
+						Unique:  true,
+						Indexer: sortIndex(&memdb.UintFieldIndex{Field: "CreatedAt"}),
+					},
+					"words": {
+						Name:         "words",
+						AllowMissing: true,
+						Indexer:      wordsIndex{},
+					},
+				},
+			},
+			model.HistoryTableName: {
+				Name: model.HistoryTableName,
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:   "id",
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "ProductID"},
+								&memdb.UintFieldIndex{Field: "Version"},
+							},
+						},
+					},
+					"product_id": {
+						Name:    "product_id",
+						Indexer: &memdb.StringFieldIndex{Field: "ProductID"},
+					},
 				},
 			},
 		},
@@ -46,11 +86,15 @@ func New(_ config.Config) (model.DB, error) {
 	}, nil
 }
 
+func (s *Service) Ping(context.Context) error {
+	return nil
+}
+
 func (s *Service) Start() error {
 	return nil
 }
 
-func (s *Service) Add(_ context.Context) (str string, err error) {
+func (s *Service) Add(ctx context.Context, val model.Product) (_ model.Product, err error) {
 	tx := s.db.Txn(true)
 	defer func() {
 		if err != nil {
@@ -59,22 +103,36 @@ func (s *Service) Add(_ context.Context) (str string, err error) {
 			tx.Commit()
 		}
 	}()
-	f := faker.NewWithSeed(rand.NewPCG(uint64(time.Now().Unix()), uint64(time.Now().UnixNano())))
-	id := uuid.NewString()
-
-	err = tx.Insert(model.TableName, &model.Product{
-		ID:        id,
-		Name:      f.Food().Fruit(),
-		Price:     f.Float64(2, 1, 100),
-		CreatedAt: uint32(time.Now().Unix()),
-	})
+	return add(ctx, tx, val)
+}
+
+func add(ctx context.Context, tx *memdb.Txn, val model.Product) (model.Product, error) {
+	val, err := model.PrepareProduct(val)
 	if err != nil {
-		return "", err
+		return model.Product{}, err
 	}
-	return id, err
+	// memdb Insert replaces the existing object with the same id
+	raw, err := tx.First(model.TableName, "id", val.ID)
+	if err != nil {
+		return model.Product{}, err
+	}
+	if raw != nil {
+		return model.Product{}, model.ErrorAlreadyExists
+	}
+
+	val.Name = strings.Clone(val.Name)
+	val.ID = strings.Clone(val.ID)
+	if err = tx.Insert(model.TableName, &val); err != nil {
+		return model.Product{}, err
+	}
+	return val, record(ctx, tx, model.OperationAdd, val)
 }
 
-func (s *Service) Update(_ context.Context, val model.Product) (err error) {
+func (s *Service) Upsert(ctx context.Context, val model.Product) (_ model.Product, _ bool, err error) {
+	val, err = model.PrepareUpsert(val)
+	if err != nil {
+		return model.Product{}, false, err
+	}
 	tx := s.db.Txn(true)
 	defer func() {
 		if err != nil {
@@ -85,26 +143,65 @@ func (s *Service) Update(_ context.Context, val model.Product) (err error) {
 	}()
 	raw, err := tx.First(model.TableName, "id", val.ID)
 	if err != nil {
-		return err
+		return model.Product{}, false, err
 	}
-	if raw == nil {
-		return model.ErrorNoRowsUpdated
+	prod, op := val, model.OperationAdd
+	if raw != nil {
+		if val.Unchanged(*raw.(*model.Product)) {
+			return *raw.(*model.Product), false, nil
+		}
+		prod, op = val.Replace(*raw.(*model.Product)), model.OperationUpdate
 	}
-	if val.Name != "" && val.Price != 0 {
-		raw.(*model.Product).Price = val.Price
-		raw.(*model.Product).Name = strings.Clone(val.Name)
-	} else if val.Price != 0 {
-		raw.(*model.Product).Price = val.Price
-	} else if val.Name != "" {
-		raw.(*model.Product).Name = strings.Clone(val.Name)
-	} else {
-		return model.ErrorNoUpdateParams
+
+	prod.ID = strings.Clone(prod.ID)
+	prod.Name = strings.Clone(prod.Name)
+	prod.Currency = strings.Clone(prod.Currency)
+	if err = tx.Insert(model.TableName, &prod); err != nil {
+		return model.Product{}, false, err
 	}
+	return prod, raw == nil, record(ctx, tx, op, prod)
+}
 
-	return nil
+func (s *Service) Update(ctx context.Context, val model.ProductUpdate) (_ model.Product, err error) {
+	tx := s.db.Txn(true)
+	defer func() {
+		if err != nil {
+			tx.Abort()
+		} else {
+			tx.Commit()
+		}
+	}()
+	return update(ctx, tx, val)
+}
+
+func update(ctx context.Context, tx *memdb.Txn, val model.ProductUpdate) (model.Product, error) {
+	raw, err := tx.First(model.TableName, "id", val.ID)
+	if err != nil {
+		return model.Product{}, err
+	}
+	if raw == nil || raw.(*model.Product).DeletedAt != 0 {
+		return model.Product{}, model.ErrorNoRowsUpdated
+	}
+	if val.Version != 0 && val.Version != raw.(*model.Product).Version {
+		return model.Product{}, model.ErrorVersionConflict
+	}
+	// The stored object must not be changed in place, the indexes are built from it
+	prod := *raw.(*model.Product)
+	if err = val.Validate(); err != nil {
+		return model.Product{}, err
+	}
+	prod = val.Apply(prod)
+	prod.Name = strings.Clone(prod.Name)
+	prod.Currency = strings.Clone(prod.Currency)
+	prod.Version++
+
+	if err = tx.Insert(model.TableName, &prod); err != nil {
+		return model.Product{}, err
+	}
+	return prod, record(ctx, tx, model.OperationUpdate, prod)
 }
 
-func (s *Service) Delete(_ context.Context, id string) (err error) {
+func (s *Service) Delete(ctx context.Context, id string, version uint64) (_ model.Product, err error) {
 	tx := s.db.Txn(true)
 	defer func() {
 		if err != nil {
@@ -113,14 +210,146 @@ func (s *Service) Delete(_ context.Context, id string) (err error) {
 			tx.Commit()
 		}
 	}()
-	err = tx.Delete(model.TableName, &model.Product{ID: id})
+	return softDelete(ctx, tx, id, version)
+}
+
+func softDelete(ctx context.Context, tx *memdb.Txn, id string, version uint64) (model.Product, error) {
+	raw, err := tx.First(model.TableName, "id", id)
 	if err != nil {
-		if errors.Is(err, memdb.ErrNotFound) {
-			return model.ErrorNoRowsDeleted
+		return model.Product{}, err
+	}
+	if raw == nil || raw.(*model.Product).DeletedAt != 0 {
+		return model.Product{}, model.ErrorNoRowsDeleted
+	}
+	if version != 0 && version != raw.(*model.Product).Version {
+		return model.Product{}, model.ErrorVersionConflict
+	}
+	prod := *raw.(*model.Product)
+	prod.DeletedAt = uint32(time.Now().Unix())
+	prod.Version++
+	if err = tx.Insert(model.TableName, &prod); err != nil {
+		return model.Product{}, err
+	}
+	return prod, record(ctx, tx, model.OperationDelete, prod)
+}
+
+func record(ctx context.Context, tx *memdb.Txn, op model.Operation, val model.Product) error {
+	entry := model.NewHistoryEntry(ctx, op, val)
+	return tx.Insert(model.HistoryTableName, &entry)
+}
+
+func (s *Service) AddBatch(ctx context.Context, vals []model.Product) ([]model.BatchResult, error) {
+	return batch(s, vals, func(tx *memdb.Txn, val model.Product) (*model.Product, error) {
+		prod, err := add(ctx, tx, val)
+		return &prod, err
+	})
+}
+
+func (s *Service) UpdateBatch(ctx context.Context, vals []model.ProductUpdate) ([]model.BatchResult, error) {
+	return batch(s, vals, func(tx *memdb.Txn, val model.ProductUpdate) (*model.Product, error) {
+		prod, err := update(ctx, tx, val)
+		return &prod, err
+	})
+}
+
+func (s *Service) DeleteBatch(ctx context.Context, vals []model.Product) ([]model.BatchResult, error) {
+	return batch(s, vals, func(tx *memdb.Txn, val model.Product) (*model.Product, error) {
+		prod, err := softDelete(ctx, tx, val.ID, val.Version)
+		return &prod, err
+	})
+}
+
+// batch checks the items before changing anything, so the failed ones leave no changes
+func batch[T model.BatchItem](s *Service, vals []T,
+	write func(tx *memdb.Txn, val T) (*model.Product, error)) (_ []model.BatchResult, err error) {
+	tx := s.db.Txn(true)
+	defer func() {
+		if err != nil {
+			tx.Abort()
+		} else {
+			tx.Commit()
+		}
+	}()
+	return model.RunBatch(vals, func(val T) (*model.Product, error) {
+		return write(tx, val)
+	})
+}
+
+func (s *Service) Restore(ctx context.Context, id string) (_ model.Product, err error) {
+	tx := s.db.Txn(true)
+	defer func() {
+		if err != nil {
+			tx.Abort()
+		} else {
+			tx.Commit()
 		}
-		return err
+	}()
+	raw, err := tx.First(model.TableName, "id", id)
+	if err != nil {
+		return model.Product{}, err
 	}
-	return nil
+	if raw == nil || raw.(*model.Product).DeletedAt == 0 {
+		return model.Product{}, model.ErrorNoRowsRestored
+	}
+	prod := *raw.(*model.Product)
+	prod.DeletedAt = 0
+	prod.Version++
+	if err = tx.Insert(model.TableName, &prod); err != nil {
+		return model.Product{}, err
+	}
+	return prod, record(ctx, tx, model.OperationRestore, prod)
+}
+
+func (s *Service) Purge(_ context.Context, before uint32) (_ int64, err error) {
+	tx := s.db.Txn(true)
+	defer func() {
+		if err != nil {
+			tx.Abort()
+		} else {
+			tx.Commit()
+		}
+	}()
+	it, err := tx.Get(model.TableName, "id")
+	if err != nil {
+		return 0, err
+	}
+	// Modifying the table invalidates the iterators, so the objects are collected first
+	var purged []any
+	for obj := it.Next(); obj != nil; obj = it.Next() {
+		if val := obj.(*model.Product); val.DeletedAt != 0 && val.DeletedAt <= before {
+			purged = append(purged, obj)
+		}
+	}
+	for _, obj := range purged {
+		if err = tx.Delete(model.TableName, obj); err != nil {
+			return 0, err
+		}
+		if _, err = tx.DeleteAll(model.HistoryTableName, "product_id", obj.(*model.Product).ID); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(purged)), nil
+}
+
+func (s *Service) History(_ context.Context, id string) (_ []model.HistoryEntry, err error) {
+	tx := s.db.Txn(false)
+	defer func() {
+		if err != nil {
+			tx.Abort()
+		} else {
+			tx.Commit()
+		}
+	}()
+	it, err := tx.Get(model.HistoryTableName, "product_id", id)
+	if err != nil {
+		return nil, err
+	}
+	// The keys end with the big-endian version, so the entries come in order
+	var ret []model.HistoryEntry
+	for obj := it.Next(); obj != nil; obj = it.Next() {
+		ret = append(ret, *obj.(*model.HistoryEntry))
+	}
+	return model.LinkHistory(ret), nil
 }
 
 func (s *Service) Get(_ context.Context, id string) (_ model.Product, err error) {
@@ -136,13 +365,13 @@ func (s *Service) Get(_ context.Context, id string) (_ model.Product, err error)
 	if err != nil {
 		return model.Product{}, err
 	}
-	if raw == nil {
-		return model.Product{}, sql.ErrNoRows
+	if raw == nil || raw.(*model.Product).DeletedAt != 0 {
+		return model.Product{}, model.ErrorNotFound
 	}
 	return *raw.(*model.Product), nil
 }
 
-func (s *Service) GetAll(_ context.Context) (_ []model.Product, err error) {
+func (s *Service) GetMany(_ context.Context, ids []string) (_ []model.Product, err error) {
 	tx := s.db.Txn(false)
 	defer func() {
 		if err != nil {
@@ -151,13 +380,181 @@ func (s *Service) GetAll(_ context.Context) (_ []model.Product, err error) {
 			tx.Commit()
 		}
 	}()
-	it, err := tx.Get(model.TableName, "id")
+	ret := []model.Product{}
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if _, found := seen[id]; found {
+			continue
+		}
+		seen[id] = struct{}{}
+		raw, err := tx.First(model.TableName, "id", id)
+		if err != nil {
+			return nil, err
+		}
+		if raw != nil && raw.(*model.Product).DeletedAt == 0 {
+			ret = append(ret, *raw.(*model.Product))
+		}
+	}
+	return ret, nil
+}
+
+func (s *Service) Search(_ context.Context, query model.SearchQuery) (_ []model.Product, err error) {
+	tx := s.db.Txn(false)
+	defer func() {
+		if err != nil {
+			tx.Abort()
+		} else {
+			tx.Commit()
+		}
+	}()
+	it, err := tx.Get(model.TableName, "words_prefix", query.Terms[0])
 	if err != nil {
 		return nil, err
 	}
-	var ret []model.Product
+	// A product is found once per word matching the term
+	scores := map[string]float64{}
+	ret := []model.Product{}
 	for obj := it.Next(); obj != nil; obj = it.Next() {
-		ret = append(ret, *obj.(*model.Product))
+		val := obj.(*model.Product)
+		if _, found := scores[val.ID]; found {
+			continue
+		}
+		scores[val.ID] = query.Score(model.Tokenize(val.Name))
+		if scores[val.ID] > 0 {
+			ret = append(ret, *val)
+		}
 	}
-	return ret, nil
+	slices.SortFunc(ret, func(a, b model.Product) int {
+		return cmp.Or(cmp.Compare(scores[b.ID], scores[a.ID]), cmp.Compare(a.ID, b.ID))
+	})
+	return ret[:min(len(ret), query.Limit)], nil
+}
+
+func (s *Service) GetAll(_ context.Context, query model.ProductQuery, page model.PageRequest) (_ model.ProductPage,
+	err error) {
+	size, after, err := page.Prepare(&query)
+	if err != nil {
+		return model.ProductPage{}, err
+	}
+	tx := s.db.Txn(false)
+	defer func() {
+		if err != nil {
+			tx.Abort()
+		} else {
+			tx.Commit()
+		}
+	}()
+	index := string(query.Sort.Field)
+	var it memdb.ResultIterator
+	switch {
+	case after == nil && query.Sort.Desc:
+		it, err = tx.GetReverse(model.TableName, index)
+	case after == nil:
+		it, err = tx.Get(model.TableName, index)
+	case query.Sort.Desc:
+		it, err = tx.ReverseLowerBound(model.TableName, index, cursorArgs(query.Sort, *after)...)
+	default:
+		it, err = tx.LowerBound(model.TableName, index, cursorArgs(query.Sort, *after)...)
+	}
+	if err != nil {
+		return model.ProductPage{}, err
+	}
+	var ret []model.Product
+	for obj := it.Next(); obj != nil && len(ret) <= size; obj = it.Next() {
+		val := obj.(*model.Product)
+		// The bounded iterators start from the cursor itself
+		if after != nil && query.Sort.Compare(*val, *after) == 0 {
+			continue
+		}
+		if query.Filter.Match(*val) {
+			ret = append(ret, *val)
+		}
+	}
+	return model.NewProductPage(ret, size, query.Sort), nil
+}
+
+func sortIndex(field memdb.Indexer) memdb.Indexer {
+	return &memdb.CompoundIndex{
+		Indexes: []memdb.Indexer{field, &memdb.StringFieldIndex{Field: "ID"}},
+	}
+}
+
+func cursorArgs(sort model.ProductSort, after model.Product) []any {
+	if sort.Field == model.SortByID {
+		return []any{after.ID}
+	}
+	return []any{sort.Key(after), after.ID}
+}
+
+// wordsIndex is the inverted index of the names of the live products
+type wordsIndex struct{}
+
+func (wordsIndex) FromObject(obj any) (bool, [][]byte, error) {
+	val, ok := obj.(*model.Product)
+	if !ok {
+		return false, nil, fmt.Errorf("%T is not a product", obj)
+	}
+	if val.DeletedAt != 0 {
+		return false, nil, nil
+	}
+	words := model.Tokenize(val.Name)
+	ret := make([][]byte, 0, len(words))
+	for _, word := range words {
+		ret = append(ret, []byte(word+"\x00"))
+	}
+	return len(ret) > 0, ret, nil
+}
+
+func (w wordsIndex) FromArgs(args ...any) ([]byte, error) {
+	ret, err := w.PrefixFromArgs(args...)
+	if err != nil {
+		return nil, err
+	}
+	return append(ret, 0), nil
+}
+
+func (wordsIndex) PrefixFromArgs(args ...any) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("must provide only a single argument")
+	}
+	val, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a string: %#v", args[0])
+	}
+	return []byte(val), nil
+}
+
+// nameIndex keeps the products with the cleared names, unlike memdb.StringFieldIndex
+type nameIndex struct {
+	memdb.StringFieldIndex
+}
+
+func (nameIndex) FromObject(obj any) (bool, []byte, error) {
+	val, ok := obj.(*model.Product)
+	if !ok {
+		return false, nil, fmt.Errorf("%T is not a product", obj)
+	}
+	return true, []byte(val.Name + "\x00"), nil
+}
+
+// priceIndex orders the products by model.PriceKey
+type priceIndex struct{}
+
+func (priceIndex) FromObject(obj any) (bool, []byte, error) {
+	val, ok := obj.(*model.Product)
+	if !ok {
+		return false, nil, fmt.Errorf("%T is not a product", obj)
+	}
+	return true, model.PriceKey(val.Price), nil
+}
+
+func (priceIndex) FromArgs(args ...any) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("must provide only a single argument")
+	}
+	val, ok := args[0].(decimal.Decimal)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a decimal: %#v", args[0])
+	}
+	return model.PriceKey(val), nil
 }
@@ -1,18 +1,14 @@
 package in_memory2
 
 import (
+	"cmp"
 	"context"
-	"database/sql"
-	"errors"
-	"math/rand/v2"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/google/uuid"
-	"github.com/jaswdr/faker/v2"
-
 	"github.com/aleksandrzhukovskii/go-template/internal/config"
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
 )
@@ -20,10 +16,19 @@ import (
 type Service struct {
 	mu       sync.RWMutex
 	products []model.Product
+	history  map[string][]model.HistoryEntry
+	words    wordIndex
 }
 
 func New(_ config.Config) (model.DB, error) {
-	return &Service{}, nil
+	return &Service{
+		history: map[string][]model.HistoryEntry{},
+		words:   wordIndex{ids: map[string]map[string]struct{}{}},
+	}, nil
+}
+
+func (s *Service) Ping(context.Context) error {
+	return nil
 }
 
 func (s *Service) Start() error {
@@ -40,63 +45,178 @@ func (s *Service) findIndex(id string) (int, bool) {
 	return i, false
 }
 
-func (s *Service) Add(_ context.Context) (string, error) {
+func (s *Service) Add(ctx context.Context, val model.Product) (model.Product, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.add(ctx, val)
+}
 
-	f := faker.NewWithSeed(rand.NewPCG(uint64(time.Now().Unix()), uint64(time.Now().UnixNano())))
-	id := uuid.NewString()
-	val := model.Product{
-		ID:        id,
-		Name:      f.Food().Fruit(),
-		Price:     f.Float64(2, 1, 100),
-		CreatedAt: uint32(time.Now().Unix()),
+// add has to be called with the write lock held, as do update, softDelete and record
+func (s *Service) add(ctx context.Context, val model.Product) (model.Product, error) {
+	val, err := model.PrepareProduct(val)
+	if err != nil {
+		return model.Product{}, err
 	}
+	val.ID = strings.Clone(val.ID)
+	val.Name = strings.Clone(val.Name)
 
-	i, found := s.findIndex(id)
+	i, found := s.findIndex(val.ID)
 	if found {
-		return "", errors.New("product already exists")
+		return model.Product{}, model.ErrorAlreadyExists
 	}
 
 	s.products = append(s.products, model.Product{})
 	copy(s.products[i+1:], s.products[i:])
 	s.products[i] = val
-	return id, nil
+	s.words.add(val.ID, val.Name)
+	s.record(ctx, model.OperationAdd, val)
+	return val, nil
 }
 
-func (s *Service) Update(_ context.Context, val model.Product) error {
+func (s *Service) Upsert(ctx context.Context, val model.Product) (model.Product, bool, error) {
+	val, err := model.PrepareUpsert(val)
+	if err != nil {
+		return model.Product{}, false, err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	i, found := s.findIndex(val.ID)
 	if !found {
-		return model.ErrorNoRowsUpdated
-	}
-	newVal := s.products[i]
-	if val.Name != "" && val.Price != 0 {
-		newVal.Price = val.Price
-		newVal.Name = strings.Clone(val.Name)
-	} else if val.Price != 0 {
-		newVal.Price = val.Price
-	} else if val.Name != "" {
-		newVal.Name = strings.Clone(val.Name)
-	} else {
-		return model.ErrorNoUpdateParams
+		val, err = s.add(ctx, val)
+		return val, err == nil, err
+	}
+	if val.Unchanged(s.products[i]) {
+		return s.products[i], false, nil
 	}
+	newVal := val.Replace(s.products[i])
+	newVal.Name = strings.Clone(newVal.Name)
+	newVal.Currency = strings.Clone(newVal.Currency)
+	if s.products[i].DeletedAt == 0 {
+		s.words.remove(newVal.ID, s.products[i].Name)
+	}
+	s.words.add(newVal.ID, newVal.Name)
 	s.products[i] = newVal
-	return nil
+	s.record(ctx, model.OperationUpdate, newVal)
+	return newVal, false, nil
+}
+
+func (s *Service) Update(ctx context.Context, val model.ProductUpdate) (model.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.update(ctx, val)
+}
+
+func (s *Service) update(ctx context.Context, val model.ProductUpdate) (model.Product, error) {
+	i, found := s.findIndex(val.ID)
+	if !found || s.products[i].DeletedAt != 0 {
+		return model.Product{}, model.ErrorNoRowsUpdated
+	}
+	if val.Version != 0 && val.Version != s.products[i].Version {
+		return model.Product{}, model.ErrorVersionConflict
+	}
+	if err := val.Validate(); err != nil {
+		return model.Product{}, err
+	}
+	newVal := val.Apply(s.products[i])
+	newVal.Name = strings.Clone(newVal.Name)
+	newVal.Currency = strings.Clone(newVal.Currency)
+	newVal.Version++
+	s.words.remove(newVal.ID, s.products[i].Name)
+	s.words.add(newVal.ID, newVal.Name)
+	s.products[i] = newVal
+	s.record(ctx, model.OperationUpdate, newVal)
+	return newVal, nil
 }
 
-func (s *Service) Delete(_ context.Context, id string) error {
+func (s *Service) Delete(ctx context.Context, id string, version uint64) (model.Product, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.softDelete(ctx, id, version)
+}
 
+func (s *Service) softDelete(ctx context.Context, id string, version uint64) (model.Product, error) {
 	i, found := s.findIndex(id)
-	if !found {
-		return model.ErrorNoRowsDeleted
+	if !found || s.products[i].DeletedAt != 0 {
+		return model.Product{}, model.ErrorNoRowsDeleted
 	}
-	s.products = append(s.products[:i], s.products[i+1:]...)
-	return nil
+	if version != 0 && version != s.products[i].Version {
+		return model.Product{}, model.ErrorVersionConflict
+	}
+	s.products[i].DeletedAt = uint32(time.Now().Unix())
+	s.products[i].Version++
+	s.words.remove(id, s.products[i].Name)
+	s.record(ctx, model.OperationDelete, s.products[i])
+	return s.products[i], nil
+}
+
+func (s *Service) record(ctx context.Context, op model.Operation, val model.Product) {
+	s.history[val.ID] = append(s.history[val.ID], model.NewHistoryEntry(ctx, op, val))
+}
+
+func (s *Service) AddBatch(ctx context.Context, vals []model.Product) ([]model.BatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return model.RunBatch(vals, func(val model.Product) (*model.Product, error) {
+		prod, err := s.add(ctx, val)
+		return &prod, err
+	})
+}
+
+func (s *Service) UpdateBatch(ctx context.Context, vals []model.ProductUpdate) ([]model.BatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return model.RunBatch(vals, func(val model.ProductUpdate) (*model.Product, error) {
+		prod, err := s.update(ctx, val)
+		return &prod, err
+	})
+}
+
+func (s *Service) DeleteBatch(ctx context.Context, vals []model.Product) ([]model.BatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return model.RunBatch(vals, func(val model.Product) (*model.Product, error) {
+		prod, err := s.softDelete(ctx, val.ID, val.Version)
+		return &prod, err
+	})
+}
+
+func (s *Service) Restore(ctx context.Context, id string) (model.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, found := s.findIndex(id)
+	if !found || s.products[i].DeletedAt == 0 {
+		return model.Product{}, model.ErrorNoRowsRestored
+	}
+	s.products[i].DeletedAt = 0
+	s.products[i].Version++
+	s.words.add(id, s.products[i].Name)
+	s.record(ctx, model.OperationRestore, s.products[i])
+	return s.products[i], nil
+}
+
+func (s *Service) Purge(_ context.Context, before uint32) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.products)
+	s.products = slices.DeleteFunc(s.products, func(val model.Product) bool {
+		if val.DeletedAt != 0 && val.DeletedAt <= before {
+			delete(s.history, val.ID)
+			return true
+		}
+		return false
+	})
+	return int64(n - len(s.products)), nil
+}
+
+func (s *Service) History(_ context.Context, id string) ([]model.HistoryEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Only Before of the copies is filled
+	return model.LinkHistory(slices.Clone(s.history[id])), nil
 }
 
 func (s *Service) Get(_ context.Context, id string) (model.Product, error) {
@@ -104,17 +224,130 @@ func (s *Service) Get(_ context.Context, id string) (model.Product, error) {
 	defer s.mu.RUnlock()
 
 	i, found := s.findIndex(id)
-	if !found {
-		return model.Product{}, sql.ErrNoRows
+	if !found || s.products[i].DeletedAt != 0 {
+		return model.Product{}, model.ErrorNotFound
 	}
 	return s.products[i], nil
 }
 
-func (s *Service) GetAll(_ context.Context) ([]model.Product, error) {
+func (s *Service) GetMany(_ context.Context, ids []string) ([]model.Product, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	out := make([]model.Product, len(s.products))
-	copy(out, s.products)
-	return out, nil
+	ret := []model.Product{}
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if _, found := seen[id]; found {
+			continue
+		}
+		seen[id] = struct{}{}
+		if i, found := s.findIndex(id); found && s.products[i].DeletedAt == 0 {
+			ret = append(ret, s.products[i])
+		}
+	}
+	return ret, nil
+}
+
+func (s *Service) GetAll(_ context.Context, query model.ProductQuery, page model.PageRequest) (model.ProductPage, error) {
+	size, after, err := page.Prepare(&query)
+	if err != nil {
+		return model.ProductPage{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// The slice is sorted by id
+	var out []model.Product
+	if query.Sort.Field == model.SortByID && !query.Sort.Desc {
+		start := 0
+		if after != nil {
+			start = sort.Search(len(s.products), func(i int) bool {
+				return s.products[i].ID > after.ID
+			})
+		}
+		for i := start; i < len(s.products) && len(out) <= size; i++ {
+			if query.Filter.Match(s.products[i]) {
+				out = append(out, s.products[i])
+			}
+		}
+		return model.NewProductPage(out, size, query.Sort), nil
+	}
+
+	for _, val := range s.products {
+		if query.Filter.Match(val) {
+			out = append(out, val)
+		}
+	}
+	slices.SortFunc(out, query.Sort.Compare)
+	start := 0
+	if after != nil {
+		start = sort.Search(len(out), func(i int) bool {
+			return query.Sort.Compare(out[i], *after) > 0
+		})
+	}
+	out = out[start:min(start+size+1, len(out))]
+	return model.NewProductPage(out, size, query.Sort), nil
+}
+
+func (s *Service) Search(_ context.Context, query model.SearchQuery) ([]model.Product, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	scores := map[string]float64{}
+	ret := []model.Product{}
+	for _, word := range s.words.prefixed(query.Terms[0]) {
+		for id := range s.words.ids[word] {
+			if _, found := scores[id]; found {
+				continue
+			}
+			i, _ := s.findIndex(id)
+			scores[id] = query.Score(model.Tokenize(s.products[i].Name))
+			if scores[id] > 0 {
+				ret = append(ret, s.products[i])
+			}
+		}
+	}
+	slices.SortFunc(ret, func(a, b model.Product) int {
+		return cmp.Or(cmp.Compare(scores[b.ID], scores[a.ID]), cmp.Compare(a.ID, b.ID))
+	})
+	return ret[:min(len(ret), query.Limit)], nil
+}
+
+// wordIndex maps the words of the names to the product ids, the caller holds the lock
+type wordIndex struct {
+	ids    map[string]map[string]struct{}
+	sorted []string
+}
+
+func (w *wordIndex) add(id string, name string) {
+	for _, word := range model.Tokenize(name) {
+		if w.ids[word] == nil {
+			w.ids[word] = map[string]struct{}{}
+			i, _ := slices.BinarySearch(w.sorted, word)
+			w.sorted = slices.Insert(w.sorted, i, word)
+		}
+		w.ids[word][id] = struct{}{}
+	}
+}
+
+func (w *wordIndex) remove(id string, name string) {
+	for _, word := range model.Tokenize(name) {
+		delete(w.ids[word], id)
+		if len(w.ids[word]) == 0 {
+			delete(w.ids, word)
+			if i, found := slices.BinarySearch(w.sorted, word); found {
+				w.sorted = slices.Delete(w.sorted, i, i+1)
+			}
+		}
+	}
+}
+
+func (w *wordIndex) prefixed(prefix string) []string {
+	start, _ := slices.BinarySearch(w.sorted, prefix)
+	end := start
+	for end < len(w.sorted) && strings.HasPrefix(w.sorted[end], prefix) {
+		end++
+	}
+	return w.sorted[start:end]
 }
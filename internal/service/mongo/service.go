@@ -2,13 +2,14 @@ package mongo
 
 import (
 	"context"
-	"database/sql"
 	"errors"
-	"math/rand/v2"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
 	"time"
 
-	"github.com/google/uuid"
-	"github.com/jaswdr/faker/v2"
+	"github.com/shopspring/decimal"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
@@ -18,10 +19,13 @@ import (
 )
 
 type Service struct {
-	dns    string
-	dbName string
-	db     *mongo.Client
-	c      *mongo.Collection
+	dns     string
+	dbName  string
+	db      *mongo.Client
+	c       *mongo.Collection
+	history *mongo.Collection
+	// transactions are only available on replica sets and sharded clusters
+	transactions bool
 }
 
 func New(cfg config.Config) (model.DB, error) {
@@ -31,88 +35,529 @@ func New(cfg config.Config) (model.DB, error) {
 	}, nil
 }
 
+func (s *Service) Ping(ctx context.Context) error {
+	return s.db.Ping(ctx, nil)
+}
+
 func (s *Service) Start() error {
-	client, err := mongo.Connect(options.Client().ApplyURI(s.dns))
+	client, err := mongo.Connect(options.Client().ApplyURI(s.dns).SetRegistry(registry()))
 	if err != nil {
 		return err
 	}
 	s.c = client.Database(s.dbName).Collection(model.TableName)
+	s.history = client.Database(s.dbName).Collection(model.HistoryTableName)
 	s.db = client
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	var hello bson.M
+	if err = client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return err
+	}
+	_, replicaSet := hello["setName"]
+	s.transactions = replicaSet || hello["msg"] == "isdbgrid"
+	return s.migrate()
+}
+
+func (s *Service) migrate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	_, err := s.c.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.history.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	// The text index only matches whole words, so it's built over the prefixes
+	_, err = s.c.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name_prefixes", Value: "text"}},
+		Options: options.Index().SetDefaultLanguage("none"),
+	})
+	if err != nil {
+		return err
+	}
+	// Products stored before the search was introduced
+	cursor, err := s.c.Find(ctx, bson.M{"name_prefixes": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"id": 1, "name": 1}))
+	if err != nil {
+		return err
+	}
+	var missing []model.Product
+	if err = cursor.All(ctx, &missing); err != nil {
+		return err
+	}
+	for _, val := range missing {
+		_, err = s.c.UpdateOne(ctx, bson.M{"id": val.ID},
+			bson.M{"$set": bson.M{"name_prefixes": model.Prefixes(val.Name)}})
+		if err != nil {
+			return err
+		}
+	}
+	// Products and history entries stored when the prices were floats
+	for _, c := range []struct {
+		coll   *mongo.Collection
+		prefix string
+	}{{s.c, ""}, {s.history, "after."}} {
+		_, err = c.coll.UpdateMany(ctx, bson.M{c.prefix + "price": bson.M{"$type": "double"}}, bson.A{
+			bson.M{"$set": bson.M{c.prefix + "price": bson.M{
+				"$round": bson.A{bson.M{"$toDecimal": "$" + c.prefix + "price"}, model.PriceScale},
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = c.coll.UpdateMany(ctx, bson.M{c.prefix + "currency": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{c.prefix + "currency": model.DefaultCurrency}})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (s *Service) Add(ctx context.Context) (string, error) {
-	f := faker.NewWithSeed(rand.NewPCG(uint64(time.Now().Unix()), uint64(time.Now().UnixNano())))
-	id := uuid.NewString()
-	_, err := s.c.InsertOne(ctx, model.Product{
-		ID:        id,
-		Name:      f.Food().Fruit(),
-		Price:     f.Float64(2, 1, 100),
-		CreatedAt: uint32(time.Now().Unix()),
+// registry stores the prices as Decimal128 and still reads the floats
+func registry() *bson.Registry {
+	ret := bson.NewRegistry()
+	typ := reflect.TypeFor[decimal.Decimal]()
+	ret.RegisterTypeEncoder(typ, bson.ValueEncoderFunc(
+		func(_ bson.EncodeContext, vw bson.ValueWriter, val reflect.Value) error {
+			d128, err := bson.ParseDecimal128(val.Interface().(decimal.Decimal).String())
+			if err != nil {
+				return err
+			}
+			return vw.WriteDecimal128(d128)
+		}))
+	ret.RegisterTypeDecoder(typ, bson.ValueDecoderFunc(
+		func(_ bson.DecodeContext, vr bson.ValueReader, val reflect.Value) error {
+			var ret decimal.Decimal
+			switch vr.Type() {
+			case bson.TypeDecimal128:
+				d128, err := vr.ReadDecimal128()
+				if err != nil {
+					return err
+				}
+				if ret, err = decimal.NewFromString(d128.String()); err != nil {
+					return err
+				}
+			case bson.TypeDouble:
+				f, err := vr.ReadDouble()
+				if err != nil {
+					return err
+				}
+				ret = decimal.NewFromFloat(f)
+			case bson.TypeNull:
+				if err := vr.ReadNull(); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("cannot decode %v into a decimal", vr.Type())
+			}
+			val.Set(reflect.ValueOf(ret))
+			return nil
+		}))
+	return ret
+}
+
+type document struct {
+	model.Product `bson:",inline"`
+	NamePrefixes  []string `bson:"name_prefixes"`
+}
+
+func (s *Service) Add(ctx context.Context, val model.Product) (ret model.Product, err error) {
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		ret, err = s.add(ctx, val)
+		return err
+	})
+	return ret, err
+}
+
+func (s *Service) add(ctx context.Context, val model.Product) (model.Product, error) {
+	val, err := model.PrepareProduct(val)
+	if err != nil {
+		return model.Product{}, err
+	}
+	if _, err = s.c.InsertOne(ctx, document{Product: val, NamePrefixes: model.Prefixes(val.Name)}); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return model.Product{}, model.ErrorAlreadyExists
+		}
+		return model.Product{}, err
+	}
+	return val, s.record(ctx, model.OperationAdd, val)
+}
+
+func (s *Service) Upsert(ctx context.Context, val model.Product) (ret model.Product, created bool, err error) {
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		ret, created, err = s.upsert(ctx, val)
+		return err
 	})
+	return ret, created, err
+}
+
+// upsert replaces the document only when it still has the version it was read with
+func (s *Service) upsert(ctx context.Context, val model.Product) (model.Product, bool, error) {
+	val, err := model.PrepareUpsert(val)
+	if err != nil {
+		return model.Product{}, false, err
+	}
+	ret, filter := val, bson.M{"id": val.ID}
+	var current model.Product
+	err = s.c.FindOne(ctx, filter).Decode(&current)
+	if err == nil {
+		if val.Unchanged(current) {
+			return current, false, nil
+		}
+		ret, filter = val.Replace(current), bson.M{"id": val.ID, "version": current.Version}
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return model.Product{}, false, err
+	}
 
-	return id, err
+	res, err := s.c.ReplaceOne(ctx, filter, document{Product: ret, NamePrefixes: model.Prefixes(ret.Name)},
+		options.Replace().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return model.Product{}, false, model.ErrorVersionConflict
+		}
+		return model.Product{}, false, err
+	}
+	if res.UpsertedCount > 0 {
+		return ret, true, s.record(ctx, model.OperationAdd, ret)
+	}
+	return ret, false, s.record(ctx, model.OperationUpdate, ret)
 }
 
-func (s *Service) Update(ctx context.Context, val model.Product) error {
+func (s *Service) Update(ctx context.Context, val model.ProductUpdate) (ret model.Product, err error) {
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		ret, err = s.update(ctx, val)
+		return err
+	})
+	return ret, err
+}
+
+func (s *Service) update(ctx context.Context, val model.ProductUpdate) (model.Product, error) {
+	if err := val.Validate(); err != nil {
+		return model.Product{}, err
+	}
 	update := bson.M{}
-	if val.Name != "" {
-		update["name"] = val.Name
+	cols, vals := val.Columns()
+	for i, col := range cols {
+		update[col] = vals[i]
 	}
-	if val.Price != 0 {
-		update["price"] = val.Price
+	if val.Name.Set {
+		update["name_prefixes"] = model.Prefixes(val.Name.Get())
 	}
-	if len(update) == 0 {
-		return model.ErrorNoUpdateParams
+
+	// Matching the version in the filter makes the update a compare-and-set
+	ret, err := s.change(ctx, model.OperationUpdate, versionFilter(val.ID, val.Version),
+		bson.M{"$set": update, "$inc": bson.M{"version": 1}})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model.Product{}, s.versionError(ctx, val.ID, val.Version, model.ErrorNoRowsUpdated)
 	}
+	return ret, err
+}
 
-	res, err := s.c.UpdateOne(ctx, bson.M{"id": val.ID}, bson.M{"$set": update})
+func (s *Service) Delete(ctx context.Context, id string, version uint64) (ret model.Product, err error) {
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		ret, err = s.softDelete(ctx, id, version)
+		return err
+	})
+	return ret, err
+}
+
+func (s *Service) softDelete(ctx context.Context, id string, version uint64) (model.Product, error) {
+	ret, err := s.change(ctx, model.OperationDelete, versionFilter(id, version),
+		bson.M{"$set": bson.M{"deleted_at": uint32(time.Now().Unix())}, "$inc": bson.M{"version": 1}})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model.Product{}, s.versionError(ctx, id, version, model.ErrorNoRowsDeleted)
+	}
+	return ret, err
+}
+
+func (s *Service) change(ctx context.Context, op model.Operation, filter bson.M, update bson.M) (model.Product, error) {
+	var val model.Product
+	err := s.c.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).
+		Decode(&val)
 	if err != nil {
+		return model.Product{}, err
+	}
+	return val, s.record(ctx, op, val)
+}
+
+func (s *Service) record(ctx context.Context, op model.Operation, val model.Product) error {
+	_, err := s.history.InsertOne(ctx, model.NewHistoryEntry(ctx, op, val))
+	return err
+}
+
+func (s *Service) AddBatch(ctx context.Context, vals []model.Product) ([]model.BatchResult, error) {
+	return batch(ctx, s, vals, func(ctx context.Context, val model.Product) (*model.Product, error) {
+		// A duplicate key error aborts the transaction, so the id is checked beforehand
+		if val.ID != "" {
+			cnt, err := s.c.CountDocuments(ctx, bson.M{"id": val.ID})
+			if err != nil {
+				return nil, err
+			}
+			if cnt > 0 {
+				return nil, model.ErrorAlreadyExists
+			}
+		}
+		prod, err := s.add(ctx, val)
+		return &prod, err
+	})
+}
+
+func (s *Service) UpdateBatch(ctx context.Context, vals []model.ProductUpdate) ([]model.BatchResult, error) {
+	return batch(ctx, s, vals, func(ctx context.Context, val model.ProductUpdate) (*model.Product, error) {
+		prod, err := s.update(ctx, val)
+		return &prod, err
+	})
+}
+
+func (s *Service) DeleteBatch(ctx context.Context, vals []model.Product) ([]model.BatchResult, error) {
+	return batch(ctx, s, vals, func(ctx context.Context, val model.Product) (*model.Product, error) {
+		prod, err := s.softDelete(ctx, val.ID, val.Version)
+		return &prod, err
+	})
+}
+
+func batch[T model.BatchItem](ctx context.Context, s *Service, vals []T,
+	write func(ctx context.Context, val T) (*model.Product, error)) (ret []model.BatchResult, err error) {
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		ret, err = model.RunBatch(vals, func(val T) (*model.Product, error) {
+			return write(ctx, val)
+		})
 		return err
+	})
+	return ret, err
+}
+
+// inTx runs the writes in a transaction when the deployment supports them,
+// the write may be retried and has to use the passed context
+func (s *Service) inTx(ctx context.Context, write func(ctx context.Context) error) error {
+	if !s.transactions {
+		return write(ctx)
 	}
-	if res.MatchedCount == 0 {
-		return model.ErrorNoRowsUpdated
+	session, err := s.db.StartSession()
+	if err != nil {
+		return err
 	}
-	return nil
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(ctx context.Context) (any, error) {
+		return nil, write(ctx)
+	})
+	return err
 }
 
-func (s *Service) Delete(ctx context.Context, id string) error {
-	res, err := s.c.DeleteOne(ctx, bson.M{"id": id})
+func (s *Service) Restore(ctx context.Context, id string) (ret model.Product, err error) {
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		ret, err = s.change(ctx, model.OperationRestore, bson.M{"id": id, "deleted_at": bson.M{"$ne": 0}},
+			bson.M{"$set": bson.M{"deleted_at": 0}, "$inc": bson.M{"version": 1}})
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return model.ErrorNoRowsRestored
+		}
+		return err
+	})
+	return ret, err
+}
+
+func (s *Service) Purge(ctx context.Context, before uint32) (cnt int64, err error) {
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		filter := bson.M{"deleted_at": bson.M{"$ne": 0, "$lte": before}}
+		cursor, err := s.c.Find(ctx, filter, options.Find().SetProjection(bson.M{"id": 1}))
+		if err != nil {
+			return err
+		}
+		var purged []model.Product
+		if err = cursor.All(ctx, &purged); err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(purged))
+		for _, val := range purged {
+			ids = append(ids, val.ID)
+		}
+		if _, err = s.history.DeleteMany(ctx, bson.M{"product_id": bson.M{"$in": ids}}); err != nil {
+			return err
+		}
+		// The products soft deleted meanwhile keep their history
+		filter["id"] = bson.M{"$in": ids}
+		res, err := s.c.DeleteMany(ctx, filter)
+		if err != nil {
+			return err
+		}
+		cnt = res.DeletedCount
+		return nil
+	})
+	return cnt, err
+}
+
+func (s *Service) History(ctx context.Context, id string) ([]model.HistoryEntry, error) {
+	cursor, err := s.history.Find(ctx, bson.M{"product_id": id},
+		options.Find().SetSort(bson.D{{Key: "version", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var ret []model.HistoryEntry
+	if err = cursor.All(ctx, &ret); err != nil {
+		return nil, err
+	}
+	return model.LinkHistory(ret), nil
+}
+
+// The documents stored before the soft delete have no deleted_at field
+func versionFilter(id string, version uint64) bson.M {
+	ret := bson.M{"id": id, "deleted_at": bson.M{"$in": bson.A{0, nil}}}
+	if version != 0 {
+		ret["version"] = version
+	}
+	return ret
+}
+
+// versionError tells a version conflict from a missing product
+func (s *Service) versionError(ctx context.Context, id string, version uint64, notFound error) error {
+	if version == 0 {
+		return notFound
+	}
+	cnt, err := s.c.CountDocuments(ctx, versionFilter(id, 0))
 	if err != nil {
 		return err
 	}
-	if res.DeletedCount == 0 {
-		return model.ErrorNoRowsDeleted
+	if cnt == 0 {
+		return notFound
 	}
-	return nil
+	return model.ErrorVersionConflict
 }
 
 func (s *Service) Get(ctx context.Context, id string) (model.Product, error) {
 	var result model.Product
-	err := s.c.FindOne(ctx, bson.M{"id": id}).Decode(&result)
+	err := s.c.FindOne(ctx, versionFilter(id, 0)).Decode(&result)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return model.Product{}, sql.ErrNoRows
+			return model.Product{}, model.ErrorNotFound
 		}
 		return model.Product{}, err
 	}
 	return result, nil
 }
 
-func (s *Service) GetAll(ctx context.Context) ([]model.Product, error) {
-	cursor, err := s.c.Find(ctx, bson.M{})
+func (s *Service) GetMany(ctx context.Context, ids []string) ([]model.Product, error) {
+	if len(ids) == 0 {
+		return []model.Product{}, nil
+	}
+	cursor, err := s.c.Find(ctx, bson.M{"id": bson.M{"$in": ids}, "deleted_at": bson.M{"$in": bson.A{0, nil}}})
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
+	ret := []model.Product{}
+	if err = cursor.All(ctx, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (s *Service) GetAll(ctx context.Context, query model.ProductQuery, page model.PageRequest) (model.ProductPage, error) {
+	size, after, err := page.Prepare(&query)
+	if err != nil {
+		return model.ProductPage{}, err
+	}
+	dir := 1
+	if query.Sort.Desc {
+		dir = -1
+	}
+	sort := bson.D{{Key: "id", Value: dir}}
+	if query.Sort.Field != model.SortByID {
+		sort = append(bson.D{{Key: string(query.Sort.Field), Value: dir}}, sort...)
+	}
+	cursor, err := s.c.Find(ctx, filter(query, after), options.Find().SetSort(sort).SetLimit(int64(size+1)))
+	if err != nil {
+		return model.ProductPage{}, err
+	}
+	defer cursor.Close(ctx)
 
 	var results []model.Product
 	for cursor.Next(ctx) {
 		var p model.Product
 		if err = cursor.Decode(&p); err != nil {
-			return nil, err
+			return model.ProductPage{}, err
 		}
 		results = append(results, p)
 	}
-	return results, nil
+	if err = cursor.Err(); err != nil {
+		return model.ProductPage{}, err
+	}
+	return model.NewProductPage(results, size, query.Sort), nil
+}
+
+// The text index matches any of the terms, $all filters out the rest
+func (s *Service) Search(ctx context.Context, query model.SearchQuery) ([]model.Product, error) {
+	filter := bson.M{
+		"$text":         bson.M{"$search": strings.Join(query.Terms, " ")},
+		"name_prefixes": bson.M{"$all": query.Terms},
+		"deleted_at":    bson.M{"$in": bson.A{0, nil}},
+	}
+	sort := bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "id", Value: 1}}
+	cursor, err := s.c.Find(ctx, filter, options.Find().SetSort(sort).SetLimit(int64(query.Limit)).
+		SetProjection(bson.M{"name_prefixes": 0}))
+	if err != nil {
+		return nil, err
+	}
+	ret := []model.Product{}
+	if err = cursor.All(ctx, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func filter(query model.ProductQuery, after *model.Product) bson.M {
+	ret := bson.M{}
+	f := query.Filter
+	if !f.IncludeDeleted {
+		ret["deleted_at"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	if f.NameContains != "" {
+		ret["name"] = bson.M{"$regex": regexp.QuoteMeta(f.NameContains), "$options": "i"}
+	}
+	price := bson.M{}
+	if f.MinPrice != nil {
+		price["$gte"] = *f.MinPrice
+	}
+	if f.MaxPrice != nil {
+		price["$lte"] = *f.MaxPrice
+	}
+	if len(price) > 0 {
+		ret["price"] = price
+	}
+	created := bson.M{}
+	if f.CreatedFrom != nil {
+		created["$gte"] = *f.CreatedFrom
+	}
+	if f.CreatedTo != nil {
+		created["$lte"] = *f.CreatedTo
+	}
+	if len(created) > 0 {
+		ret["created_at"] = created
+	}
+
+	if after != nil {
+		op := "$gt"
+		if query.Sort.Desc {
+			op = "$lt"
+		}
+		if query.Sort.Field == model.SortByID {
+			ret["id"] = bson.M{op: after.ID}
+		} else {
+			field, key := string(query.Sort.Field), query.Sort.Key(*after)
+			ret["$or"] = bson.A{
+				bson.M{field: bson.M{op: key}},
+				bson.M{field: key, "id": bson.M{op: after.ID}},
+			}
+		}
+	}
+	return ret
 }
@@ -0,0 +1,91 @@
+package mux
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aleksandrzhukovskii/go-template/internal/model"
+	"github.com/aleksandrzhukovskii/go-template/internal/service/graphql"
+)
+
+// Mux routes every request of the shared listener to gRPC, GraphQL or the REST server
+type Mux struct {
+	name     string
+	server   *http.Server
+	lis      net.Listener
+	handlers []model.Handler
+	grpc     http.Handler
+	graphql  http.Handler
+	rest     http.Handler
+}
+
+// New returns the multiplexer of the handlers, only one of them can be a REST server
+func New(lis net.Listener, handlers map[string]model.Handler) (model.Server, error) {
+	types := slices.Sorted(maps.Keys(handlers))
+	ret := &Mux{
+		name: strings.Join(types, "+"),
+		lis:  lis,
+	}
+	var rest string
+	for _, typ := range types {
+		ret.handlers = append(ret.handlers, handlers[typ])
+		switch typ {
+		case "grpc":
+			ret.grpc = handlers[typ].Handler()
+		case "graphql":
+			ret.graphql = handlers[typ].Handler()
+		default:
+			if ret.rest != nil {
+				return nil, fmt.Errorf("only one REST server can share the listener, got %s and %s", rest, typ)
+			}
+			ret.rest, rest = handlers[typ].Handler(), typ
+		}
+	}
+
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	ret.server = &http.Server{
+		Handler:   ret,
+		Protocols: protocols,
+	}
+	return ret, nil
+}
+
+func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case m.grpc != nil && r.ProtoMajor == 2 && isGRPC(r.Header.Get("Content-Type")):
+		m.grpc.ServeHTTP(w, r)
+	case m.graphql != nil && slices.Contains(graphql.Paths, r.URL.Path):
+		m.graphql.ServeHTTP(w, r)
+	case m.rest != nil:
+		m.rest.ServeHTTP(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func isGRPC(contentType string) bool {
+	return contentType == "application/grpc" || strings.HasPrefix(contentType, "application/grpc+") ||
+		strings.HasPrefix(contentType, "application/grpc;")
+}
+
+func (m *Mux) Start(ctx context.Context) error {
+	return model.Serve(ctx, m.name, func() error {
+		return m.server.Serve(m.lis)
+	}, func() error {
+		// The streams would hold the shutdown until the timeout
+		for _, handler := range m.handlers {
+			handler.Stop()
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		return m.server.Shutdown(ctx)
+	})
+}
@@ -2,15 +2,15 @@ package net_http
 
 import (
 	"bytes"
-	"database/sql"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
+	"github.com/aleksandrzhukovskii/go-template/internal/service/content"
 )
 
 func (s *Service) Main(w http.ResponseWriter, r *http.Request) {
@@ -19,7 +19,7 @@ func (s *Service) Main(w http.ResponseWriter, r *http.Request) {
 	if r.Body != nil {
 		body, err = io.ReadAll(r.Body)
 		if err != nil {
-			s.sendError(w, http.StatusInternalServerError, err)
+			s.sendError(w, r, err)
 			return
 		}
 	}
@@ -45,88 +45,306 @@ func (s *Service) Main(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Service) AddProduct(w http.ResponseWriter, r *http.Request) {
-	id, err := s.db.Add(r.Context())
+	val, err := s.addProduct(r)
 	if err != nil {
-		s.sendError(w, http.StatusInternalServerError, err)
+		s.sendError(w, r, err)
 		return
 	}
-	s.sendJson(w, &model.Product{ID: id})
+	s.sendJson(w, r, val)
 }
 
+func (s *Service) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	val, err := s.addProduct(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", model.ProductLocation(val.ID))
+	s.sendJsonStatus(w, r, http.StatusCreated, val)
+}
+
+func (s *Service) addProduct(r *http.Request) (model.Product, error) {
+	prod := model.SampleProduct()
+	if r.FormValue("sample") != "true" {
+		var err error
+		prod, err = model.ParseNewProduct(r.FormValue("id"), r.FormValue("name"), r.FormValue("price"),
+			r.FormValue("currency"))
+		if err != nil {
+			return model.Product{}, err
+		}
+	}
+	return s.db.Add(r.Context(), prod)
+}
+
+// UpdateProduct reads the body by its Content-Type and answers by the Accept header
 func (s *Service) UpdateProduct(w http.ResponseWriter, r *http.Request) {
-	prod, err := model.ParseProduct(r.FormValue("id"), r.FormValue("name"), r.FormValue("price"))
+	respFormat, err := content.ResponseFormat(r.Header.Get("Accept"))
 	if err != nil {
-		s.sendError(w, http.StatusBadRequest, err)
+		s.sendError(w, r, err)
 		return
 	}
-	err = s.db.Update(r.Context(), prod)
+	reqFormat, err := content.RequestFormat(r.Header.Get("Content-Type"))
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, model.ErrorNoRowsUpdated) {
-			status = http.StatusBadRequest
-		}
-		s.sendError(w, status, err)
+		s.sendError(w, r, err)
+		return
+	}
+	var prod model.ProductUpdate
+	switch reqFormat {
+	case content.Form:
+		prod, err = model.ParseUpdate(r.FormValue("id"), r.FormValue("name"), r.FormValue("price"),
+			r.FormValue("currency"))
+	case content.MergePatch:
+		prod, err = model.ParseMergePatch(r.FormValue("id"), r.Body)
+	default:
+		prod, err = content.DecodeUpdate(reqFormat, r.Body)
+	}
+	if err == nil && (reqFormat == content.Form || reqFormat == content.MergePatch) {
+		prod.Version, err = model.ParseVersion(r.FormValue("version"))
+	}
+	if err == nil {
+		prod.Version, err = model.CheckIfMatch(r.Context(), s.db, prod.ID, prod.Version, r.Header.Get(model.IfMatchHeader))
+	}
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	_, err = s.db.Update(r.Context(), prod)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	b, err := content.EncodeUpdateResponse(respFormat, "Product updated")
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.Header().Set(ct, string(respFormat))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(b)
+}
+
+func (s *Service) UpsertProduct(w http.ResponseWriter, r *http.Request) {
+	prod, err := model.ParseNewProduct(r.PathValue("id"), r.FormValue("name"), r.FormValue("price"),
+		r.FormValue("currency"))
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	val, created, err := s.db.Upsert(r.Context(), prod)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	s.sendJsonStatus(w, r, status, val)
+}
+
+func (s *Service) PatchProductByID(w http.ResponseWriter, r *http.Request) {
+	prod, err := model.ParseMergePatch(r.PathValue("id"), r.Body)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	if prod.Version, err = model.ParseVersion(r.FormValue("version")); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	prod.Version, err = model.CheckIfMatch(r.Context(), s.db, prod.ID, prod.Version, r.Header.Get(model.IfMatchHeader))
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	val, err := s.db.Update(r.Context(), prod)
+	if err != nil {
+		s.sendError(w, r, err)
 		return
 	}
-	s.sendMessage(w, "Product updated")
+	s.sendJson(w, r, val)
 }
 
 func (s *Service) DeleteProduct(w http.ResponseWriter, r *http.Request) {
-	id := r.FormValue("id")
-	if err := s.db.Delete(r.Context(), id); err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, model.ErrorNoRowsDeleted) {
-			status = http.StatusBadRequest
-		}
-		s.sendError(w, status, err)
+	if err := s.deleteProduct(r, r.FormValue("id")); err != nil {
+		s.sendError(w, r, err)
 		return
 	}
 	s.sendMessage(w, "Product deleted")
 }
 
+func (s *Service) DeleteProductByID(w http.ResponseWriter, r *http.Request) {
+	if err := s.deleteProduct(r, r.PathValue("id")); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Service) deleteProduct(r *http.Request, id string) error {
+	version, err := model.ParseVersion(r.FormValue("version"))
+	if err != nil {
+		return err
+	}
+	if version, err = model.CheckIfMatch(r.Context(), s.db, id, version, r.Header.Get(model.IfMatchHeader)); err != nil {
+		return err
+	}
+	_, err = s.db.Delete(r.Context(), id, version)
+	return err
+}
+
+func (s *Service) AddProducts(w http.ResponseWriter, r *http.Request) {
+	batch(s, w, r, s.db.AddBatch)
+}
+
+func (s *Service) UpdateProducts(w http.ResponseWriter, r *http.Request) {
+	batch(s, w, r, s.db.UpdateBatch)
+}
+
+func (s *Service) DeleteProducts(w http.ResponseWriter, r *http.Request) {
+	batch(s, w, r, s.db.DeleteBatch)
+}
+
+func batch[T model.BatchItem](s *Service, w http.ResponseWriter, r *http.Request,
+	run func(ctx context.Context, vals []T) ([]model.BatchResult, error)) {
+	vals, err := model.ParseBatch[T](r.Body)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	val, err := run(r.Context(), vals)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJson(w, r, model.BatchResponse{Results: val})
+}
+
+func (s *Service) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.db.Restore(r.Context(), r.FormValue("id")); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendMessage(w, "Product restored")
+}
+
+func (s *Service) PurgeProducts(w http.ResponseWriter, r *http.Request) {
+	days, err := model.ParsePurgeDays(r.FormValue("days"))
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	cnt, err := s.db.Purge(r.Context(), model.PurgeBefore(days))
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJson(w, r, model.PurgeResult{Purged: cnt})
+}
+
+// GetProduct serves both the resource route and the legacy one
 func (s *Service) GetProduct(w http.ResponseWriter, r *http.Request) {
-	id := r.FormValue("id")
-	val, err := s.db.Get(r.Context(), id)
+	id := r.PathValue("id")
+	if id == "" {
+		id = r.FormValue("id")
+	}
+	asOf, err := model.ParseAsOf(r.FormValue("as_of"))
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, sql.ErrNoRows) {
-			status = http.StatusBadRequest
-		}
-		s.sendError(w, status, err)
+		s.sendError(w, r, err)
+		return
+	}
+	val, err := model.GetAsOf(r.Context(), s.db, id, asOf)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendTagged(w, r, val)
+}
+
+func (s *Service) GetProductHistory(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.db.History(r.Context(), r.FormValue("id"))
+	if err != nil {
+		s.sendError(w, r, err)
 		return
 	}
-	s.sendJson(w, val)
+	s.sendJson(w, r, model.HistoryResponse{Entries: entries})
 }
 
 func (s *Service) GetProducts(w http.ResponseWriter, r *http.Request) {
-	val, err := s.db.GetAll(r.Context())
+	query, err := model.ParseProductQuery(r.FormValue("name"), r.FormValue("min_price"), r.FormValue("max_price"),
+		r.FormValue("created_from"), r.FormValue("created_to"), r.FormValue("include_deleted"), r.FormValue("sort"))
 	if err != nil {
-		s.sendError(w, http.StatusInternalServerError, err)
+		s.sendError(w, r, err)
 		return
 	}
-	s.sendJson(w, val)
+	page, err := model.ParsePageRequest(r.FormValue("page_size"), r.FormValue("cursor"))
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	val, err := s.db.GetAll(r.Context(), query, page)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendTagged(w, r, val)
 }
 
-func (s *Service) sendError(w http.ResponseWriter, status int, err error) {
-	w.WriteHeader(status)
-	w.Header().Set(ct, ctJSON)
-	_, _ = w.Write([]byte(fmt.Sprintf(`{"error":%s}`, strconv.Quote(err.Error()))))
+func (s *Service) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	query, err := model.ParseSearchQuery(r.FormValue("q"), r.FormValue("limit"))
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	val, err := s.db.Search(r.Context(), query)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJson(w, r, model.SearchResponse{Items: val})
+}
+
+func (s *Service) sendError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := model.NewProblem(r.Context(), err, r.URL.RequestURI())
+	b, _ := json.Marshal(problem)
+	w.Header().Set(ct, model.ProblemContentType)
+	w.WriteHeader(problem.Status)
+	_, _ = w.Write(b)
 }
 
 func (s *Service) sendMessage(w http.ResponseWriter, msg string) {
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set(ct, ctJSON)
+	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte(`{"msg":` + strconv.Quote(msg) + `}`))
 }
 
-func (s *Service) sendJson(w http.ResponseWriter, val any) {
+func (s *Service) sendJson(w http.ResponseWriter, r *http.Request, val any) {
+	s.sendJsonStatus(w, r, http.StatusOK, val)
+}
+
+// sendTagged answers 304 when the If-None-Match header matches the tag
+func (s *Service) sendTagged(w http.ResponseWriter, r *http.Request, val any) {
+	etag, err := model.ETag(val)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.Header().Set(model.ETagHeader, etag)
+	if model.NoneMatch(r.Header.Get(model.IfNoneMatchHeader), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.sendJson(w, r, val)
+}
+
+func (s *Service) sendJsonStatus(w http.ResponseWriter, r *http.Request, status int, val any) {
 	b, err := json.Marshal(val)
 	if err != nil {
-		s.sendError(w, http.StatusInternalServerError, err)
+		s.sendError(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set(ct, ctJSON)
+	w.WriteHeader(status)
 	_, _ = w.Write(b)
 }
 
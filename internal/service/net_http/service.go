@@ -2,14 +2,12 @@ package net_http
 
 import (
 	"context"
-	"errors"
 	"net"
 	"net/http"
 	"time"
 
-	"github.com/rs/zerolog/log"
-
 	"github.com/aleksandrzhukovskii/go-template/api"
+	"github.com/aleksandrzhukovskii/go-template/internal/config"
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
 	"github.com/aleksandrzhukovskii/go-template/web/swagger"
 )
@@ -18,42 +16,64 @@ type Service struct {
 	server *http.Server
 	db     model.DB
 	lis    net.Listener
+	feeds  *model.Feeds
 }
 
-func New(db model.DB, lis net.Listener) (model.Server, error) {
+func New(cfg config.Config, db model.DB, lis net.Listener) (model.Server, error) {
 	mux := http.NewServeMux()
 	ret := &Service{
-		db:  db,
-		lis: lis,
+		db:    db,
+		lis:   lis,
+		feeds: model.NewFeeds(db, cfg.Events.Heartbeat),
 	}
 	ret.server = &http.Server{
-		Handler: mux,
+		Handler: model.RequestContext(mux),
 	}
 	mux.HandleFunc("/", ret.Main)
-	mux.HandleFunc("/add", ret.AddProduct)
-	mux.HandleFunc("/update", ret.UpdateProduct)
-	mux.HandleFunc("/delete", ret.DeleteProduct)
-	mux.HandleFunc("/get", ret.GetProduct)
-	mux.HandleFunc("/get_all", ret.GetProducts)
-	mux.HandleFunc("/swagger.yaml", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
+	mux.HandleFunc("POST /add", ret.AddProduct)
+	mux.HandleFunc("PUT /update", ret.UpdateProduct)
+	mux.HandleFunc("PATCH /update", ret.UpdateProduct)
+	mux.HandleFunc("DELETE /delete", ret.DeleteProduct)
+	mux.HandleFunc("POST /batch", ret.AddProducts)
+	mux.HandleFunc("PUT /batch", ret.UpdateProducts)
+	mux.HandleFunc("DELETE /batch", ret.DeleteProducts)
+	mux.HandleFunc("POST /restore", ret.RestoreProduct)
+	mux.HandleFunc("DELETE /purge", ret.PurgeProducts)
+	mux.HandleFunc("GET /get", ret.GetProduct)
+	mux.HandleFunc("GET /history", ret.GetProductHistory)
+	mux.HandleFunc("GET /get_all", ret.GetProducts)
+	mux.HandleFunc("GET /search", ret.SearchProducts)
+	mux.HandleFunc("POST /products", ret.CreateProduct)
+	mux.HandleFunc("GET /products", ret.GetProducts)
+	mux.Handle("GET /products/events", ret.feeds)
+	mux.HandleFunc("GET /products/{id}", ret.GetProduct)
+	mux.HandleFunc("PUT /products/{id}", ret.UpsertProduct)
+	mux.HandleFunc("PATCH /products/{id}", ret.PatchProductByID)
+	mux.HandleFunc("DELETE /products/{id}", ret.DeleteProductByID)
+	mux.HandleFunc("GET /swagger.yaml", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/yaml")
+		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write(api.SwaggerConfig)
 	})
 	mux.Handle("/swagger/", http.StripPrefix("/swagger/", http.FileServer(http.FS(swagger.Swagger))))
 	return ret, nil
 }
 
+func (s *Service) Handler() http.Handler {
+	return s.server.Handler
+}
+
+func (s *Service) Stop() {
+	s.feeds.Stop()
+}
+
 func (s *Service) Start(ctx context.Context) error {
-	log.Info().Msg("starting net/http server")
-	go func() {
-		if err := s.server.Serve(s.lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatal().Err(err).Msg("failed to start net/http server")
-		}
-	}()
-	<-ctx.Done()
-	log.Info().Msg("gracefully shutting down net/http server")
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
-	return s.server.Shutdown(ctx)
+	return model.Serve(ctx, "net/http", func() error {
+		return s.server.Serve(s.lis)
+	}, func() error {
+		s.Stop()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		return s.server.Shutdown(ctx)
+	})
 }
@@ -3,11 +3,11 @@ package postgres
 import (
 	"context"
 	"database/sql"
-	"math/rand/v2"
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
-	"github.com/google/uuid"
-	"github.com/jaswdr/faker/v2"
 	_ "github.com/lib/pq"
 
 	"github.com/aleksandrzhukovskii/go-template/internal/config"
@@ -25,6 +25,10 @@ func New(cfg config.Config) (model.DB, error) {
 	}, nil
 }
 
+func (s *Service) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
 func (s *Service) Start() error {
 	db, err := sql.Open("postgres", s.dns)
 	if err != nil {
@@ -44,78 +48,406 @@ func (s *Service) migrate() error {
 	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS products (
 			id TEXT PRIMARY KEY,
 			name TEXT,
-			price DOUBLE PRECISION,
+			price NUMERIC(18, 4),
 			created_at BIGINT
 		);`)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("ALTER TABLE products ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1")
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at BIGINT NOT NULL DEFAULT 0")
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("ALTER TABLE products ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD'")
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`CREATE TABLE IF NOT EXISTS product_history (
+			product_id TEXT NOT NULL,
+			version BIGINT NOT NULL,
+			operation TEXT NOT NULL,
+			changed_at BIGINT NOT NULL,
+			actor TEXT NOT NULL,
+			name TEXT,
+			price NUMERIC(18, 4),
+			currency TEXT NOT NULL DEFAULT 'USD',
+			created_at BIGINT,
+			deleted_at BIGINT NOT NULL,
+			PRIMARY KEY (product_id, version)
+		);`)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("ALTER TABLE product_history ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD'")
+	if err != nil {
+		return err
+	}
+	// Tables created when the prices were floats, checked to spare the lock of the ALTER
+	for _, table := range []string{model.TableName, model.HistoryTableName} {
+		var typ string
+		err = s.db.QueryRow("SELECT data_type FROM information_schema.columns "+
+			"WHERE table_schema = current_schema() AND table_name = $1 AND column_name = 'price'", table).Scan(&typ)
+		if err != nil {
+			return err
+		}
+		if typ == "numeric" {
+			continue
+		}
+		if _, err = s.db.Exec("ALTER TABLE " + table + " ALTER COLUMN price TYPE NUMERIC(18, 4)"); err != nil {
+			return err
+		}
+	}
+	// The simple configuration doesn't stem the words
+	_, err = s.db.Exec("CREATE INDEX IF NOT EXISTS products_name_search ON products " +
+		"USING GIN (to_tsvector('simple', name))")
 	return err
 }
 
-func (s *Service) Add(ctx context.Context) (string, error) {
-	f := faker.NewWithSeed(rand.NewPCG(uint64(time.Now().Unix()), uint64(time.Now().UnixNano())))
-	id := uuid.NewString()
-	_, err := s.db.ExecContext(ctx, "INSERT INTO products(id, name, price, created_at) values ($1,$2,$3,$4)",
-		id, f.Food().Fruit(), f.Float64(2, 1, 100), uint32(time.Now().Unix()))
-	return id, err
+func (s *Service) Add(ctx context.Context, val model.Product) (ret model.Product, err error) {
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		ret, err = add(ctx, tx, val)
+		return err
+	})
+	return ret, err
+}
+
+func add(ctx context.Context, tx *sql.Tx, val model.Product) (model.Product, error) {
+	val, err := model.PrepareProduct(val)
+	if err != nil {
+		return model.Product{}, err
+	}
+	// A failed statement aborts the whole transaction in postgres, so the conflict is skipped
+	res, err := tx.ExecContext(ctx, "INSERT INTO products(id, name, price, currency, created_at, version) "+
+		"values ($1,$2,$3,$4,$5,$6) ON CONFLICT (id) DO NOTHING", val.ID, val.Name, val.Price, val.Currency,
+		val.CreatedAt, val.Version)
+	if err != nil {
+		return model.Product{}, err
+	}
+	if cnt, _ := res.RowsAffected(); cnt == 0 {
+		return model.Product{}, model.ErrorAlreadyExists
+	}
+	return val, record(ctx, tx, model.NewHistoryEntry(ctx, model.OperationAdd, val))
+}
+
+func (s *Service) Upsert(ctx context.Context, val model.Product) (ret model.Product, created bool, err error) {
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		ret, created, err = upsert(ctx, tx, val)
+		return err
+	})
+	return ret, created, err
 }
 
-func (s *Service) Update(ctx context.Context, val model.Product) error {
-	var res sql.Result
-	var err error
-	if val.Name != "" && val.Price != 0 {
-		res, err = s.db.ExecContext(ctx, "UPDATE products SET name=$1, price=$2 WHERE id=$3", val.Name, val.Price, val.ID)
-	} else if val.Price != 0 {
-		res, err = s.db.ExecContext(ctx, "UPDATE products SET price=$1 WHERE id=$2", val.Price, val.ID)
-	} else if val.Name != "" {
-		res, err = s.db.ExecContext(ctx, "UPDATE products SET name=$1 WHERE id=$2", val.Name, val.ID)
-	} else {
-		return model.ErrorNoUpdateParams
+// upsert returns no row for the unchanged product
+func upsert(ctx context.Context, tx *sql.Tx, val model.Product) (model.Product, bool, error) {
+	val, err := model.PrepareUpsert(val)
+	if err != nil {
+		return model.Product{}, false, err
+	}
+	var ret model.Product
+	err = tx.QueryRowContext(ctx, "INSERT INTO products(id, name, price, currency, created_at, version) "+
+		"values ($1,$2,$3,$4,$5,$6) ON CONFLICT (id) DO UPDATE SET name=excluded.name, price=excluded.price, "+
+		"currency=excluded.currency, deleted_at=0, version=products.version+1 "+
+		"WHERE products.name IS DISTINCT FROM excluded.name OR products.price<>excluded.price OR "+
+		"products.currency<>excluded.currency OR products.deleted_at<>0 RETURNING *",
+		val.ID, val.Name, val.Price, val.Currency, val.CreatedAt, val.Version).
+		Scan(&ret.ID, &ret.Name, &ret.Price, &ret.CreatedAt, &ret.Version, &ret.DeletedAt, &ret.Currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		ret, err = stored(ctx, tx, val.ID)
+		return ret, false, err
+	}
+	if err != nil {
+		return model.Product{}, false, err
 	}
+	op := model.OperationUpdate
+	if ret.Version == 1 {
+		op = model.OperationAdd
+	}
+	return ret, op == model.OperationAdd, record(ctx, tx, model.NewHistoryEntry(ctx, op, ret))
+}
+
+func (s *Service) Update(ctx context.Context, val model.ProductUpdate) (ret model.Product, err error) {
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		ret, err = update(ctx, tx, val)
+		return err
+	})
+	return ret, err
+}
 
+func update(ctx context.Context, tx *sql.Tx, val model.ProductUpdate) (model.Product, error) {
+	if err := val.Validate(); err != nil {
+		return model.Product{}, err
+	}
+	set, args := val.SQL(dialect)
+	n := len(args)
+	res, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE products SET %s, version=version+1 "+
+		"WHERE id=$%d AND deleted_at=0 AND (version=$%d OR $%d=0)", set, n+1, n+2, n+2),
+		append(args, val.ID, val.Version)...)
 	if err != nil {
+		return model.Product{}, err
+	}
+	if cnt, _ := res.RowsAffected(); cnt == 0 {
+		return model.Product{}, versionError(ctx, tx, val.ID, val.Version, model.ErrorNoRowsUpdated)
+	}
+	return recordChange(ctx, tx, model.OperationUpdate, val.ID)
+}
+
+func (s *Service) Delete(ctx context.Context, id string, version uint64) (ret model.Product, err error) {
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		ret, err = softDelete(ctx, tx, id, version)
 		return err
+	})
+	return ret, err
+}
+
+func softDelete(ctx context.Context, tx *sql.Tx, id string, version uint64) (model.Product, error) {
+	res, err := tx.ExecContext(ctx, "UPDATE products SET deleted_at=$1, version=version+1 "+
+		"WHERE id=$2 AND deleted_at=0 AND (version=$3 OR $3=0)", time.Now().Unix(), id, version)
+	if err != nil {
+		return model.Product{}, err
 	}
 	if cnt, _ := res.RowsAffected(); cnt == 0 {
-		return model.ErrorNoRowsUpdated
+		return model.Product{}, versionError(ctx, tx, id, version, model.ErrorNoRowsDeleted)
 	}
-	return nil
+	return recordChange(ctx, tx, model.OperationDelete, id)
+}
+
+func (s *Service) AddBatch(ctx context.Context, vals []model.Product) ([]model.BatchResult, error) {
+	return batch(ctx, s, vals, func(tx *sql.Tx, val model.Product) (*model.Product, error) {
+		prod, err := add(ctx, tx, val)
+		return &prod, err
+	})
+}
+
+func (s *Service) UpdateBatch(ctx context.Context, vals []model.ProductUpdate) ([]model.BatchResult, error) {
+	return batch(ctx, s, vals, func(tx *sql.Tx, val model.ProductUpdate) (*model.Product, error) {
+		prod, err := update(ctx, tx, val)
+		return &prod, err
+	})
+}
+
+func (s *Service) DeleteBatch(ctx context.Context, vals []model.Product) ([]model.BatchResult, error) {
+	return batch(ctx, s, vals, func(tx *sql.Tx, val model.Product) (*model.Product, error) {
+		prod, err := softDelete(ctx, tx, val.ID, val.Version)
+		return &prod, err
+	})
 }
 
-func (s *Service) Delete(ctx context.Context, id string) error {
-	res, err := s.db.ExecContext(ctx, "DELETE FROM products WHERE id=$1", id)
+func batch[T model.BatchItem](ctx context.Context, s *Service, vals []T,
+	write func(tx *sql.Tx, val T) (*model.Product, error)) (ret []model.BatchResult, err error) {
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		ret, err = model.RunBatch(vals, func(val T) (*model.Product, error) {
+			return write(tx, val)
+		})
+		return err
+	})
+	return ret, err
+}
+
+func (s *Service) inTx(ctx context.Context, write func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	if cnt, _ := res.RowsAffected(); cnt == 0 {
-		return model.ErrorNoRowsDeleted
+	if err = write(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Service) Restore(ctx context.Context, id string) (ret model.Product, err error) {
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			"UPDATE products SET deleted_at=0, version=version+1 WHERE id=$1 AND deleted_at<>0", id)
+		if err != nil {
+			return err
+		}
+		if cnt, _ := res.RowsAffected(); cnt == 0 {
+			return model.ErrorNoRowsRestored
+		}
+		ret, err = recordChange(ctx, tx, model.OperationRestore, id)
+		return err
+	})
+	return ret, err
+}
+
+func (s *Service) Purge(ctx context.Context, before uint32) (cnt int64, err error) {
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		_, err = tx.ExecContext(ctx, "DELETE FROM product_history WHERE product_id IN "+
+			"(SELECT id FROM products WHERE deleted_at<>0 AND deleted_at<=$1)", before)
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, "DELETE FROM products WHERE deleted_at<>0 AND deleted_at<=$1", before)
+		if err != nil {
+			return err
+		}
+		cnt, err = res.RowsAffected()
+		return err
+	})
+	return cnt, err
+}
+
+func record(ctx context.Context, tx *sql.Tx, entry model.HistoryEntry) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO product_history("+model.HistoryColumns+") "+
+		"values ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)", entry.Values()...)
+	return err
+}
+
+func recordChange(ctx context.Context, tx *sql.Tx, op model.Operation, id string) (model.Product, error) {
+	val, err := stored(ctx, tx, id)
+	if err != nil {
+		return model.Product{}, err
+	}
+	return val, record(ctx, tx, model.NewHistoryEntry(ctx, op, val))
+}
+
+func stored(ctx context.Context, tx *sql.Tx, id string) (model.Product, error) {
+	var val model.Product
+	err := tx.QueryRowContext(ctx, "SELECT * FROM products WHERE id=$1", id).
+		Scan(&val.ID, &val.Name, &val.Price, &val.CreatedAt, &val.Version, &val.DeletedAt, &val.Currency)
+	return val, err
+}
+
+func (s *Service) History(ctx context.Context, id string) ([]model.HistoryEntry, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+model.HistoryColumns+" FROM product_history "+
+		"WHERE product_id=$1 ORDER BY version", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ret []model.HistoryEntry
+	for rows.Next() {
+		elem, err := model.ScanHistoryEntry(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, elem)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return model.LinkHistory(ret), nil
+}
+
+// versionError tells a version conflict from a missing product
+func versionError(ctx context.Context, tx *sql.Tx, id string, version uint64, notFound error) error {
+	if version == 0 {
+		return notFound
 	}
-	return nil
+	var exists int
+	err := tx.QueryRowContext(ctx, "SELECT 1 FROM products WHERE id=$1 AND deleted_at=0", id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound
+	}
+	if err != nil {
+		return err
+	}
+	return model.ErrorVersionConflict
 }
 
 func (s *Service) Get(ctx context.Context, id string) (model.Product, error) {
-	row := s.db.QueryRowContext(ctx, "SELECT * FROM products WHERE id=$1", id)
+	row := s.db.QueryRowContext(ctx, "SELECT * FROM products WHERE id=$1 AND deleted_at=0", id)
 	if err := row.Err(); err != nil {
 		return model.Product{}, err
 	}
 	var ret model.Product
-	err := row.Scan(&ret.ID, &ret.Name, &ret.Price, &ret.CreatedAt)
+	err := row.Scan(&ret.ID, &ret.Name, &ret.Price, &ret.CreatedAt, &ret.Version, &ret.DeletedAt, &ret.Currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Product{}, model.ErrorNotFound
+	}
 	if err != nil {
 		return model.Product{}, err
 	}
 	return ret, nil
 }
 
-func (s *Service) GetAll(ctx context.Context) ([]model.Product, error) {
-	rows, err := s.db.QueryContext(ctx, "SELECT * FROM products")
+var dialect = model.SQLDialect{
+	Placeholder: func(n int) string {
+		return "$" + strconv.Itoa(n)
+	},
+}
+
+func (s *Service) GetMany(ctx context.Context, ids []string) ([]model.Product, error) {
+	if len(ids) == 0 {
+		return []model.Product{}, nil
+	}
+	in, args := dialect.In("id", ids)
+	rows, err := s.db.QueryContext(ctx, "SELECT * FROM products WHERE "+in+" AND deleted_at=0", args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+	ret := []model.Product{}
+	for rows.Next() {
+		var elem model.Product
+		err = rows.Scan(&elem.ID, &elem.Name, &elem.Price, &elem.CreatedAt, &elem.Version, &elem.DeletedAt,
+			&elem.Currency)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, elem)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (s *Service) GetAll(ctx context.Context, query model.ProductQuery, page model.PageRequest) (model.ProductPage, error) {
+	size, after, err := page.Prepare(&query)
+	if err != nil {
+		return model.ProductPage{}, err
+	}
+	where, order, args := query.SQL(dialect, after)
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT * FROM products WHERE %s ORDER BY %s LIMIT %d",
+		where, order, size+1), args...)
+	if err != nil {
+		return model.ProductPage{}, err
+	}
+	defer rows.Close()
 	var ret []model.Product
 	for rows.Next() {
 		var elem model.Product
-		if err = rows.Scan(&elem.ID, &elem.Name, &elem.Price, &elem.CreatedAt); err != nil {
+		err = rows.Scan(&elem.ID, &elem.Name, &elem.Price, &elem.CreatedAt, &elem.Version, &elem.DeletedAt,
+			&elem.Currency)
+		if err != nil {
+			return model.ProductPage{}, err
+		}
+		ret = append(ret, elem)
+	}
+	if err = rows.Err(); err != nil {
+		return model.ProductPage{}, err
+	}
+	return model.NewProductPage(ret, size, query.Sort), nil
+}
+
+// The rank expression matches the one of the index
+func (s *Service) Search(ctx context.Context, query model.SearchQuery) ([]model.Product, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT * FROM products "+
+		"WHERE to_tsvector('simple', name) @@ to_tsquery('simple', $1) AND deleted_at=0 "+
+		"ORDER BY ts_rank(to_tsvector('simple', name), to_tsquery('simple', $1), 2) DESC, id LIMIT $2",
+		query.TSQuery(), query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ret := []model.Product{}
+	for rows.Next() {
+		var elem model.Product
+		err = rows.Scan(&elem.ID, &elem.Name, &elem.Price, &elem.CreatedAt, &elem.Version, &elem.DeletedAt,
+			&elem.Currency)
+		if err != nil {
 			return nil, err
 		}
 		ret = append(ret, elem)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 	return ret, nil
 }
@@ -3,7 +3,10 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
+	"slices"
+	"sync"
 
 	"github.com/aleksandrzhukovskii/go-template/internal/config"
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
@@ -16,6 +19,7 @@ import (
 	"github.com/aleksandrzhukovskii/go-template/internal/service/in_memory"
 	"github.com/aleksandrzhukovskii/go-template/internal/service/in_memory2"
 	"github.com/aleksandrzhukovskii/go-template/internal/service/mongo"
+	"github.com/aleksandrzhukovskii/go-template/internal/service/mux"
 	"github.com/aleksandrzhukovskii/go-template/internal/service/mysql"
 	"github.com/aleksandrzhukovskii/go-template/internal/service/net_http"
 	"github.com/aleksandrzhukovskii/go-template/internal/service/postgres"
@@ -24,21 +28,23 @@ import (
 )
 
 type Services struct {
-	server model.Server
-	db     model.DB
-	lis    net.Listener
+	cfg     config.Config
+	servers []model.Server
+	db      model.DB
+	lis     []net.Listener
 }
 
 func New(cfg config.Config) (*Services, error) {
 	return newServices(cfg, nil)
 }
 
-func NewWithListener(cfg config.Config, listener net.Listener) (*Services, error) {
-	return newServices(cfg, listener)
+// NewWithListener serves the servers of the config on the given listeners in the same order
+func NewWithListener(cfg config.Config, listeners ...net.Listener) (*Services, error) {
+	return newServices(cfg, listeners)
 }
 
-func newServices(cfg config.Config, lis net.Listener) (*Services, error) {
-	ret := new(Services)
+func newServices(cfg config.Config, listeners []net.Listener) (*Services, error) {
+	ret := &Services{cfg: cfg}
 	var err error
 
 	dbNewFunc, ok := dbNew[cfg.Db]
@@ -46,37 +52,73 @@ func newServices(cfg config.Config, lis net.Listener) (*Services, error) {
 		return nil, errors.New("database driver not found")
 	}
 
-	serverNewFunc, ok := serverNew[cfg.Server]
-	if !ok {
-		return nil, errors.New("server type not found")
+	for _, server := range cfg.Servers {
+		types := server.Types()
+		for i, typ := range types {
+			if _, ok = serverNew[typ]; !ok {
+				return nil, fmt.Errorf("server type %q not found", typ)
+			}
+			if slices.Contains(types[:i], typ) {
+				return nil, fmt.Errorf("server type %q repeats on the listener", typ)
+			}
+		}
 	}
 
-	ret.db, err = dbNewFunc(cfg)
+	db, err := dbNewFunc(cfg)
 	if err != nil {
 		return nil, err
 	}
+	ret.db = model.WithEvents(model.TypedDB(db), model.NewEventBus(model.EventHistory))
 
 	/*if err = migrator.Migrate(cfg); err != nil {
 		return nil, err
 	}*/
 
-	if lis == nil {
-		addr := cfg.HttpGrpc.IP + ":" + cfg.HttpGrpc.Port
-		lis, err = net.Listen("tcp", addr)
+	for i, server := range cfg.Servers {
+		var lis net.Listener
+		if i < len(listeners) {
+			lis = listeners[i]
+		}
+		if lis == nil {
+			lis, err = net.Listen("tcp", server.Addr())
+			if err != nil {
+				ret.close()
+				return nil, err
+			}
+		}
+		ret.lis = append(ret.lis, lis)
+
+		val, err := ret.newServer(server.Types(), lis)
 		if err != nil {
+			ret.close()
 			return nil, err
 		}
-	}
-	ret.lis = lis
-
-	ret.server, err = serverNewFunc(ret.db, lis)
-	if err != nil {
-		return nil, err
+		ret.servers = append(ret.servers, val)
 	}
 
 	return ret, nil
 }
 
+// newServer shares the listener through the multiplexer when there are several types
+func (s *Services) newServer(types []string, lis net.Listener) (model.Server, error) {
+	if len(types) == 1 {
+		return serverNew[types[0]](s.cfg, s.db, lis)
+	}
+	handlers := make(map[string]model.Handler, len(types))
+	for _, typ := range types {
+		val, err := serverNew[typ](s.cfg, s.db, nil)
+		if err != nil {
+			return nil, err
+		}
+		handler, ok := val.(model.Handler)
+		if !ok {
+			return nil, fmt.Errorf("%s server can't share the listener", typ)
+		}
+		handlers[typ] = handler
+	}
+	return mux.New(lis, handlers)
+}
+
 var dbNew = map[string]func(cfg config.Config) (model.DB, error){
 	"sqlite":        sqlite.New,
 	"mysql":         mysql.New,
@@ -90,21 +132,42 @@ var dbNew = map[string]func(cfg config.Config) (model.DB, error){
 	"gorm_sqlite":   gorm.New,
 }
 
-var serverNew = map[string]func(db model.DB, lis net.Listener) (model.Server, error){
+var serverNew = map[string]func(cfg config.Config, db model.DB, lis net.Listener) (model.Server, error){
 	"net_http":     net_http.New,
 	"gin":          gin.New,
 	"fiber":        fiber.New,
 	"grpc":         grpc.New,
+	"grpc_gateway": grpc.NewGateway,
 	"graphql":      graphql.New,
 	"yaml_to_code": yaml_to_code.New,
 }
 
+// Start starts the storage and the servers, they're shut down together when any of them fails
 func (s *Services) Start(ctx context.Context) error {
 	if err := s.db.Start(); err != nil {
 		return err
 	}
-	if err := s.server.Start(ctx); err != nil {
-		return err
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make([]error, len(s.servers))
+	var wg sync.WaitGroup
+	for i, server := range s.servers {
+		wg.Go(func() {
+			defer cancel()
+			errs[i] = server.Start(ctx)
+		})
+	}
+	wg.Wait()
+	return errors.Join(append(errs, s.close())...)
+}
+
+// close skips the listeners closed by the servers
+func (s *Services) close() error {
+	var errs []error
+	for _, lis := range s.lis {
+		if err := lis.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
 	}
-	return s.lis.Close()
+	return errors.Join(errs...)
 }
@@ -3,12 +3,14 @@ package sqlite
 import (
 	"context"
 	"database/sql"
-	"math/rand/v2"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
-	"github.com/google/uuid"
-	"github.com/jaswdr/faker/v2"
-	_ "modernc.org/sqlite"
+	"github.com/shopspring/decimal"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 
 	"github.com/aleksandrzhukovskii/go-template/internal/config"
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
@@ -25,6 +27,10 @@ func New(cfg config.Config) (model.DB, error) {
 	}, nil
 }
 
+func (s *Service) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
 func (s *Service) Start() error {
 	db, err := sql.Open("sqlite", s.path)
 	if err != nil {
@@ -34,82 +40,461 @@ func (s *Service) Start() error {
 	return s.migrate()
 }
 
-func (s *Service) migrate() error {
-	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS products (
+// The prices are text, the NUMERIC affinity would turn them into floats
+const (
+	productsSchema = `CREATE TABLE IF NOT EXISTS %s (
 			id TEXT PRIMARY KEY,
 			name TEXT,
-			price REAL,
-			created_at INTEGER
-		);`)
-	return err
-}
+			price TEXT NOT NULL DEFAULT '0',
+			created_at INTEGER,
+			version INTEGER NOT NULL DEFAULT 1,
+			deleted_at INTEGER NOT NULL DEFAULT 0,
+			currency TEXT NOT NULL DEFAULT 'USD'
+		);`
+	historySchema = `CREATE TABLE IF NOT EXISTS %s (
+			product_id TEXT NOT NULL,
+			version INTEGER NOT NULL,
+			operation TEXT NOT NULL,
+			changed_at INTEGER NOT NULL,
+			actor TEXT NOT NULL,
+			name TEXT,
+			price TEXT NOT NULL DEFAULT '0',
+			currency TEXT NOT NULL DEFAULT 'USD',
+			created_at INTEGER,
+			deleted_at INTEGER NOT NULL,
+			PRIMARY KEY (product_id, version)
+		);`
+	productColumns = "id, name, price, created_at, version, deleted_at, currency"
+)
 
-func (s *Service) Add(ctx context.Context) (string, error) {
-	f := faker.NewWithSeed(rand.NewPCG(uint64(time.Now().Unix()), uint64(time.Now().UnixNano())))
-	id := uuid.NewString()
-	_, err := s.db.ExecContext(ctx, "INSERT INTO products(id, name, price, created_at) values (?,?,?,?)",
-		id, f.Food().Fruit(), f.Float64(2, 1, 100), uint32(time.Now().Unix()))
-	return id, err
+func (s *Service) migrate() error {
+	_, err := s.db.Exec(fmt.Sprintf(productsSchema, model.TableName))
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(fmt.Sprintf(historySchema, model.HistoryTableName))
+	if err != nil {
+		return err
+	}
+	// Tables created before the columns were introduced
+	for _, col := range []struct{ table, name, def string }{
+		{model.TableName, "version", "INTEGER NOT NULL DEFAULT 1"},
+		{model.TableName, "deleted_at", "INTEGER NOT NULL DEFAULT 0"},
+		{model.TableName, "currency", "TEXT NOT NULL DEFAULT 'USD'"},
+		{model.HistoryTableName, "currency", "TEXT NOT NULL DEFAULT 'USD'"},
+	} {
+		var cnt int
+		err = s.db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name=?", col.table, col.name).
+			Scan(&cnt)
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			continue
+		}
+		if _, err = s.db.Exec("ALTER TABLE " + col.table + " ADD COLUMN " + col.name + " " + col.def); err != nil {
+			return err
+		}
+	}
+	if err = s.migratePrice(model.TableName, productsSchema, productColumns); err != nil {
+		return err
+	}
+	if err = s.migratePrice(model.HistoryTableName, historySchema, model.HistoryColumns); err != nil {
+		return err
+	}
+	for _, stmt := range searchSchema {
+		if _, err = s.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (s *Service) Update(ctx context.Context, val model.Product) error {
-	var res sql.Result
-	var err error
-	if val.Name != "" && val.Price != 0 {
-		res, err = s.db.ExecContext(ctx, "UPDATE products SET name=?, price=? WHERE id=?", val.Name, val.Price, val.ID)
-	} else if val.Price != 0 {
-		res, err = s.db.ExecContext(ctx, "UPDATE products SET price=? WHERE id=?", val.Price, val.ID)
-	} else if val.Name != "" {
-		res, err = s.db.ExecContext(ctx, "UPDATE products SET name=? WHERE id=?", val.Name, val.ID)
-	} else {
-		return model.ErrorNoUpdateParams
+// migratePrice converts the float prices, the table is copied along with its rowids
+func (s *Service) migratePrice(table string, schema string, columns string) error {
+	var typ string
+	err := s.db.QueryRow("SELECT type FROM pragma_table_info(?) WHERE name='price'", table).Scan(&typ)
+	if err != nil {
+		return err
+	}
+	if typ == "TEXT" {
+		return nil
 	}
+	return s.inTx(context.Background(), func(tx *sql.Tx) error {
+		copied := table + "_decimal"
+		stmts := []string{
+			fmt.Sprintf(schema, copied),
+			fmt.Sprintf("INSERT INTO %s (rowid, %s) SELECT rowid, %s FROM %s", copied, columns,
+				strings.Replace(columns, "price", "CAST(COALESCE(price, 0) AS TEXT)", 1), table),
+			"DROP TABLE " + table,
+			fmt.Sprintf("ALTER TABLE %s RENAME TO %s", copied, table),
+		}
+		for _, stmt := range stmts {
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// The FTS5 table refers to the rowids, which VACUUM may change, so it's rebuilt on every start
+var searchSchema = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS products_fts USING fts5(
+			name, content='products', content_rowid='rowid', tokenize='unicode61 remove_diacritics 0'
+		);`,
+	`CREATE TRIGGER IF NOT EXISTS products_fts_insert AFTER INSERT ON products BEGIN
+			INSERT INTO products_fts(rowid, name) VALUES (new.rowid, new.name);
+		END;`,
+	`CREATE TRIGGER IF NOT EXISTS products_fts_delete AFTER DELETE ON products BEGIN
+			INSERT INTO products_fts(products_fts, rowid, name) VALUES ('delete', old.rowid, old.name);
+		END;`,
+	`CREATE TRIGGER IF NOT EXISTS products_fts_update AFTER UPDATE OF name ON products BEGIN
+			INSERT INTO products_fts(products_fts, rowid, name) VALUES ('delete', old.rowid, old.name);
+			INSERT INTO products_fts(rowid, name) VALUES (new.rowid, new.name);
+		END;`,
+	"INSERT INTO products_fts(products_fts) VALUES ('rebuild');",
+}
 
+func (s *Service) Add(ctx context.Context, val model.Product) (ret model.Product, err error) {
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		ret, err = add(ctx, tx, val)
+		return err
+	})
+	return ret, err
+}
+
+func add(ctx context.Context, tx *sql.Tx, val model.Product) (model.Product, error) {
+	val, err := model.PrepareProduct(val)
+	if err != nil {
+		return model.Product{}, err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO products(id, name, price, currency, created_at, version) "+
+		"values (?,?,?,?,?,?)", val.ID, val.Name, val.Price, val.Currency, val.CreatedAt, val.Version)
 	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
+			return model.Product{}, model.ErrorAlreadyExists
+		}
+		return model.Product{}, err
+	}
+	return val, record(ctx, tx, model.NewHistoryEntry(ctx, model.OperationAdd, val))
+}
+
+func (s *Service) Update(ctx context.Context, val model.ProductUpdate) (ret model.Product, err error) {
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		ret, err = update(ctx, tx, val)
 		return err
+	})
+	return ret, err
+}
+
+func update(ctx context.Context, tx *sql.Tx, val model.ProductUpdate) (model.Product, error) {
+	if err := val.Validate(); err != nil {
+		return model.Product{}, err
+	}
+	set, args := val.SQL(dialect)
+	res, err := tx.ExecContext(ctx, "UPDATE products SET "+set+", version=version+1 "+
+		"WHERE id=? AND deleted_at=0 AND (version=? OR ?=0)", append(args, val.ID, val.Version, val.Version)...)
+	if err != nil {
+		return model.Product{}, err
 	}
 	if cnt, _ := res.RowsAffected(); cnt == 0 {
-		return model.ErrorNoRowsUpdated
+		return model.Product{}, versionError(ctx, tx, val.ID, val.Version, model.ErrorNoRowsUpdated)
 	}
-	return nil
+	return recordChange(ctx, tx, model.OperationUpdate, val.ID)
+}
+
+func (s *Service) Upsert(ctx context.Context, val model.Product) (ret model.Product, created bool, err error) {
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		ret, created, err = upsert(ctx, tx, val)
+		return err
+	})
+	return ret, created, err
 }
 
-func (s *Service) Delete(ctx context.Context, id string) error {
-	res, err := s.db.ExecContext(ctx, "DELETE FROM products WHERE id=?", id)
+// upsert returns no row for the unchanged product
+func upsert(ctx context.Context, tx *sql.Tx, val model.Product) (model.Product, bool, error) {
+	val, err := model.PrepareUpsert(val)
+	if err != nil {
+		return model.Product{}, false, err
+	}
+	var ret model.Product
+	err = tx.QueryRowContext(ctx, "INSERT INTO products(id, name, price, currency, created_at, version) "+
+		"values (?,?,?,?,?,?) ON CONFLICT(id) DO UPDATE SET name=excluded.name, price=excluded.price, "+
+		"currency=excluded.currency, deleted_at=0, version=version+1 WHERE name IS NOT excluded.name OR "+
+		"price IS NOT excluded.price OR currency IS NOT excluded.currency OR deleted_at<>0 RETURNING *",
+		val.ID, val.Name, val.Price, val.Currency, val.CreatedAt, val.Version).
+		Scan(&ret.ID, &ret.Name, &ret.Price, &ret.CreatedAt, &ret.Version, &ret.DeletedAt, &ret.Currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		ret, err = stored(ctx, tx, val.ID)
+		return ret, false, err
+	}
 	if err != nil {
+		return model.Product{}, false, err
+	}
+	op := model.OperationUpdate
+	if ret.Version == 1 {
+		op = model.OperationAdd
+	}
+	return ret, op == model.OperationAdd, record(ctx, tx, model.NewHistoryEntry(ctx, op, ret))
+}
+
+func (s *Service) Delete(ctx context.Context, id string, version uint64) (ret model.Product, err error) {
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		ret, err = softDelete(ctx, tx, id, version)
 		return err
+	})
+	return ret, err
+}
+
+func softDelete(ctx context.Context, tx *sql.Tx, id string, version uint64) (model.Product, error) {
+	res, err := tx.ExecContext(ctx, "UPDATE products SET deleted_at=?, version=version+1 "+
+		"WHERE id=? AND deleted_at=0 AND (version=? OR ?=0)", time.Now().Unix(), id, version, version)
+	if err != nil {
+		return model.Product{}, err
 	}
 	if cnt, _ := res.RowsAffected(); cnt == 0 {
-		return model.ErrorNoRowsDeleted
+		return model.Product{}, versionError(ctx, tx, id, version, model.ErrorNoRowsDeleted)
 	}
-	return nil
+	return recordChange(ctx, tx, model.OperationDelete, id)
+}
+
+func (s *Service) AddBatch(ctx context.Context, vals []model.Product) ([]model.BatchResult, error) {
+	return batch(ctx, s, vals, func(tx *sql.Tx, val model.Product) (*model.Product, error) {
+		prod, err := add(ctx, tx, val)
+		return &prod, err
+	})
+}
+
+func (s *Service) UpdateBatch(ctx context.Context, vals []model.ProductUpdate) ([]model.BatchResult, error) {
+	return batch(ctx, s, vals, func(tx *sql.Tx, val model.ProductUpdate) (*model.Product, error) {
+		prod, err := update(ctx, tx, val)
+		return &prod, err
+	})
+}
+
+func (s *Service) DeleteBatch(ctx context.Context, vals []model.Product) ([]model.BatchResult, error) {
+	return batch(ctx, s, vals, func(tx *sql.Tx, val model.Product) (*model.Product, error) {
+		prod, err := softDelete(ctx, tx, val.ID, val.Version)
+		return &prod, err
+	})
+}
+
+func batch[T model.BatchItem](ctx context.Context, s *Service, vals []T,
+	write func(tx *sql.Tx, val T) (*model.Product, error)) (ret []model.BatchResult, err error) {
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		ret, err = model.RunBatch(vals, func(val T) (*model.Product, error) {
+			return write(tx, val)
+		})
+		return err
+	})
+	return ret, err
+}
+
+func (s *Service) inTx(ctx context.Context, write func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = write(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Service) Restore(ctx context.Context, id string) (ret model.Product, err error) {
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			"UPDATE products SET deleted_at=0, version=version+1 WHERE id=? AND deleted_at<>0", id)
+		if err != nil {
+			return err
+		}
+		if cnt, _ := res.RowsAffected(); cnt == 0 {
+			return model.ErrorNoRowsRestored
+		}
+		ret, err = recordChange(ctx, tx, model.OperationRestore, id)
+		return err
+	})
+	return ret, err
+}
+
+func (s *Service) Purge(ctx context.Context, before uint32) (cnt int64, err error) {
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		_, err = tx.ExecContext(ctx, "DELETE FROM product_history WHERE product_id IN "+
+			"(SELECT id FROM products WHERE deleted_at<>0 AND deleted_at<=?)", before)
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, "DELETE FROM products WHERE deleted_at<>0 AND deleted_at<=?", before)
+		if err != nil {
+			return err
+		}
+		cnt, err = res.RowsAffected()
+		return err
+	})
+	return cnt, err
+}
+
+func record(ctx context.Context, tx *sql.Tx, entry model.HistoryEntry) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO product_history("+model.HistoryColumns+") values (?,?,?,?,?,?,?,?,?,?)",
+		entry.Values()...)
+	return err
+}
+
+func recordChange(ctx context.Context, tx *sql.Tx, op model.Operation, id string) (model.Product, error) {
+	val, err := stored(ctx, tx, id)
+	if err != nil {
+		return model.Product{}, err
+	}
+	return val, record(ctx, tx, model.NewHistoryEntry(ctx, op, val))
+}
+
+func stored(ctx context.Context, tx *sql.Tx, id string) (model.Product, error) {
+	var val model.Product
+	err := tx.QueryRowContext(ctx, "SELECT * FROM products WHERE id=?", id).
+		Scan(&val.ID, &val.Name, &val.Price, &val.CreatedAt, &val.Version, &val.DeletedAt, &val.Currency)
+	return val, err
+}
+
+func (s *Service) History(ctx context.Context, id string) ([]model.HistoryEntry, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+model.HistoryColumns+" FROM product_history "+
+		"WHERE product_id=? ORDER BY version", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ret []model.HistoryEntry
+	for rows.Next() {
+		elem, err := model.ScanHistoryEntry(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, elem)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return model.LinkHistory(ret), nil
+}
+
+// versionError tells a version conflict from a missing product
+func versionError(ctx context.Context, tx *sql.Tx, id string, version uint64, notFound error) error {
+	if version == 0 {
+		return notFound
+	}
+	var exists int
+	err := tx.QueryRowContext(ctx, "SELECT 1 FROM products WHERE id=? AND deleted_at=0", id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound
+	}
+	if err != nil {
+		return err
+	}
+	return model.ErrorVersionConflict
 }
 
 func (s *Service) Get(ctx context.Context, id string) (model.Product, error) {
-	row := s.db.QueryRowContext(ctx, "SELECT * FROM products WHERE id=?", id)
+	row := s.db.QueryRowContext(ctx, "SELECT * FROM products WHERE id=? AND deleted_at=0", id)
 	if err := row.Err(); err != nil {
 		return model.Product{}, err
 	}
 	var ret model.Product
-	err := row.Scan(&ret.ID, &ret.Name, &ret.Price, &ret.CreatedAt)
+	err := row.Scan(&ret.ID, &ret.Name, &ret.Price, &ret.CreatedAt, &ret.Version, &ret.DeletedAt, &ret.Currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Product{}, model.ErrorNotFound
+	}
 	if err != nil {
 		return model.Product{}, err
 	}
 	return ret, nil
 }
 
-func (s *Service) GetAll(ctx context.Context) ([]model.Product, error) {
-	rows, err := s.db.QueryContext(ctx, "SELECT * FROM products")
+// sqlite has no default LIKE escape, the text prices are compared as integers
+var dialect = model.SQLDialect{
+	Placeholder: model.QuestionPlaceholder,
+	LikeEscape:  ` ESCAPE '\'`,
+	PriceColumn: model.SQLiteScaledPrice,
+	PriceArg:    func(price decimal.Decimal) any { return model.ScaledPrice(price) },
+}
+
+func (s *Service) GetMany(ctx context.Context, ids []string) ([]model.Product, error) {
+	if len(ids) == 0 {
+		return []model.Product{}, nil
+	}
+	in, args := dialect.In("id", ids)
+	rows, err := s.db.QueryContext(ctx, "SELECT * FROM products WHERE "+in+" AND deleted_at=0", args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+	ret := []model.Product{}
+	for rows.Next() {
+		var elem model.Product
+		err = rows.Scan(&elem.ID, &elem.Name, &elem.Price, &elem.CreatedAt, &elem.Version, &elem.DeletedAt,
+			&elem.Currency)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, elem)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (s *Service) GetAll(ctx context.Context, query model.ProductQuery, page model.PageRequest) (model.ProductPage, error) {
+	size, after, err := page.Prepare(&query)
+	if err != nil {
+		return model.ProductPage{}, err
+	}
+	where, order, args := query.SQL(dialect, after)
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT * FROM products WHERE %s ORDER BY %s LIMIT %d",
+		where, order, size+1), args...)
+	if err != nil {
+		return model.ProductPage{}, err
+	}
+	defer rows.Close()
 	var ret []model.Product
 	for rows.Next() {
 		var elem model.Product
-		if err = rows.Scan(&elem.ID, &elem.Name, &elem.Price, &elem.CreatedAt); err != nil {
+		err = rows.Scan(&elem.ID, &elem.Name, &elem.Price, &elem.CreatedAt, &elem.Version, &elem.DeletedAt,
+			&elem.Currency)
+		if err != nil {
+			return model.ProductPage{}, err
+		}
+		ret = append(ret, elem)
+	}
+	if err = rows.Err(); err != nil {
+		return model.ProductPage{}, err
+	}
+	return model.NewProductPage(ret, size, query.Sort), nil
+}
+
+// Lower bm25 rank is the better match
+func (s *Service) Search(ctx context.Context, query model.SearchQuery) ([]model.Product, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT products.* FROM products_fts "+
+		"JOIN products ON products.rowid = products_fts.rowid "+
+		"WHERE products_fts MATCH ? AND products.deleted_at = 0 "+
+		"ORDER BY products_fts.rank, products.id LIMIT ?", query.FTS5(), query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ret := []model.Product{}
+	for rows.Next() {
+		var elem model.Product
+		err = rows.Scan(&elem.ID, &elem.Name, &elem.Price, &elem.CreatedAt, &elem.Version, &elem.DeletedAt,
+			&elem.Currency)
+		if err != nil {
 			return nil, err
 		}
 		ret = append(ret, elem)
 	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 	return ret, nil
 }
@@ -9,72 +9,158 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
 	"github.com/oapi-codegen/runtime"
 	strictnethttp "github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
 )
 
-// Add defines model for add.
-type Add struct {
-	// Id New product id
-	Id string `json:"id"`
-}
+// Defines values for ProductSort.
+const (
+	ProductSortCreatedAt      ProductSort = "created_at"
+	ProductSortId             ProductSort = "id"
+	ProductSortMinusCreatedAt ProductSort = "-created_at"
+	ProductSortMinusId        ProductSort = "-id"
+	ProductSortMinusName      ProductSort = "-name"
+	ProductSortMinusPrice     ProductSort = "-price"
+	ProductSortName           ProductSort = "name"
+	ProductSortPrice          ProductSort = "price"
+)
+
+// AddRequest defines model for add_request.
+type AddRequest struct {
+	// Currency ISO 4217 code of the price, required unless sample is set
+	Currency *string `json:"currency,omitempty"`
+
+	// Id ID of product, generated when omitted
+	Id *string `json:"id,omitempty"`
+
+	// Name Name of product, required unless sample is set
+	Name *string `json:"name,omitempty"`
+
+	// Price Exact decimal price of product, required unless sample is set
+	Price *string `json:"price,omitempty"`
 
-// DbIssue defines model for db_issue.
-type DbIssue struct {
-	// Error error message
-	Error string `json:"error"`
+	// Sample Ignore the other fields and store a product with random name and price
+	Sample *bool `json:"sample,omitempty"`
 }
 
+// BatchRequest defines model for batch_request.
+type BatchRequest = []Product
+
+// BatchResponse defines model for batch_response.
+type BatchResponse = model.BatchResponse
+
+// BatchResult defines model for batch_result.
+type BatchResult = model.BatchResult
+
 // Delete defines model for delete.
 type Delete struct {
 	// Msg response message
 	Msg string `json:"msg"`
 }
 
-// NoDelete defines model for no_delete.
-type NoDelete struct {
-	// Error error message
-	Error string `json:"error"`
-}
+// History defines model for history.
+type History = model.HistoryResponse
+
+// HistoryEntry defines model for history_entry.
+type HistoryEntry = model.HistoryEntry
+
+// Problem RFC 9457 problem details, the type of the problem follows from the kind of the error
+type Problem struct {
+	// Detail Explanation of this occurrence of the problem
+	Detail string `json:"detail"`
+
+	// Errors Invalid request fields, only for the invalid arguments
+	Errors *[]ProblemField `json:"errors,omitempty"`
+
+	// Instance URI of the request
+	Instance *string `json:"instance,omitempty"`
 
-// NoRows defines model for no_rows.
-type NoRows struct {
-	// Error error message
-	Error string `json:"error"`
+	// RequestId ID of the request, the one of the X-Request-ID header or a generated one
+	RequestId *string `json:"request_id,omitempty"`
+
+	// Status HTTP status code
+	Status int `json:"status"`
+
+	// Title Summary of the problem type
+	Title string `json:"title"`
+
+	// Type URI of the problem type
+	Type string `json:"type"`
 }
 
-// NoUpdate defines model for no_update.
-type NoUpdate struct {
-	// Error error message
-	Error string `json:"error"`
+// ProblemField defines model for problem_field.
+type ProblemField struct {
+	// Detail What is wrong with the field
+	Detail string `json:"detail"`
+
+	// Field Name of the request field
+	Field string `json:"field"`
 }
 
 // Product defines model for product.
 type Product = model.Product
 
+// ProductPatch Fields of product to update, the missing ones are left as they are
+type ProductPatch = model.ProductUpdate
+
+// ProductSort Field to sort by, prefixed with "-" for descending order
+type ProductSort string
+
 // Products defines model for products.
 type Products = []Product
 
+// ProductsPage defines model for products_page.
+type ProductsPage = model.ProductPage
+
+// Purge defines model for purge.
+type Purge = model.PurgeResult
+
+// Restore defines model for restore.
+type Restore struct {
+	// Msg response message
+	Msg string `json:"msg"`
+}
+
+// RestoreRequest defines model for restore_request.
+type RestoreRequest struct {
+	// Id ID of product
+	Id string `json:"id"`
+}
+
+// SearchResult defines model for search_result.
+type SearchResult = model.SearchResponse
+
 // Update defines model for update.
 type Update struct {
 	// Msg response message
 	Msg string `json:"msg"`
 }
 
+// UpdateBatchRequest defines model for update_batch_request.
+type UpdateBatchRequest = []ProductPatch
+
 // UpdateRequest defines model for update_request.
 type UpdateRequest struct {
+	// Currency ISO 4217 code of the price
+	Currency *string `json:"currency,omitempty"`
+
 	// Id ID of product
 	Id string `json:"id"`
 
 	// Name Name of product
 	Name *string `json:"name,omitempty"`
 
-	// Price Price of product
-	Price *float64 `json:"price,omitempty"`
-	union json.RawMessage
+	// Price Exact decimal price of product, non-zero
+	Price *string `json:"price,omitempty"`
+
+	// Version Update only if the product still has this version
+	Version *uint64 `json:"version,omitempty"`
+	union   json.RawMessage
 }
 
 // UpdateRequest0 defines model for .
@@ -83,16 +169,301 @@ type UpdateRequest0 = interface{}
 // UpdateRequest1 defines model for .
 type UpdateRequest1 = interface{}
 
+// UpdateRequest2 defines model for .
+type UpdateRequest2 = interface{}
+
+// UpsertRequest defines model for upsert_request.
+type UpsertRequest struct {
+	// Currency ISO 4217 code of the price
+	Currency string `json:"currency"`
+
+	// Name Name of product
+	Name string `json:"name"`
+
+	// Price Exact decimal price of product
+	Price string `json:"price"`
+}
+
+// AsOf defines model for as_of.
+type AsOf = uint32
+
+// CreatedFrom defines model for created_from.
+type CreatedFrom = uint32
+
+// CreatedTo defines model for created_to.
+type CreatedTo = uint32
+
+// Cursor defines model for cursor.
+type Cursor = string
+
+// IfMatch defines model for if_match.
+type IfMatch = string
+
+// IfNoneMatch defines model for if_none_match.
+type IfNoneMatch = string
+
+// IncludeDeleted defines model for include_deleted.
+type IncludeDeleted = bool
+
+// LastEventId defines model for last_event_id.
+type LastEventId = uint64
+
+// MaxPrice defines model for max_price.
+type MaxPrice = string
+
+// MinPrice defines model for min_price.
+type MinPrice = string
+
+// Name defines model for name.
+type Name = string
+
+// PageSize defines model for page_size.
+type PageSize = int
+
+// ProductId defines model for product_id.
+type ProductId = string
+
+// Sort Field to sort by, prefixed with "-" for descending order
+type Sort = ProductSort
+
+// Version defines model for version.
+type Version = uint64
+
+// AlreadyExists RFC 9457 problem details, the type of the problem follows from the kind of the error
+type AlreadyExists = Problem
+
+// Batch defines model for batch.
+type Batch = BatchResponse
+
+// DbIssue RFC 9457 problem details, the type of the problem follows from the kind of the error
+type DbIssue = Problem
+
+// InvalidAsOf RFC 9457 problem details, the type of the problem follows from the kind of the error
+type InvalidAsOf = Problem
+
+// InvalidBatch RFC 9457 problem details, the type of the problem follows from the kind of the error
+type InvalidBatch = Problem
+
+// InvalidLastEventId RFC 9457 problem details, the type of the problem follows from the kind of the error
+type InvalidLastEventId = Problem
+
+// InvalidListing RFC 9457 problem details, the type of the problem follows from the kind of the error
+type InvalidListing = Problem
+
+// InvalidProduct RFC 9457 problem details, the type of the problem follows from the kind of the error
+type InvalidProduct = Problem
+
+// InvalidSearch RFC 9457 problem details, the type of the problem follows from the kind of the error
+type InvalidSearch = Problem
+
+// NoUpdate RFC 9457 problem details, the type of the problem follows from the kind of the error
+type NoUpdate = Problem
+
+// NotAcceptable RFC 9457 problem details, the type of the problem follows from the kind of the error
+type NotAcceptable = Problem
+
+// NotFound RFC 9457 problem details, the type of the problem follows from the kind of the error
+type NotFound = Problem
+
+// PreconditionFailed RFC 9457 problem details, the type of the problem follows from the kind of the error
+type PreconditionFailed = Problem
+
+// Unavailable RFC 9457 problem details, the type of the problem follows from the kind of the error
+type Unavailable = Problem
+
+// UnsupportedMediaType RFC 9457 problem details, the type of the problem follows from the kind of the error
+type UnsupportedMediaType = Problem
+
+// VersionConflict RFC 9457 problem details, the type of the problem follows from the kind of the error
+type VersionConflict = Problem
+
 // DeleteProductParams defines parameters for DeleteProduct.
 type DeleteProductParams struct {
 	Id string `form:"id" json:"id"`
+
+	// Version Delete only if the product still has this version
+	Version *uint64 `form:"version,omitempty" json:"version,omitempty"`
+
+	// IfMatch Entity tag of the product read before, the product is only changed while it's still current
+	IfMatch *IfMatch `json:"If-Match,omitempty"`
 }
 
 // GetProductParams defines parameters for GetProduct.
 type GetProductParams struct {
 	Id string `form:"id" json:"id"`
+
+	// AsOf Timestamp to read the product as it was at, taken from its history
+	AsOf *uint32 `form:"as_of,omitempty" json:"as_of,omitempty"`
+
+	// IfNoneMatch Entity tags of the cached response, 304 is returned when one of them is still current
+	IfNoneMatch *IfNoneMatch `json:"If-None-Match,omitempty"`
+}
+
+// GetProductsParams defines parameters for GetProducts.
+type GetProductsParams struct {
+	// Name Case-insensitive substring of the product name
+	Name *Name `form:"name,omitempty" json:"name,omitempty"`
+
+	// MinPrice Exact decimal lowest price, inclusive
+	MinPrice *MinPrice `form:"min_price,omitempty" json:"min_price,omitempty"`
+
+	// MaxPrice Exact decimal highest price, inclusive
+	MaxPrice *MaxPrice `form:"max_price,omitempty" json:"max_price,omitempty"`
+
+	// CreatedFrom Earliest creation timestamp, inclusive
+	CreatedFrom *CreatedFrom `form:"created_from,omitempty" json:"created_from,omitempty"`
+
+	// CreatedTo Latest creation timestamp, inclusive
+	CreatedTo *CreatedTo `form:"created_to,omitempty" json:"created_to,omitempty"`
+
+	// IncludeDeleted List soft deleted products too
+	IncludeDeleted *IncludeDeleted `form:"include_deleted,omitempty" json:"include_deleted,omitempty"`
+
+	// Sort Field to sort by, prefixed with "-" for descending order, id by default
+	Sort *Sort `form:"sort,omitempty" json:"sort,omitempty"`
+
+	// PageSize Maximum number of products to return, 100 by default and 1000 at most
+	PageSize *PageSize `form:"page_size,omitempty" json:"page_size,omitempty"`
+
+	// Cursor Opaque cursor taken from next_cursor of the previous page
+	Cursor *Cursor `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// IfNoneMatch Entity tags of the cached response, 304 is returned when one of them is still current
+	IfNoneMatch *IfNoneMatch `json:"If-None-Match,omitempty"`
+}
+
+// GetProductHistoryParams defines parameters for GetProductHistory.
+type GetProductHistoryParams struct {
+	Id string `form:"id" json:"id"`
+}
+
+// ListProductsParams defines parameters for ListProducts.
+type ListProductsParams struct {
+	// Name Case-insensitive substring of the product name
+	Name *Name `form:"name,omitempty" json:"name,omitempty"`
+
+	// MinPrice Exact decimal lowest price, inclusive
+	MinPrice *MinPrice `form:"min_price,omitempty" json:"min_price,omitempty"`
+
+	// MaxPrice Exact decimal highest price, inclusive
+	MaxPrice *MaxPrice `form:"max_price,omitempty" json:"max_price,omitempty"`
+
+	// CreatedFrom Earliest creation timestamp, inclusive
+	CreatedFrom *CreatedFrom `form:"created_from,omitempty" json:"created_from,omitempty"`
+
+	// CreatedTo Latest creation timestamp, inclusive
+	CreatedTo *CreatedTo `form:"created_to,omitempty" json:"created_to,omitempty"`
+
+	// IncludeDeleted List soft deleted products too
+	IncludeDeleted *IncludeDeleted `form:"include_deleted,omitempty" json:"include_deleted,omitempty"`
+
+	// Sort Field to sort by, prefixed with "-" for descending order, id by default
+	Sort *Sort `form:"sort,omitempty" json:"sort,omitempty"`
+
+	// PageSize Maximum number of products to return, 100 by default and 1000 at most
+	PageSize *PageSize `form:"page_size,omitempty" json:"page_size,omitempty"`
+
+	// Cursor Opaque cursor taken from next_cursor of the previous page
+	Cursor *Cursor `form:"cursor,omitempty" json:"cursor,omitempty"`
+
+	// IfNoneMatch Entity tags of the cached response, 304 is returned when one of them is still current
+	IfNoneMatch *IfNoneMatch `json:"If-None-Match,omitempty"`
+}
+
+// StreamProductEventsParams defines parameters for StreamProductEvents.
+type StreamProductEventsParams struct {
+	// LastEventID Id of the last event received, the kept events after it are sent before the new ones
+	LastEventID *LastEventId `json:"Last-Event-ID,omitempty"`
+}
+
+// DeleteProductByIDParams defines parameters for DeleteProductByID.
+type DeleteProductByIDParams struct {
+	// Version Change the product only if it still has this version
+	Version *Version `form:"version,omitempty" json:"version,omitempty"`
+
+	// IfMatch Entity tag of the product read before, the product is only changed while it's still current
+	IfMatch *IfMatch `json:"If-Match,omitempty"`
+}
+
+// GetProductByIDParams defines parameters for GetProductByID.
+type GetProductByIDParams struct {
+	// AsOf Timestamp to read the product as it was at, taken from its history
+	AsOf *AsOf `form:"as_of,omitempty" json:"as_of,omitempty"`
+
+	// IfNoneMatch Entity tags of the cached response, 304 is returned when one of them is still current
+	IfNoneMatch *IfNoneMatch `json:"If-None-Match,omitempty"`
+}
+
+// PatchProductByIDParams defines parameters for PatchProductByID.
+type PatchProductByIDParams struct {
+	// Version Change the product only if it still has this version
+	Version *Version `form:"version,omitempty" json:"version,omitempty"`
+
+	// IfMatch Entity tag of the product read before, the product is only changed while it's still current
+	IfMatch *IfMatch `json:"If-Match,omitempty"`
+}
+
+// PurgeProductsParams defines parameters for PurgeProducts.
+type PurgeProductsParams struct {
+	Days uint32 `form:"days" json:"days"`
+}
+
+// SearchProductsParams defines parameters for SearchProducts.
+type SearchProductsParams struct {
+	// Q Words to look for, every one has to be the beginning of a word of the name
+	Q string `form:"q" json:"q"`
+
+	// Limit Maximum number of products to return, 20 by default and 100 at most
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// PatchProductParams defines parameters for PatchProduct.
+type PatchProductParams struct {
+	Id string `form:"id" json:"id"`
+
+	// Version Update only if the product still has this version
+	Version *uint64 `form:"version,omitempty" json:"version,omitempty"`
+
+	// IfMatch Entity tag of the product read before, the product is only changed while it's still current
+	IfMatch *IfMatch `json:"If-Match,omitempty"`
+}
+
+// UpdateProductParams defines parameters for UpdateProduct.
+type UpdateProductParams struct {
+	// IfMatch Entity tag of the product read before, the product is only changed while it's still current
+	IfMatch *IfMatch `json:"If-Match,omitempty"`
 }
 
+// AddProductFormdataRequestBody defines body for AddProduct for application/x-www-form-urlencoded ContentType.
+type AddProductFormdataRequestBody = AddRequest
+
+// DeleteProductsJSONRequestBody defines body for DeleteProducts for application/json ContentType.
+type DeleteProductsJSONRequestBody = BatchRequest
+
+// AddProductsJSONRequestBody defines body for AddProducts for application/json ContentType.
+type AddProductsJSONRequestBody = BatchRequest
+
+// UpdateProductsJSONRequestBody defines body for UpdateProducts for application/json ContentType.
+type UpdateProductsJSONRequestBody = UpdateBatchRequest
+
+// CreateProductFormdataRequestBody defines body for CreateProduct for application/x-www-form-urlencoded ContentType.
+type CreateProductFormdataRequestBody = AddRequest
+
+// PatchProductByIDApplicationMergePatchPlusJSONRequestBody defines body for PatchProductByID for application/merge-patch+json ContentType.
+type PatchProductByIDApplicationMergePatchPlusJSONRequestBody = ProductPatch
+
+// UpsertProductFormdataRequestBody defines body for UpsertProduct for application/x-www-form-urlencoded ContentType.
+type UpsertProductFormdataRequestBody = UpsertRequest
+
+// RestoreProductFormdataRequestBody defines body for RestoreProduct for application/x-www-form-urlencoded ContentType.
+type RestoreProductFormdataRequestBody = RestoreRequest
+
+// PatchProductApplicationMergePatchPlusJSONRequestBody defines body for PatchProduct for application/merge-patch+json ContentType.
+type PatchProductApplicationMergePatchPlusJSONRequestBody = ProductPatch
+
+// UpdateProductJSONRequestBody defines body for UpdateProduct for application/json ContentType.
+type UpdateProductJSONRequestBody = ProductPatch
+
 // UpdateProductFormdataRequestBody defines body for UpdateProduct for application/x-www-form-urlencoded ContentType.
 type UpdateProductFormdataRequestBody = UpdateRequest
 
@@ -148,6 +519,32 @@ func (t *UpdateRequest) MergeUpdateRequest1(v UpdateRequest1) error {
 	return err
 }
 
+// AsUpdateRequest2 returns the union data inside the UpdateRequest as a UpdateRequest2
+func (t UpdateRequest) AsUpdateRequest2() (UpdateRequest2, error) {
+	var body UpdateRequest2
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromUpdateRequest2 overwrites any union data inside the UpdateRequest as the provided UpdateRequest2
+func (t *UpdateRequest) FromUpdateRequest2(v UpdateRequest2) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeUpdateRequest2 performs a merge with any union data inside the UpdateRequest, using the provided UpdateRequest2
+func (t *UpdateRequest) MergeUpdateRequest2(v UpdateRequest2) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
 func (t UpdateRequest) MarshalJSON() ([]byte, error) {
 	b, err := t.union.MarshalJSON()
 	if err != nil {
@@ -161,6 +558,13 @@ func (t UpdateRequest) MarshalJSON() ([]byte, error) {
 		}
 	}
 
+	if t.Currency != nil {
+		object["currency"], err = json.Marshal(t.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'currency': %w", err)
+		}
+	}
+
 	object["id"], err = json.Marshal(t.Id)
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling 'id': %w", err)
@@ -179,6 +583,13 @@ func (t UpdateRequest) MarshalJSON() ([]byte, error) {
 			return nil, fmt.Errorf("error marshaling 'price': %w", err)
 		}
 	}
+
+	if t.Version != nil {
+		object["version"], err = json.Marshal(t.Version)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling 'version': %w", err)
+		}
+	}
 	b, err = json.Marshal(object)
 	return b, err
 }
@@ -194,6 +605,13 @@ func (t *UpdateRequest) UnmarshalJSON(b []byte) error {
 		return err
 	}
 
+	if raw, found := object["currency"]; found {
+		err = json.Unmarshal(raw, &t.Currency)
+		if err != nil {
+			return fmt.Errorf("error reading 'currency': %w", err)
+		}
+	}
+
 	if raw, found := object["id"]; found {
 		err = json.Unmarshal(raw, &t.Id)
 		if err != nil {
@@ -215,6 +633,13 @@ func (t *UpdateRequest) UnmarshalJSON(b []byte) error {
 		}
 	}
 
+	if raw, found := object["version"]; found {
+		err = json.Unmarshal(raw, &t.Version)
+		if err != nil {
+			return fmt.Errorf("error reading 'version': %w", err)
+		}
+	}
+
 	return err
 }
 
@@ -226,18 +651,63 @@ type ServerInterface interface {
 	// Adds new product
 	// (POST /add)
 	AddProduct(w http.ResponseWriter, r *http.Request)
-	// Deletes product
+	// Soft deletes products in one go, only id and version of the items are used
+	// (DELETE /batch)
+	DeleteProducts(w http.ResponseWriter, r *http.Request)
+	// Adds products in one go, the failed items don't prevent the others from being added
+	// (POST /batch)
+	AddProducts(w http.ResponseWriter, r *http.Request)
+	// Updates products in one go, the items are merge patches along with the ids and the versions
+	// (PUT /batch)
+	UpdateProducts(w http.ResponseWriter, r *http.Request)
+	// Soft deletes product, it's hidden from get and listing until restored
 	// (DELETE /delete)
 	DeleteProduct(w http.ResponseWriter, r *http.Request, params DeleteProductParams)
 	// Gets the product
 	// (GET /get)
 	GetProduct(w http.ResponseWriter, r *http.Request, params GetProductParams)
-	// Gets products
+	// Gets a page of filtered and sorted products
 	// (GET /get_all)
-	GetProducts(w http.ResponseWriter, r *http.Request)
+	GetProducts(w http.ResponseWriter, r *http.Request, params GetProductsParams)
+	// Gets the changes of the product, the changes are attributed to the X-Actor header of their requests
+	// (GET /history)
+	GetProductHistory(w http.ResponseWriter, r *http.Request, params GetProductHistoryParams)
+	// Gets a page of filtered and sorted products
+	// (GET /products)
+	ListProducts(w http.ResponseWriter, r *http.Request, params ListProductsParams)
+	// Creates product, it's returned along with its location
+	// (POST /products)
+	CreateProduct(w http.ResponseWriter, r *http.Request)
+	// Streams the changes of the products made through any server of the process as server-sent events
+	// (GET /products/events)
+	StreamProductEvents(w http.ResponseWriter, r *http.Request, params StreamProductEventsParams)
+	// Soft deletes product, it's hidden from get and listing until restored
+	// (DELETE /products/{id})
+	DeleteProductByID(w http.ResponseWriter, r *http.Request, id ProductId, params DeleteProductByIDParams)
+	// Gets the product
+	// (GET /products/{id})
+	GetProductByID(w http.ResponseWriter, r *http.Request, id ProductId, params GetProductByIDParams)
+	// Updates product with a JSON merge patch, null clears the field, the updated product is returned
+	// (PATCH /products/{id})
+	PatchProductByID(w http.ResponseWriter, r *http.Request, id ProductId, params PatchProductByIDParams)
+	// Creates product with the given id or replaces its name, price and currency, restoring it when deleted
+	// (PUT /products/{id})
+	UpsertProduct(w http.ResponseWriter, r *http.Request, id ProductId)
+	// Permanently removes products soft deleted more than the given number of days ago
+	// (DELETE /purge)
+	PurgeProducts(w http.ResponseWriter, r *http.Request, params PurgeProductsParams)
+	// Restores soft deleted product
+	// (POST /restore)
+	RestoreProduct(w http.ResponseWriter, r *http.Request)
+	// Searches the live products by name, the most relevant first
+	// (GET /search)
+	SearchProducts(w http.ResponseWriter, r *http.Request, params SearchProductsParams)
+	// Updates product with a JSON merge patch, null clears the field
+	// (PATCH /update)
+	PatchProduct(w http.ResponseWriter, r *http.Request, params PatchProductParams)
 	// Updates product
 	// (PUT /update)
-	UpdateProduct(w http.ResponseWriter, r *http.Request)
+	UpdateProduct(w http.ResponseWriter, r *http.Request, params UpdateProductParams)
 }
 
 // ServerInterfaceWrapper converts contexts to parameters.
@@ -277,6 +747,48 @@ func (siw *ServerInterfaceWrapper) AddProduct(w http.ResponseWriter, r *http.Req
 	handler.ServeHTTP(w, r)
 }
 
+// DeleteProducts operation middleware
+func (siw *ServerInterfaceWrapper) DeleteProducts(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteProducts(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// AddProducts operation middleware
+func (siw *ServerInterfaceWrapper) AddProducts(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.AddProducts(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// UpdateProducts operation middleware
+func (siw *ServerInterfaceWrapper) UpdateProducts(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UpdateProducts(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
 // DeleteProduct operation middleware
 func (siw *ServerInterfaceWrapper) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 
@@ -300,6 +812,35 @@ func (siw *ServerInterfaceWrapper) DeleteProduct(w http.ResponseWriter, r *http.
 		return
 	}
 
+	// ------------- Optional query parameter "version" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "version", r.URL.Query(), &params.Version)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "version", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch IfMatch
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "If-Match", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-Match", valueList[0], &IfMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "If-Match", Err: err})
+			return
+		}
+
+		params.IfMatch = &IfMatch
+
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.DeleteProduct(w, r, params)
 	}))
@@ -334,6 +875,35 @@ func (siw *ServerInterfaceWrapper) GetProduct(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// ------------- Optional query parameter "as_of" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "as_of", r.URL.Query(), &params.AsOf)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "as_of", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "If-None-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-None-Match")]; found {
+		var IfNoneMatch IfNoneMatch
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "If-None-Match", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-None-Match", valueList[0], &IfNoneMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "If-None-Match", Err: err})
+			return
+		}
+
+		params.IfNoneMatch = &IfNoneMatch
+
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		siw.Handler.GetProduct(w, r, params)
 	}))
@@ -348,417 +918,2801 @@ func (siw *ServerInterfaceWrapper) GetProduct(w http.ResponseWriter, r *http.Req
 // GetProducts operation middleware
 func (siw *ServerInterfaceWrapper) GetProducts(w http.ResponseWriter, r *http.Request) {
 
-	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetProducts(w, r)
-	}))
+	var err error
 
-	for _, middleware := range siw.HandlerMiddlewares {
-		handler = middleware(handler)
-	}
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetProductsParams
 
-	handler.ServeHTTP(w, r)
-}
+	// ------------- Optional query parameter "name" -------------
 
-// UpdateProduct operation middleware
-func (siw *ServerInterfaceWrapper) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	err = runtime.BindQueryParameter("form", true, false, "name", r.URL.Query(), &params.Name)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "name", Err: err})
+		return
+	}
 
-	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.UpdateProduct(w, r)
-	}))
+	// ------------- Optional query parameter "min_price" -------------
 
-	for _, middleware := range siw.HandlerMiddlewares {
-		handler = middleware(handler)
+	err = runtime.BindQueryParameter("form", true, false, "min_price", r.URL.Query(), &params.MinPrice)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "min_price", Err: err})
+		return
 	}
 
-	handler.ServeHTTP(w, r)
-}
+	// ------------- Optional query parameter "max_price" -------------
 
-type UnescapedCookieParamError struct {
-	ParamName string
-	Err       error
-}
+	err = runtime.BindQueryParameter("form", true, false, "max_price", r.URL.Query(), &params.MaxPrice)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "max_price", Err: err})
+		return
+	}
 
-func (e *UnescapedCookieParamError) Error() string {
-	return fmt.Sprintf("error unescaping cookie parameter '%s'", e.ParamName)
-}
+	// ------------- Optional query parameter "created_from" -------------
 
-func (e *UnescapedCookieParamError) Unwrap() error {
-	return e.Err
-}
+	err = runtime.BindQueryParameter("form", true, false, "created_from", r.URL.Query(), &params.CreatedFrom)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "created_from", Err: err})
+		return
+	}
 
-type UnmarshalingParamError struct {
-	ParamName string
-	Err       error
-}
+	// ------------- Optional query parameter "created_to" -------------
 
-func (e *UnmarshalingParamError) Error() string {
-	return fmt.Sprintf("Error unmarshaling parameter %s as JSON: %s", e.ParamName, e.Err.Error())
-}
+	err = runtime.BindQueryParameter("form", true, false, "created_to", r.URL.Query(), &params.CreatedTo)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "created_to", Err: err})
+		return
+	}
 
-func (e *UnmarshalingParamError) Unwrap() error {
-	return e.Err
-}
+	// ------------- Optional query parameter "include_deleted" -------------
 
-type RequiredParamError struct {
-	ParamName string
-}
+	err = runtime.BindQueryParameter("form", true, false, "include_deleted", r.URL.Query(), &params.IncludeDeleted)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "include_deleted", Err: err})
+		return
+	}
 
-func (e *RequiredParamError) Error() string {
-	return fmt.Sprintf("Query argument %s is required, but not found", e.ParamName)
-}
+	// ------------- Optional query parameter "sort" -------------
 
-type RequiredHeaderError struct {
-	ParamName string
-	Err       error
-}
+	err = runtime.BindQueryParameter("form", true, false, "sort", r.URL.Query(), &params.Sort)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "sort", Err: err})
+		return
+	}
 
-func (e *RequiredHeaderError) Error() string {
-	return fmt.Sprintf("Header parameter %s is required, but not found", e.ParamName)
-}
+	// ------------- Optional query parameter "page_size" -------------
 
-func (e *RequiredHeaderError) Unwrap() error {
-	return e.Err
-}
+	err = runtime.BindQueryParameter("form", true, false, "page_size", r.URL.Query(), &params.PageSize)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "page_size", Err: err})
+		return
+	}
 
-type InvalidParamFormatError struct {
-	ParamName string
-	Err       error
-}
+	// ------------- Optional query parameter "cursor" -------------
 
-func (e *InvalidParamFormatError) Error() string {
-	return fmt.Sprintf("Invalid format for parameter %s: %s", e.ParamName, e.Err.Error())
-}
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
 
-func (e *InvalidParamFormatError) Unwrap() error {
-	return e.Err
-}
+	headers := r.Header
 
-type TooManyValuesForParamError struct {
-	ParamName string
-	Count     int
-}
+	// ------------- Optional header parameter "If-None-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-None-Match")]; found {
+		var IfNoneMatch IfNoneMatch
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "If-None-Match", Count: n})
+			return
+		}
 
-func (e *TooManyValuesForParamError) Error() string {
-	return fmt.Sprintf("Expected one value for %s, got %d", e.ParamName, e.Count)
-}
+		err = runtime.BindStyledParameterWithOptions("simple", "If-None-Match", valueList[0], &IfNoneMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "If-None-Match", Err: err})
+			return
+		}
 
-// Handler creates http.Handler with routing matching OpenAPI spec.
-func Handler(si ServerInterface) http.Handler {
-	return HandlerWithOptions(si, StdHTTPServerOptions{})
-}
+		params.IfNoneMatch = &IfNoneMatch
 
-// ServeMux is an abstraction of http.ServeMux.
-type ServeMux interface {
-	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
-	ServeHTTP(w http.ResponseWriter, r *http.Request)
-}
+	}
 
-type StdHTTPServerOptions struct {
-	BaseURL          string
-	BaseRouter       ServeMux
-	Middlewares      []MiddlewareFunc
-	ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
-}
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetProducts(w, r, params)
+	}))
 
-// HandlerFromMux creates http.Handler with routing matching OpenAPI spec based on the provided mux.
-func HandlerFromMux(si ServerInterface, m ServeMux) http.Handler {
-	return HandlerWithOptions(si, StdHTTPServerOptions{
-		BaseRouter: m,
-	})
-}
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
 
-func HandlerFromMuxWithBaseURL(si ServerInterface, m ServeMux, baseURL string) http.Handler {
-	return HandlerWithOptions(si, StdHTTPServerOptions{
-		BaseURL:    baseURL,
-		BaseRouter: m,
-	})
+	handler.ServeHTTP(w, r)
 }
 
-// HandlerWithOptions creates http.Handler with additional options
-func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.Handler {
-	m := options.BaseRouter
+// GetProductHistory operation middleware
+func (siw *ServerInterfaceWrapper) GetProductHistory(w http.ResponseWriter, r *http.Request) {
 
-	if m == nil {
-		m = http.NewServeMux()
-	}
-	if options.ErrorHandlerFunc == nil {
-		options.ErrorHandlerFunc = func(w http.ResponseWriter, r *http.Request, err error) {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		}
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetProductHistoryParams
+
+	// ------------- Required query parameter "id" -------------
+
+	if paramValue := r.URL.Query().Get("id"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "id"})
+		return
 	}
 
-	wrapper := ServerInterfaceWrapper{
-		Handler:            si,
-		HandlerMiddlewares: options.Middlewares,
-		ErrorHandlerFunc:   options.ErrorHandlerFunc,
+	err = runtime.BindQueryParameter("form", true, true, "id", r.URL.Query(), &params.Id)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
 	}
 
-	m.HandleFunc("GET "+options.BaseURL+"/", wrapper.GetMain)
-	m.HandleFunc("POST "+options.BaseURL+"/add", wrapper.AddProduct)
-	m.HandleFunc("DELETE "+options.BaseURL+"/delete", wrapper.DeleteProduct)
-	m.HandleFunc("GET "+options.BaseURL+"/get", wrapper.GetProduct)
-	m.HandleFunc("GET "+options.BaseURL+"/get_all", wrapper.GetProducts)
-	m.HandleFunc("PUT "+options.BaseURL+"/update", wrapper.UpdateProduct)
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetProductHistory(w, r, params)
+	}))
 
-	return m
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
 }
 
-type DbIssueJSONResponse DbIssue
+// ListProducts operation middleware
+func (siw *ServerInterfaceWrapper) ListProducts(w http.ResponseWriter, r *http.Request) {
 
-type NoRowsJSONResponse NoRows
+	var err error
 
-type NoUpdateJSONResponse NoUpdate
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListProductsParams
 
-type GetMainRequestObject struct {
-}
+	// ------------- Optional query parameter "name" -------------
 
-type GetMainResponseObject interface {
-	VisitGetMainResponse(w http.ResponseWriter) error
-}
+	err = runtime.BindQueryParameter("form", true, false, "name", r.URL.Query(), &params.Name)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "name", Err: err})
+		return
+	}
 
-type GetMain200TextResponse string
+	// ------------- Optional query parameter "min_price" -------------
 
-func (response GetMain200TextResponse) VisitGetMainResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "text/plain")
-	w.WriteHeader(200)
+	err = runtime.BindQueryParameter("form", true, false, "min_price", r.URL.Query(), &params.MinPrice)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "min_price", Err: err})
+		return
+	}
 
-	_, err := w.Write([]byte(response))
-	return err
-}
+	// ------------- Optional query parameter "max_price" -------------
 
-type GetMain500TextResponse string
+	err = runtime.BindQueryParameter("form", true, false, "max_price", r.URL.Query(), &params.MaxPrice)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "max_price", Err: err})
+		return
+	}
 
-func (response GetMain500TextResponse) VisitGetMainResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "text/plain")
-	w.WriteHeader(500)
+	// ------------- Optional query parameter "created_from" -------------
 
-	_, err := w.Write([]byte(response))
-	return err
-}
+	err = runtime.BindQueryParameter("form", true, false, "created_from", r.URL.Query(), &params.CreatedFrom)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "created_from", Err: err})
+		return
+	}
 
-type AddProductRequestObject struct {
-}
+	// ------------- Optional query parameter "created_to" -------------
 
-type AddProductResponseObject interface {
-	VisitAddProductResponse(w http.ResponseWriter) error
-}
+	err = runtime.BindQueryParameter("form", true, false, "created_to", r.URL.Query(), &params.CreatedTo)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "created_to", Err: err})
+		return
+	}
 
-type AddProduct200JSONResponse Add
+	// ------------- Optional query parameter "include_deleted" -------------
 
-func (response AddProduct200JSONResponse) VisitAddProductResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(200)
+	err = runtime.BindQueryParameter("form", true, false, "include_deleted", r.URL.Query(), &params.IncludeDeleted)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "include_deleted", Err: err})
+		return
+	}
 
-	return json.NewEncoder(w).Encode(response)
-}
+	// ------------- Optional query parameter "sort" -------------
 
-type AddProduct500JSONResponse struct{ DbIssueJSONResponse }
+	err = runtime.BindQueryParameter("form", true, false, "sort", r.URL.Query(), &params.Sort)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "sort", Err: err})
+		return
+	}
 
-func (response AddProduct500JSONResponse) VisitAddProductResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(500)
+	// ------------- Optional query parameter "page_size" -------------
 
-	return json.NewEncoder(w).Encode(response)
-}
+	err = runtime.BindQueryParameter("form", true, false, "page_size", r.URL.Query(), &params.PageSize)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "page_size", Err: err})
+		return
+	}
 
-type DeleteProductRequestObject struct {
-	Params DeleteProductParams
-}
+	// ------------- Optional query parameter "cursor" -------------
 
-type DeleteProductResponseObject interface {
-	VisitDeleteProductResponse(w http.ResponseWriter) error
-}
+	err = runtime.BindQueryParameter("form", true, false, "cursor", r.URL.Query(), &params.Cursor)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "cursor", Err: err})
+		return
+	}
 
-type DeleteProduct200JSONResponse Delete
+	headers := r.Header
 
-func (response DeleteProduct200JSONResponse) VisitDeleteProductResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(200)
+	// ------------- Optional header parameter "If-None-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-None-Match")]; found {
+		var IfNoneMatch IfNoneMatch
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "If-None-Match", Count: n})
+			return
+		}
 
-	return json.NewEncoder(w).Encode(response)
+		err = runtime.BindStyledParameterWithOptions("simple", "If-None-Match", valueList[0], &IfNoneMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "If-None-Match", Err: err})
+			return
+		}
+
+		params.IfNoneMatch = &IfNoneMatch
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListProducts(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
 }
 
-type DeleteProduct400JSONResponse NoDelete
+// CreateProduct operation middleware
+func (siw *ServerInterfaceWrapper) CreateProduct(w http.ResponseWriter, r *http.Request) {
 
-func (response DeleteProduct400JSONResponse) VisitDeleteProductResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(400)
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateProduct(w, r)
+	}))
 
-	return json.NewEncoder(w).Encode(response)
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
 }
 
-type DeleteProduct500JSONResponse struct{ DbIssueJSONResponse }
+// StreamProductEvents operation middleware
+func (siw *ServerInterfaceWrapper) StreamProductEvents(w http.ResponseWriter, r *http.Request) {
 
-func (response DeleteProduct500JSONResponse) VisitDeleteProductResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(500)
+	var err error
 
-	return json.NewEncoder(w).Encode(response)
+	// Parameter object where we will unmarshal all parameters from the context
+	var params StreamProductEventsParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "Last-Event-ID" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("Last-Event-ID")]; found {
+		var LastEventID LastEventId
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "Last-Event-ID", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "Last-Event-ID", valueList[0], &LastEventID, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "Last-Event-ID", Err: err})
+			return
+		}
+
+		params.LastEventID = &LastEventID
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.StreamProductEvents(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteProductByID operation middleware
+func (siw *ServerInterfaceWrapper) DeleteProductByID(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ProductId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params DeleteProductByIDParams
+
+	// ------------- Optional query parameter "version" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "version", r.URL.Query(), &params.Version)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "version", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch IfMatch
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "If-Match", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-Match", valueList[0], &IfMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "If-Match", Err: err})
+			return
+		}
+
+		params.IfMatch = &IfMatch
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteProductByID(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetProductByID operation middleware
+func (siw *ServerInterfaceWrapper) GetProductByID(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ProductId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetProductByIDParams
+
+	// ------------- Optional query parameter "as_of" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "as_of", r.URL.Query(), &params.AsOf)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "as_of", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "If-None-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-None-Match")]; found {
+		var IfNoneMatch IfNoneMatch
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "If-None-Match", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-None-Match", valueList[0], &IfNoneMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "If-None-Match", Err: err})
+			return
+		}
+
+		params.IfNoneMatch = &IfNoneMatch
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetProductByID(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PatchProductByID operation middleware
+func (siw *ServerInterfaceWrapper) PatchProductByID(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ProductId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PatchProductByIDParams
+
+	// ------------- Optional query parameter "version" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "version", r.URL.Query(), &params.Version)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "version", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch IfMatch
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "If-Match", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-Match", valueList[0], &IfMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "If-Match", Err: err})
+			return
+		}
+
+		params.IfMatch = &IfMatch
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PatchProductByID(w, r, id, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// UpsertProduct operation middleware
+func (siw *ServerInterfaceWrapper) UpsertProduct(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id ProductId
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", r.PathValue("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UpsertProduct(w, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PurgeProducts operation middleware
+func (siw *ServerInterfaceWrapper) PurgeProducts(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PurgeProductsParams
+
+	// ------------- Required query parameter "days" -------------
+
+	if paramValue := r.URL.Query().Get("days"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "days"})
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "days", r.URL.Query(), &params.Days)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "days", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PurgeProducts(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RestoreProduct operation middleware
+func (siw *ServerInterfaceWrapper) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RestoreProduct(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// SearchProducts operation middleware
+func (siw *ServerInterfaceWrapper) SearchProducts(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params SearchProductsParams
+
+	// ------------- Required query parameter "q" -------------
+
+	if paramValue := r.URL.Query().Get("q"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "q"})
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "q", r.URL.Query(), &params.Q)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "q", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.SearchProducts(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// PatchProduct operation middleware
+func (siw *ServerInterfaceWrapper) PatchProduct(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PatchProductParams
+
+	// ------------- Required query parameter "id" -------------
+
+	if paramValue := r.URL.Query().Get("id"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(w, r, &RequiredParamError{ParamName: "id"})
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "id", r.URL.Query(), &params.Id)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "version" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "version", r.URL.Query(), &params.Version)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "version", Err: err})
+		return
+	}
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch IfMatch
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "If-Match", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-Match", valueList[0], &IfMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "If-Match", Err: err})
+			return
+		}
+
+		params.IfMatch = &IfMatch
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PatchProduct(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// UpdateProduct operation middleware
+func (siw *ServerInterfaceWrapper) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params UpdateProductParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "If-Match" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("If-Match")]; found {
+		var IfMatch IfMatch
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "If-Match", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "If-Match", valueList[0], &IfMatch, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "If-Match", Err: err})
+			return
+		}
+
+		params.IfMatch = &IfMatch
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UpdateProduct(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+type UnescapedCookieParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnescapedCookieParamError) Error() string {
+	return fmt.Sprintf("error unescaping cookie parameter '%s'", e.ParamName)
+}
+
+func (e *UnescapedCookieParamError) Unwrap() error {
+	return e.Err
+}
+
+type UnmarshalingParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnmarshalingParamError) Error() string {
+	return fmt.Sprintf("Error unmarshaling parameter %s as JSON: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *UnmarshalingParamError) Unwrap() error {
+	return e.Err
+}
+
+type RequiredParamError struct {
+	ParamName string
+}
+
+func (e *RequiredParamError) Error() string {
+	return fmt.Sprintf("Query argument %s is required, but not found", e.ParamName)
+}
+
+type RequiredHeaderError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *RequiredHeaderError) Error() string {
+	return fmt.Sprintf("Header parameter %s is required, but not found", e.ParamName)
+}
+
+func (e *RequiredHeaderError) Unwrap() error {
+	return e.Err
+}
+
+type InvalidParamFormatError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *InvalidParamFormatError) Error() string {
+	return fmt.Sprintf("Invalid format for parameter %s: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *InvalidParamFormatError) Unwrap() error {
+	return e.Err
+}
+
+type TooManyValuesForParamError struct {
+	ParamName string
+	Count     int
+}
+
+func (e *TooManyValuesForParamError) Error() string {
+	return fmt.Sprintf("Expected one value for %s, got %d", e.ParamName, e.Count)
+}
+
+// Handler creates http.Handler with routing matching OpenAPI spec.
+func Handler(si ServerInterface) http.Handler {
+	return HandlerWithOptions(si, StdHTTPServerOptions{})
+}
+
+// ServeMux is an abstraction of http.ServeMux.
+type ServeMux interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+	ServeHTTP(w http.ResponseWriter, r *http.Request)
+}
+
+type StdHTTPServerOptions struct {
+	BaseURL          string
+	BaseRouter       ServeMux
+	Middlewares      []MiddlewareFunc
+	ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// HandlerFromMux creates http.Handler with routing matching OpenAPI spec based on the provided mux.
+func HandlerFromMux(si ServerInterface, m ServeMux) http.Handler {
+	return HandlerWithOptions(si, StdHTTPServerOptions{
+		BaseRouter: m,
+	})
+}
+
+func HandlerFromMuxWithBaseURL(si ServerInterface, m ServeMux, baseURL string) http.Handler {
+	return HandlerWithOptions(si, StdHTTPServerOptions{
+		BaseURL:    baseURL,
+		BaseRouter: m,
+	})
+}
+
+// HandlerWithOptions creates http.Handler with additional options
+func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.Handler {
+	m := options.BaseRouter
+
+	if m == nil {
+		m = http.NewServeMux()
+	}
+	if options.ErrorHandlerFunc == nil {
+		options.ErrorHandlerFunc = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}
+
+	wrapper := ServerInterfaceWrapper{
+		Handler:            si,
+		HandlerMiddlewares: options.Middlewares,
+		ErrorHandlerFunc:   options.ErrorHandlerFunc,
+	}
+
+	m.HandleFunc("GET "+options.BaseURL+"/", wrapper.GetMain)
+	m.HandleFunc("POST "+options.BaseURL+"/add", wrapper.AddProduct)
+	m.HandleFunc("DELETE "+options.BaseURL+"/batch", wrapper.DeleteProducts)
+	m.HandleFunc("POST "+options.BaseURL+"/batch", wrapper.AddProducts)
+	m.HandleFunc("PUT "+options.BaseURL+"/batch", wrapper.UpdateProducts)
+	m.HandleFunc("DELETE "+options.BaseURL+"/delete", wrapper.DeleteProduct)
+	m.HandleFunc("GET "+options.BaseURL+"/get", wrapper.GetProduct)
+	m.HandleFunc("GET "+options.BaseURL+"/get_all", wrapper.GetProducts)
+	m.HandleFunc("GET "+options.BaseURL+"/history", wrapper.GetProductHistory)
+	m.HandleFunc("GET "+options.BaseURL+"/products", wrapper.ListProducts)
+	m.HandleFunc("POST "+options.BaseURL+"/products", wrapper.CreateProduct)
+	m.HandleFunc("GET "+options.BaseURL+"/products/events", wrapper.StreamProductEvents)
+	m.HandleFunc("DELETE "+options.BaseURL+"/products/{id}", wrapper.DeleteProductByID)
+	m.HandleFunc("GET "+options.BaseURL+"/products/{id}", wrapper.GetProductByID)
+	m.HandleFunc("PATCH "+options.BaseURL+"/products/{id}", wrapper.PatchProductByID)
+	m.HandleFunc("PUT "+options.BaseURL+"/products/{id}", wrapper.UpsertProduct)
+	m.HandleFunc("DELETE "+options.BaseURL+"/purge", wrapper.PurgeProducts)
+	m.HandleFunc("POST "+options.BaseURL+"/restore", wrapper.RestoreProduct)
+	m.HandleFunc("GET "+options.BaseURL+"/search", wrapper.SearchProducts)
+	m.HandleFunc("PATCH "+options.BaseURL+"/update", wrapper.PatchProduct)
+	m.HandleFunc("PUT "+options.BaseURL+"/update", wrapper.UpdateProduct)
+
+	return m
+}
+
+type AlreadyExistsApplicationProblemPlusJSONResponse Problem
+
+type BatchJSONResponse BatchResponse
+
+type DbIssueApplicationProblemPlusJSONResponse Problem
+
+type InvalidAsOfApplicationProblemPlusJSONResponse Problem
+
+type InvalidBatchApplicationProblemPlusJSONResponse Problem
+
+type InvalidLastEventIdApplicationProblemPlusJSONResponse Problem
+
+type InvalidListingApplicationProblemPlusJSONResponse Problem
+
+type InvalidProductApplicationProblemPlusJSONResponse Problem
+
+type InvalidSearchApplicationProblemPlusJSONResponse Problem
+
+type NoUpdateApplicationProblemPlusJSONResponse Problem
+
+type NotAcceptableApplicationProblemPlusJSONResponse Problem
+
+type NotFoundApplicationProblemPlusJSONResponse Problem
+
+type NotModifiedResponseHeaders struct {
+	ETag string
+}
+type NotModifiedResponse struct {
+	Headers NotModifiedResponseHeaders
+}
+
+type PreconditionFailedApplicationProblemPlusJSONResponse Problem
+
+type UnavailableApplicationProblemPlusJSONResponse Problem
+
+type UnsupportedMediaTypeApplicationProblemPlusJSONResponse Problem
+
+type VersionConflictApplicationProblemPlusJSONResponse Problem
+
+type GetMainRequestObject struct {
+}
+
+type GetMainResponseObject interface {
+	VisitGetMainResponse(w http.ResponseWriter) error
+}
+
+type GetMain200TextResponse string
+
+func (response GetMain200TextResponse) VisitGetMainResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(200)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type GetMain500TextResponse string
+
+func (response GetMain500TextResponse) VisitGetMainResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(500)
+
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+type AddProductRequestObject struct {
+	Body *AddProductFormdataRequestBody
+}
+
+type AddProductResponseObject interface {
+	VisitAddProductResponse(w http.ResponseWriter) error
+}
+
+type AddProduct200JSONResponse Product
+
+func (response AddProduct200JSONResponse) VisitAddProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type AddProduct400ApplicationProblemPlusJSONResponse struct {
+	InvalidProductApplicationProblemPlusJSONResponse
+}
+
+func (response AddProduct400ApplicationProblemPlusJSONResponse) VisitAddProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type AddProduct409ApplicationProblemPlusJSONResponse struct {
+	AlreadyExistsApplicationProblemPlusJSONResponse
+}
+
+func (response AddProduct409ApplicationProblemPlusJSONResponse) VisitAddProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type AddProduct500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response AddProduct500ApplicationProblemPlusJSONResponse) VisitAddProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type AddProduct503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response AddProduct503ApplicationProblemPlusJSONResponse) VisitAddProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProductsRequestObject struct {
+	Body *DeleteProductsJSONRequestBody
+}
+
+type DeleteProductsResponseObject interface {
+	VisitDeleteProductsResponse(w http.ResponseWriter) error
+}
+
+type DeleteProducts200JSONResponse struct{ BatchJSONResponse }
+
+func (response DeleteProducts200JSONResponse) VisitDeleteProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProducts400ApplicationProblemPlusJSONResponse struct {
+	InvalidBatchApplicationProblemPlusJSONResponse
+}
+
+func (response DeleteProducts400ApplicationProblemPlusJSONResponse) VisitDeleteProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProducts500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response DeleteProducts500ApplicationProblemPlusJSONResponse) VisitDeleteProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProducts503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response DeleteProducts503ApplicationProblemPlusJSONResponse) VisitDeleteProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type AddProductsRequestObject struct {
+	Body *AddProductsJSONRequestBody
+}
+
+type AddProductsResponseObject interface {
+	VisitAddProductsResponse(w http.ResponseWriter) error
+}
+
+type AddProducts200JSONResponse struct{ BatchJSONResponse }
+
+func (response AddProducts200JSONResponse) VisitAddProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type AddProducts400ApplicationProblemPlusJSONResponse struct {
+	InvalidBatchApplicationProblemPlusJSONResponse
+}
+
+func (response AddProducts400ApplicationProblemPlusJSONResponse) VisitAddProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type AddProducts500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response AddProducts500ApplicationProblemPlusJSONResponse) VisitAddProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type AddProducts503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response AddProducts503ApplicationProblemPlusJSONResponse) VisitAddProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateProductsRequestObject struct {
+	Body *UpdateProductsJSONRequestBody
+}
+
+type UpdateProductsResponseObject interface {
+	VisitUpdateProductsResponse(w http.ResponseWriter) error
+}
+
+type UpdateProducts200JSONResponse struct{ BatchJSONResponse }
+
+func (response UpdateProducts200JSONResponse) VisitUpdateProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateProducts400ApplicationProblemPlusJSONResponse struct {
+	InvalidBatchApplicationProblemPlusJSONResponse
+}
+
+func (response UpdateProducts400ApplicationProblemPlusJSONResponse) VisitUpdateProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateProducts500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response UpdateProducts500ApplicationProblemPlusJSONResponse) VisitUpdateProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateProducts503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response UpdateProducts503ApplicationProblemPlusJSONResponse) VisitUpdateProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProductRequestObject struct {
+	Params DeleteProductParams
+}
+
+type DeleteProductResponseObject interface {
+	VisitDeleteProductResponse(w http.ResponseWriter) error
+}
+
+type DeleteProduct200JSONResponse Delete
+
+func (response DeleteProduct200JSONResponse) VisitDeleteProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProduct404ApplicationProblemPlusJSONResponse Problem
+
+func (response DeleteProduct404ApplicationProblemPlusJSONResponse) VisitDeleteProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProduct409ApplicationProblemPlusJSONResponse struct {
+	VersionConflictApplicationProblemPlusJSONResponse
+}
+
+func (response DeleteProduct409ApplicationProblemPlusJSONResponse) VisitDeleteProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProduct412ApplicationProblemPlusJSONResponse struct {
+	PreconditionFailedApplicationProblemPlusJSONResponse
+}
+
+func (response DeleteProduct412ApplicationProblemPlusJSONResponse) VisitDeleteProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(412)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProduct500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response DeleteProduct500ApplicationProblemPlusJSONResponse) VisitDeleteProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProduct503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response DeleteProduct503ApplicationProblemPlusJSONResponse) VisitDeleteProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetProductRequestObject struct {
+	Params GetProductParams
+}
+
+type GetProductResponseObject interface {
+	VisitGetProductResponse(w http.ResponseWriter) error
+}
+
+type GetProduct200ResponseHeaders struct {
+	ETag string
+}
+
+type GetProduct200JSONResponse struct {
+	Body    Product
+	Headers GetProduct200ResponseHeaders
+}
+
+func (response GetProduct200JSONResponse) VisitGetProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", fmt.Sprint(response.Headers.ETag))
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetProduct304Response = NotModifiedResponse
+
+func (response GetProduct304Response) VisitGetProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("ETag", fmt.Sprint(response.Headers.ETag))
+	w.WriteHeader(304)
+	return nil
+}
+
+type GetProduct400ApplicationProblemPlusJSONResponse struct {
+	InvalidAsOfApplicationProblemPlusJSONResponse
+}
+
+func (response GetProduct400ApplicationProblemPlusJSONResponse) VisitGetProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetProduct404ApplicationProblemPlusJSONResponse struct {
+	NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response GetProduct404ApplicationProblemPlusJSONResponse) VisitGetProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetProduct500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response GetProduct500ApplicationProblemPlusJSONResponse) VisitGetProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetProduct503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response GetProduct503ApplicationProblemPlusJSONResponse) VisitGetProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetProductsRequestObject struct {
+	Params GetProductsParams
+}
+
+type GetProductsResponseObject interface {
+	VisitGetProductsResponse(w http.ResponseWriter) error
+}
+
+type GetProducts200ResponseHeaders struct {
+	ETag string
+}
+
+type GetProducts200JSONResponse struct {
+	Body    ProductsPage
+	Headers GetProducts200ResponseHeaders
+}
+
+func (response GetProducts200JSONResponse) VisitGetProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", fmt.Sprint(response.Headers.ETag))
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetProducts304Response = NotModifiedResponse
+
+func (response GetProducts304Response) VisitGetProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("ETag", fmt.Sprint(response.Headers.ETag))
+	w.WriteHeader(304)
+	return nil
+}
+
+type GetProducts400ApplicationProblemPlusJSONResponse struct {
+	InvalidListingApplicationProblemPlusJSONResponse
+}
+
+func (response GetProducts400ApplicationProblemPlusJSONResponse) VisitGetProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetProducts500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response GetProducts500ApplicationProblemPlusJSONResponse) VisitGetProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetProducts503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response GetProducts503ApplicationProblemPlusJSONResponse) VisitGetProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetProductHistoryRequestObject struct {
+	Params GetProductHistoryParams
+}
+
+type GetProductHistoryResponseObject interface {
+	VisitGetProductHistoryResponse(w http.ResponseWriter) error
+}
+
+type GetProductHistory200JSONResponse History
+
+func (response GetProductHistory200JSONResponse) VisitGetProductHistoryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetProductHistory500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response GetProductHistory500ApplicationProblemPlusJSONResponse) VisitGetProductHistoryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetProductHistory503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response GetProductHistory503ApplicationProblemPlusJSONResponse) VisitGetProductHistoryResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListProductsRequestObject struct {
+	Params ListProductsParams
+}
+
+type ListProductsResponseObject interface {
+	VisitListProductsResponse(w http.ResponseWriter) error
+}
+
+type ListProducts200ResponseHeaders struct {
+	ETag string
+}
+
+type ListProducts200JSONResponse struct {
+	Body    ProductsPage
+	Headers ListProducts200ResponseHeaders
+}
+
+func (response ListProducts200JSONResponse) VisitListProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", fmt.Sprint(response.Headers.ETag))
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type ListProducts304Response = NotModifiedResponse
+
+func (response ListProducts304Response) VisitListProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("ETag", fmt.Sprint(response.Headers.ETag))
+	w.WriteHeader(304)
+	return nil
+}
+
+type ListProducts400ApplicationProblemPlusJSONResponse struct {
+	InvalidListingApplicationProblemPlusJSONResponse
+}
+
+func (response ListProducts400ApplicationProblemPlusJSONResponse) VisitListProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListProducts500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response ListProducts500ApplicationProblemPlusJSONResponse) VisitListProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListProducts503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response ListProducts503ApplicationProblemPlusJSONResponse) VisitListProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateProductRequestObject struct {
+	Body *CreateProductFormdataRequestBody
+}
+
+type CreateProductResponseObject interface {
+	VisitCreateProductResponse(w http.ResponseWriter) error
+}
+
+type CreateProduct201ResponseHeaders struct {
+	Location string
+}
+
+type CreateProduct201JSONResponse struct {
+	Body    Product
+	Headers CreateProduct201ResponseHeaders
+}
+
+func (response CreateProduct201JSONResponse) VisitCreateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprint(response.Headers.Location))
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type CreateProduct400ApplicationProblemPlusJSONResponse struct {
+	InvalidProductApplicationProblemPlusJSONResponse
+}
+
+func (response CreateProduct400ApplicationProblemPlusJSONResponse) VisitCreateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateProduct409ApplicationProblemPlusJSONResponse struct {
+	AlreadyExistsApplicationProblemPlusJSONResponse
+}
+
+func (response CreateProduct409ApplicationProblemPlusJSONResponse) VisitCreateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateProduct500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response CreateProduct500ApplicationProblemPlusJSONResponse) VisitCreateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateProduct503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response CreateProduct503ApplicationProblemPlusJSONResponse) VisitCreateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type StreamProductEventsRequestObject struct {
+	Params StreamProductEventsParams
+}
+
+type StreamProductEventsResponseObject interface {
+	VisitStreamProductEventsResponse(w http.ResponseWriter) error
+}
+
+type StreamProductEvents200TexteventStreamResponse struct {
+	Body          io.Reader
+	ContentLength int64
+}
+
+func (response StreamProductEvents200TexteventStreamResponse) VisitStreamProductEventsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/event-stream")
+	if response.ContentLength != 0 {
+		w.Header().Set("Content-Length", fmt.Sprint(response.ContentLength))
+	}
+	w.WriteHeader(200)
+
+	if closer, ok := response.Body.(io.ReadCloser); ok {
+		defer closer.Close()
+	}
+	_, err := io.Copy(w, response.Body)
+	return err
+}
+
+type StreamProductEvents400ApplicationProblemPlusJSONResponse struct {
+	InvalidLastEventIdApplicationProblemPlusJSONResponse
+}
+
+func (response StreamProductEvents400ApplicationProblemPlusJSONResponse) VisitStreamProductEventsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type StreamProductEvents503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response StreamProductEvents503ApplicationProblemPlusJSONResponse) VisitStreamProductEventsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProductByIDRequestObject struct {
+	Id     ProductId `json:"id"`
+	Params DeleteProductByIDParams
+}
+
+type DeleteProductByIDResponseObject interface {
+	VisitDeleteProductByIDResponse(w http.ResponseWriter) error
+}
+
+type DeleteProductByID204Response struct {
+}
+
+func (response DeleteProductByID204Response) VisitDeleteProductByIDResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type DeleteProductByID404ApplicationProblemPlusJSONResponse struct {
+	NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response DeleteProductByID404ApplicationProblemPlusJSONResponse) VisitDeleteProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProductByID409ApplicationProblemPlusJSONResponse struct {
+	VersionConflictApplicationProblemPlusJSONResponse
+}
+
+func (response DeleteProductByID409ApplicationProblemPlusJSONResponse) VisitDeleteProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProductByID412ApplicationProblemPlusJSONResponse struct {
+	PreconditionFailedApplicationProblemPlusJSONResponse
+}
+
+func (response DeleteProductByID412ApplicationProblemPlusJSONResponse) VisitDeleteProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(412)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProductByID500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response DeleteProductByID500ApplicationProblemPlusJSONResponse) VisitDeleteProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteProductByID503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response DeleteProductByID503ApplicationProblemPlusJSONResponse) VisitDeleteProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetProductByIDRequestObject struct {
+	Id     ProductId `json:"id"`
+	Params GetProductByIDParams
+}
+
+type GetProductByIDResponseObject interface {
+	VisitGetProductByIDResponse(w http.ResponseWriter) error
+}
+
+type GetProductByID200ResponseHeaders struct {
+	ETag string
+}
+
+type GetProductByID200JSONResponse struct {
+	Body    Product
+	Headers GetProductByID200ResponseHeaders
+}
+
+func (response GetProductByID200JSONResponse) VisitGetProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", fmt.Sprint(response.Headers.ETag))
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetProductByID304Response = NotModifiedResponse
+
+func (response GetProductByID304Response) VisitGetProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("ETag", fmt.Sprint(response.Headers.ETag))
+	w.WriteHeader(304)
+	return nil
+}
+
+type GetProductByID400ApplicationProblemPlusJSONResponse struct {
+	InvalidAsOfApplicationProblemPlusJSONResponse
+}
+
+func (response GetProductByID400ApplicationProblemPlusJSONResponse) VisitGetProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetProductByID404ApplicationProblemPlusJSONResponse struct {
+	NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response GetProductByID404ApplicationProblemPlusJSONResponse) VisitGetProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetProductByID500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response GetProductByID500ApplicationProblemPlusJSONResponse) VisitGetProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetProductByID503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response GetProductByID503ApplicationProblemPlusJSONResponse) VisitGetProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchProductByIDRequestObject struct {
+	Id     ProductId `json:"id"`
+	Params PatchProductByIDParams
+	Body   *PatchProductByIDApplicationMergePatchPlusJSONRequestBody
+}
+
+type PatchProductByIDResponseObject interface {
+	VisitPatchProductByIDResponse(w http.ResponseWriter) error
+}
+
+type PatchProductByID200JSONResponse Product
+
+func (response PatchProductByID200JSONResponse) VisitPatchProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchProductByID400ApplicationProblemPlusJSONResponse struct {
+	NoUpdateApplicationProblemPlusJSONResponse
+}
+
+func (response PatchProductByID400ApplicationProblemPlusJSONResponse) VisitPatchProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchProductByID404ApplicationProblemPlusJSONResponse struct {
+	NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response PatchProductByID404ApplicationProblemPlusJSONResponse) VisitPatchProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchProductByID409ApplicationProblemPlusJSONResponse struct {
+	VersionConflictApplicationProblemPlusJSONResponse
+}
+
+func (response PatchProductByID409ApplicationProblemPlusJSONResponse) VisitPatchProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchProductByID412ApplicationProblemPlusJSONResponse struct {
+	PreconditionFailedApplicationProblemPlusJSONResponse
+}
+
+func (response PatchProductByID412ApplicationProblemPlusJSONResponse) VisitPatchProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(412)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchProductByID500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response PatchProductByID500ApplicationProblemPlusJSONResponse) VisitPatchProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchProductByID503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response PatchProductByID503ApplicationProblemPlusJSONResponse) VisitPatchProductByIDResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpsertProductRequestObject struct {
+	Id   ProductId `json:"id"`
+	Body *UpsertProductFormdataRequestBody
+}
+
+type UpsertProductResponseObject interface {
+	VisitUpsertProductResponse(w http.ResponseWriter) error
+}
+
+type UpsertProduct200JSONResponse Product
+
+func (response UpsertProduct200JSONResponse) VisitUpsertProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpsertProduct201JSONResponse Product
+
+func (response UpsertProduct201JSONResponse) VisitUpsertProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpsertProduct400ApplicationProblemPlusJSONResponse struct {
+	InvalidProductApplicationProblemPlusJSONResponse
+}
+
+func (response UpsertProduct400ApplicationProblemPlusJSONResponse) VisitUpsertProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpsertProduct409ApplicationProblemPlusJSONResponse struct {
+	VersionConflictApplicationProblemPlusJSONResponse
+}
+
+func (response UpsertProduct409ApplicationProblemPlusJSONResponse) VisitUpsertProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpsertProduct500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response UpsertProduct500ApplicationProblemPlusJSONResponse) VisitUpsertProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpsertProduct503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response UpsertProduct503ApplicationProblemPlusJSONResponse) VisitUpsertProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PurgeProductsRequestObject struct {
+	Params PurgeProductsParams
+}
+
+type PurgeProductsResponseObject interface {
+	VisitPurgeProductsResponse(w http.ResponseWriter) error
+}
+
+type PurgeProducts200JSONResponse Purge
+
+func (response PurgeProducts200JSONResponse) VisitPurgeProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PurgeProducts400ApplicationProblemPlusJSONResponse Problem
+
+func (response PurgeProducts400ApplicationProblemPlusJSONResponse) VisitPurgeProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PurgeProducts500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response PurgeProducts500ApplicationProblemPlusJSONResponse) VisitPurgeProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PurgeProducts503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response PurgeProducts503ApplicationProblemPlusJSONResponse) VisitPurgeProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RestoreProductRequestObject struct {
+	Body *RestoreProductFormdataRequestBody
+}
+
+type RestoreProductResponseObject interface {
+	VisitRestoreProductResponse(w http.ResponseWriter) error
+}
+
+type RestoreProduct200JSONResponse Restore
+
+func (response RestoreProduct200JSONResponse) VisitRestoreProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RestoreProduct404ApplicationProblemPlusJSONResponse Problem
+
+func (response RestoreProduct404ApplicationProblemPlusJSONResponse) VisitRestoreProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RestoreProduct500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response RestoreProduct500ApplicationProblemPlusJSONResponse) VisitRestoreProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RestoreProduct503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response RestoreProduct503ApplicationProblemPlusJSONResponse) VisitRestoreProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SearchProductsRequestObject struct {
+	Params SearchProductsParams
+}
+
+type SearchProductsResponseObject interface {
+	VisitSearchProductsResponse(w http.ResponseWriter) error
+}
+
+type SearchProducts200JSONResponse SearchResult
+
+func (response SearchProducts200JSONResponse) VisitSearchProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SearchProducts400ApplicationProblemPlusJSONResponse struct {
+	InvalidSearchApplicationProblemPlusJSONResponse
+}
+
+func (response SearchProducts400ApplicationProblemPlusJSONResponse) VisitSearchProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SearchProducts500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response SearchProducts500ApplicationProblemPlusJSONResponse) VisitSearchProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SearchProducts503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response SearchProducts503ApplicationProblemPlusJSONResponse) VisitSearchProductsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchProductRequestObject struct {
+	Params PatchProductParams
+	Body   *PatchProductApplicationMergePatchPlusJSONRequestBody
+}
+
+type PatchProductResponseObject interface {
+	VisitPatchProductResponse(w http.ResponseWriter) error
+}
+
+type PatchProduct200JSONResponse Update
+
+func (response PatchProduct200JSONResponse) VisitPatchProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchProduct400ApplicationProblemPlusJSONResponse struct {
+	NoUpdateApplicationProblemPlusJSONResponse
+}
+
+func (response PatchProduct400ApplicationProblemPlusJSONResponse) VisitPatchProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchProduct404ApplicationProblemPlusJSONResponse struct {
+	NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response PatchProduct404ApplicationProblemPlusJSONResponse) VisitPatchProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchProduct409ApplicationProblemPlusJSONResponse struct {
+	VersionConflictApplicationProblemPlusJSONResponse
+}
+
+func (response PatchProduct409ApplicationProblemPlusJSONResponse) VisitPatchProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchProduct412ApplicationProblemPlusJSONResponse struct {
+	PreconditionFailedApplicationProblemPlusJSONResponse
+}
+
+func (response PatchProduct412ApplicationProblemPlusJSONResponse) VisitPatchProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(412)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchProduct500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response PatchProduct500ApplicationProblemPlusJSONResponse) VisitPatchProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type PatchProduct503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response PatchProduct503ApplicationProblemPlusJSONResponse) VisitPatchProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateProductRequestObject struct {
+	Params       UpdateProductParams
+	JSONBody     *UpdateProductJSONRequestBody
+	Body         io.Reader
+	FormdataBody *UpdateProductFormdataRequestBody
+}
+
+type UpdateProductResponseObject interface {
+	VisitUpdateProductResponse(w http.ResponseWriter) error
+}
+
+type UpdateProduct200JSONResponse Update
+
+func (response UpdateProduct200JSONResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateProduct200ApplicationmsgpackResponse struct {
+	Body          io.Reader
+	ContentLength int64
+}
+
+func (response UpdateProduct200ApplicationmsgpackResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/msgpack")
+	if response.ContentLength != 0 {
+		w.Header().Set("Content-Length", fmt.Sprint(response.ContentLength))
+	}
+	w.WriteHeader(200)
+
+	if closer, ok := response.Body.(io.ReadCloser); ok {
+		defer closer.Close()
+	}
+	_, err := io.Copy(w, response.Body)
+	return err
+}
+
+type UpdateProduct200ApplicationxProtobufResponse struct {
+	Body          io.Reader
+	ContentLength int64
+}
+
+func (response UpdateProduct200ApplicationxProtobufResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	if response.ContentLength != 0 {
+		w.Header().Set("Content-Length", fmt.Sprint(response.ContentLength))
+	}
+	w.WriteHeader(200)
+
+	if closer, ok := response.Body.(io.ReadCloser); ok {
+		defer closer.Close()
+	}
+	_, err := io.Copy(w, response.Body)
+	return err
+}
+
+type UpdateProduct400ApplicationProblemPlusJSONResponse struct {
+	NoUpdateApplicationProblemPlusJSONResponse
+}
+
+func (response UpdateProduct400ApplicationProblemPlusJSONResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateProduct404ApplicationProblemPlusJSONResponse struct {
+	NotFoundApplicationProblemPlusJSONResponse
+}
+
+func (response UpdateProduct404ApplicationProblemPlusJSONResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateProduct406ApplicationProblemPlusJSONResponse struct {
+	NotAcceptableApplicationProblemPlusJSONResponse
+}
+
+func (response UpdateProduct406ApplicationProblemPlusJSONResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(406)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateProduct409ApplicationProblemPlusJSONResponse struct {
+	VersionConflictApplicationProblemPlusJSONResponse
+}
+
+func (response UpdateProduct409ApplicationProblemPlusJSONResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateProduct412ApplicationProblemPlusJSONResponse struct {
+	PreconditionFailedApplicationProblemPlusJSONResponse
+}
+
+func (response UpdateProduct412ApplicationProblemPlusJSONResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(412)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateProduct415ApplicationProblemPlusJSONResponse struct {
+	UnsupportedMediaTypeApplicationProblemPlusJSONResponse
+}
+
+func (response UpdateProduct415ApplicationProblemPlusJSONResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(415)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateProduct500ApplicationProblemPlusJSONResponse struct {
+	DbIssueApplicationProblemPlusJSONResponse
+}
+
+func (response UpdateProduct500ApplicationProblemPlusJSONResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(500)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateProduct503ApplicationProblemPlusJSONResponse struct {
+	UnavailableApplicationProblemPlusJSONResponse
+}
+
+func (response UpdateProduct503ApplicationProblemPlusJSONResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(503)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+// StrictServerInterface represents all server handlers.
+type StrictServerInterface interface {
+	// Returns information about the request, it can be not just get but any method
+	// (GET /)
+	GetMain(ctx context.Context, request GetMainRequestObject) (GetMainResponseObject, error)
+	// Adds new product
+	// (POST /add)
+	AddProduct(ctx context.Context, request AddProductRequestObject) (AddProductResponseObject, error)
+	// Soft deletes products in one go, only id and version of the items are used
+	// (DELETE /batch)
+	DeleteProducts(ctx context.Context, request DeleteProductsRequestObject) (DeleteProductsResponseObject, error)
+	// Adds products in one go, the failed items don't prevent the others from being added
+	// (POST /batch)
+	AddProducts(ctx context.Context, request AddProductsRequestObject) (AddProductsResponseObject, error)
+	// Updates products in one go, the items are merge patches along with the ids and the versions
+	// (PUT /batch)
+	UpdateProducts(ctx context.Context, request UpdateProductsRequestObject) (UpdateProductsResponseObject, error)
+	// Soft deletes product, it's hidden from get and listing until restored
+	// (DELETE /delete)
+	DeleteProduct(ctx context.Context, request DeleteProductRequestObject) (DeleteProductResponseObject, error)
+	// Gets the product
+	// (GET /get)
+	GetProduct(ctx context.Context, request GetProductRequestObject) (GetProductResponseObject, error)
+	// Gets a page of filtered and sorted products
+	// (GET /get_all)
+	GetProducts(ctx context.Context, request GetProductsRequestObject) (GetProductsResponseObject, error)
+	// Gets the changes of the product, the changes are attributed to the X-Actor header of their requests
+	// (GET /history)
+	GetProductHistory(ctx context.Context, request GetProductHistoryRequestObject) (GetProductHistoryResponseObject, error)
+	// Gets a page of filtered and sorted products
+	// (GET /products)
+	ListProducts(ctx context.Context, request ListProductsRequestObject) (ListProductsResponseObject, error)
+	// Creates product, it's returned along with its location
+	// (POST /products)
+	CreateProduct(ctx context.Context, request CreateProductRequestObject) (CreateProductResponseObject, error)
+	// Streams the changes of the products made through any server of the process as server-sent events
+	// (GET /products/events)
+	StreamProductEvents(ctx context.Context, request StreamProductEventsRequestObject) (StreamProductEventsResponseObject, error)
+	// Soft deletes product, it's hidden from get and listing until restored
+	// (DELETE /products/{id})
+	DeleteProductByID(ctx context.Context, request DeleteProductByIDRequestObject) (DeleteProductByIDResponseObject, error)
+	// Gets the product
+	// (GET /products/{id})
+	GetProductByID(ctx context.Context, request GetProductByIDRequestObject) (GetProductByIDResponseObject, error)
+	// Updates product with a JSON merge patch, null clears the field, the updated product is returned
+	// (PATCH /products/{id})
+	PatchProductByID(ctx context.Context, request PatchProductByIDRequestObject) (PatchProductByIDResponseObject, error)
+	// Creates product with the given id or replaces its name, price and currency, restoring it when deleted
+	// (PUT /products/{id})
+	UpsertProduct(ctx context.Context, request UpsertProductRequestObject) (UpsertProductResponseObject, error)
+	// Permanently removes products soft deleted more than the given number of days ago
+	// (DELETE /purge)
+	PurgeProducts(ctx context.Context, request PurgeProductsRequestObject) (PurgeProductsResponseObject, error)
+	// Restores soft deleted product
+	// (POST /restore)
+	RestoreProduct(ctx context.Context, request RestoreProductRequestObject) (RestoreProductResponseObject, error)
+	// Searches the live products by name, the most relevant first
+	// (GET /search)
+	SearchProducts(ctx context.Context, request SearchProductsRequestObject) (SearchProductsResponseObject, error)
+	// Updates product with a JSON merge patch, null clears the field
+	// (PATCH /update)
+	PatchProduct(ctx context.Context, request PatchProductRequestObject) (PatchProductResponseObject, error)
+	// Updates product
+	// (PUT /update)
+	UpdateProduct(ctx context.Context, request UpdateProductRequestObject) (UpdateProductResponseObject, error)
+}
+
+type StrictHandlerFunc = strictnethttp.StrictHTTPHandlerFunc
+type StrictMiddlewareFunc = strictnethttp.StrictHTTPMiddlewareFunc
+
+type StrictHTTPServerOptions struct {
+	RequestErrorHandlerFunc  func(w http.ResponseWriter, r *http.Request, err error)
+	ResponseErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+func NewStrictHandler(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc) ServerInterface {
+	return &strictHandler{ssi: ssi, middlewares: middlewares, options: StrictHTTPServerOptions{
+		RequestErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		},
+		ResponseErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		},
+	}}
+}
+
+func NewStrictHandlerWithOptions(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc, options StrictHTTPServerOptions) ServerInterface {
+	return &strictHandler{ssi: ssi, middlewares: middlewares, options: options}
+}
+
+type strictHandler struct {
+	ssi         StrictServerInterface
+	middlewares []StrictMiddlewareFunc
+	options     StrictHTTPServerOptions
+}
+
+// GetMain operation middleware
+func (sh *strictHandler) GetMain(w http.ResponseWriter, r *http.Request) {
+	var request GetMainRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetMain(ctx, request.(GetMainRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetMain")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetMainResponseObject); ok {
+		if err := validResponse.VisitGetMainResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// AddProduct operation middleware
+func (sh *strictHandler) AddProduct(w http.ResponseWriter, r *http.Request) {
+	var request AddProductRequestObject
+
+	if err := r.ParseForm(); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode formdata: %w", err))
+		return
+	}
+	var body AddProductFormdataRequestBody
+	if err := runtime.BindForm(&body, r.Form, nil, nil); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't bind formdata: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.AddProduct(ctx, request.(AddProductRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "AddProduct")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(AddProductResponseObject); ok {
+		if err := validResponse.VisitAddProductResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteProducts operation middleware
+func (sh *strictHandler) DeleteProducts(w http.ResponseWriter, r *http.Request) {
+	var request DeleteProductsRequestObject
+
+	var body DeleteProductsJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteProducts(ctx, request.(DeleteProductsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteProducts")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(DeleteProductsResponseObject); ok {
+		if err := validResponse.VisitDeleteProductsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// AddProducts operation middleware
+func (sh *strictHandler) AddProducts(w http.ResponseWriter, r *http.Request) {
+	var request AddProductsRequestObject
+
+	var body AddProductsJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.AddProducts(ctx, request.(AddProductsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "AddProducts")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(AddProductsResponseObject); ok {
+		if err := validResponse.VisitAddProductsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// UpdateProducts operation middleware
+func (sh *strictHandler) UpdateProducts(w http.ResponseWriter, r *http.Request) {
+	var request UpdateProductsRequestObject
+
+	var body UpdateProductsJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.UpdateProducts(ctx, request.(UpdateProductsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "UpdateProducts")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(UpdateProductsResponseObject); ok {
+		if err := validResponse.VisitUpdateProductsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteProduct operation middleware
+func (sh *strictHandler) DeleteProduct(w http.ResponseWriter, r *http.Request, params DeleteProductParams) {
+	var request DeleteProductRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteProduct(ctx, request.(DeleteProductRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteProduct")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(DeleteProductResponseObject); ok {
+		if err := validResponse.VisitDeleteProductResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetProduct operation middleware
+func (sh *strictHandler) GetProduct(w http.ResponseWriter, r *http.Request, params GetProductParams) {
+	var request GetProductRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetProduct(ctx, request.(GetProductRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetProduct")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetProductResponseObject); ok {
+		if err := validResponse.VisitGetProductResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetProducts operation middleware
+func (sh *strictHandler) GetProducts(w http.ResponseWriter, r *http.Request, params GetProductsParams) {
+	var request GetProductsRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetProducts(ctx, request.(GetProductsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetProducts")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetProductsResponseObject); ok {
+		if err := validResponse.VisitGetProductsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetProductHistory operation middleware
+func (sh *strictHandler) GetProductHistory(w http.ResponseWriter, r *http.Request, params GetProductHistoryParams) {
+	var request GetProductHistoryRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetProductHistory(ctx, request.(GetProductHistoryRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetProductHistory")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetProductHistoryResponseObject); ok {
+		if err := validResponse.VisitGetProductHistoryResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
-type GetProductRequestObject struct {
-	Params GetProductParams
-}
+// ListProducts operation middleware
+func (sh *strictHandler) ListProducts(w http.ResponseWriter, r *http.Request, params ListProductsParams) {
+	var request ListProductsRequestObject
 
-type GetProductResponseObject interface {
-	VisitGetProductResponse(w http.ResponseWriter) error
-}
+	request.Params = params
 
-type GetProduct200JSONResponse Product
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListProducts(ctx, request.(ListProductsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListProducts")
+	}
 
-func (response GetProduct200JSONResponse) VisitGetProductResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(200)
+	response, err := handler(r.Context(), w, r, request)
 
-	return json.NewEncoder(w).Encode(response)
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListProductsResponseObject); ok {
+		if err := validResponse.VisitListProductsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
-type GetProduct400JSONResponse struct{ NoRowsJSONResponse }
+// CreateProduct operation middleware
+func (sh *strictHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	var request CreateProductRequestObject
 
-func (response GetProduct400JSONResponse) VisitGetProductResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(400)
-
-	return json.NewEncoder(w).Encode(response)
-}
+	if err := r.ParseForm(); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode formdata: %w", err))
+		return
+	}
+	var body CreateProductFormdataRequestBody
+	if err := runtime.BindForm(&body, r.Form, nil, nil); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't bind formdata: %w", err))
+		return
+	}
+	request.Body = &body
 
-type GetProduct500JSONResponse struct{ DbIssueJSONResponse }
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.CreateProduct(ctx, request.(CreateProductRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "CreateProduct")
+	}
 
-func (response GetProduct500JSONResponse) VisitGetProductResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(500)
+	response, err := handler(r.Context(), w, r, request)
 
-	return json.NewEncoder(w).Encode(response)
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(CreateProductResponseObject); ok {
+		if err := validResponse.VisitCreateProductResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
-type GetProductsRequestObject struct {
-}
+// StreamProductEvents operation middleware
+func (sh *strictHandler) StreamProductEvents(w http.ResponseWriter, r *http.Request, params StreamProductEventsParams) {
+	var request StreamProductEventsRequestObject
 
-type GetProductsResponseObject interface {
-	VisitGetProductsResponse(w http.ResponseWriter) error
-}
+	request.Params = params
 
-type GetProducts200JSONResponse Products
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.StreamProductEvents(ctx, request.(StreamProductEventsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "StreamProductEvents")
+	}
 
-func (response GetProducts200JSONResponse) VisitGetProductsResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(200)
+	response, err := handler(r.Context(), w, r, request)
 
-	return json.NewEncoder(w).Encode(response)
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(StreamProductEventsResponseObject); ok {
+		if err := validResponse.VisitStreamProductEventsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
-type GetProducts500JSONResponse struct{ DbIssueJSONResponse }
+// DeleteProductByID operation middleware
+func (sh *strictHandler) DeleteProductByID(w http.ResponseWriter, r *http.Request, id ProductId, params DeleteProductByIDParams) {
+	var request DeleteProductByIDRequestObject
 
-func (response GetProducts500JSONResponse) VisitGetProductsResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(500)
+	request.Id = id
+	request.Params = params
 
-	return json.NewEncoder(w).Encode(response)
-}
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteProductByID(ctx, request.(DeleteProductByIDRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteProductByID")
+	}
 
-type UpdateProductRequestObject struct {
-	Body *UpdateProductFormdataRequestBody
-}
+	response, err := handler(r.Context(), w, r, request)
 
-type UpdateProductResponseObject interface {
-	VisitUpdateProductResponse(w http.ResponseWriter) error
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(DeleteProductByIDResponseObject); ok {
+		if err := validResponse.VisitDeleteProductByIDResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
-type UpdateProduct200JSONResponse Update
-
-func (response UpdateProduct200JSONResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(200)
+// GetProductByID operation middleware
+func (sh *strictHandler) GetProductByID(w http.ResponseWriter, r *http.Request, id ProductId, params GetProductByIDParams) {
+	var request GetProductByIDRequestObject
 
-	return json.NewEncoder(w).Encode(response)
-}
+	request.Id = id
+	request.Params = params
 
-type UpdateProduct400JSONResponse struct{ NoUpdateJSONResponse }
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetProductByID(ctx, request.(GetProductByIDRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetProductByID")
+	}
 
-func (response UpdateProduct400JSONResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(400)
+	response, err := handler(r.Context(), w, r, request)
 
-	return json.NewEncoder(w).Encode(response)
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetProductByIDResponseObject); ok {
+		if err := validResponse.VisitGetProductByIDResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
-type UpdateProduct500JSONResponse struct{ DbIssueJSONResponse }
-
-func (response UpdateProduct500JSONResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(500)
+// PatchProductByID operation middleware
+func (sh *strictHandler) PatchProductByID(w http.ResponseWriter, r *http.Request, id ProductId, params PatchProductByIDParams) {
+	var request PatchProductByIDRequestObject
 
-	return json.NewEncoder(w).Encode(response)
-}
+	request.Id = id
+	request.Params = params
 
-// StrictServerInterface represents all server handlers.
-type StrictServerInterface interface {
-	// Returns information about the request, it can be not just get but any method
-	// (GET /)
-	GetMain(ctx context.Context, request GetMainRequestObject) (GetMainResponseObject, error)
-	// Adds new product
-	// (POST /add)
-	AddProduct(ctx context.Context, request AddProductRequestObject) (AddProductResponseObject, error)
-	// Deletes product
-	// (DELETE /delete)
-	DeleteProduct(ctx context.Context, request DeleteProductRequestObject) (DeleteProductResponseObject, error)
-	// Gets the product
-	// (GET /get)
-	GetProduct(ctx context.Context, request GetProductRequestObject) (GetProductResponseObject, error)
-	// Gets products
-	// (GET /get_all)
-	GetProducts(ctx context.Context, request GetProductsRequestObject) (GetProductsResponseObject, error)
-	// Updates product
-	// (PUT /update)
-	UpdateProduct(ctx context.Context, request UpdateProductRequestObject) (UpdateProductResponseObject, error)
-}
+	var body PatchProductByIDApplicationMergePatchPlusJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
 
-type StrictHandlerFunc = strictnethttp.StrictHTTPHandlerFunc
-type StrictMiddlewareFunc = strictnethttp.StrictHTTPMiddlewareFunc
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.PatchProductByID(ctx, request.(PatchProductByIDRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "PatchProductByID")
+	}
 
-type StrictHTTPServerOptions struct {
-	RequestErrorHandlerFunc  func(w http.ResponseWriter, r *http.Request, err error)
-	ResponseErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
-}
+	response, err := handler(r.Context(), w, r, request)
 
-func NewStrictHandler(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc) ServerInterface {
-	return &strictHandler{ssi: ssi, middlewares: middlewares, options: StrictHTTPServerOptions{
-		RequestErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		},
-		ResponseErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		},
-	}}
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(PatchProductByIDResponseObject); ok {
+		if err := validResponse.VisitPatchProductByIDResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
 }
 
-func NewStrictHandlerWithOptions(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc, options StrictHTTPServerOptions) ServerInterface {
-	return &strictHandler{ssi: ssi, middlewares: middlewares, options: options}
-}
+// UpsertProduct operation middleware
+func (sh *strictHandler) UpsertProduct(w http.ResponseWriter, r *http.Request, id ProductId) {
+	var request UpsertProductRequestObject
 
-type strictHandler struct {
-	ssi         StrictServerInterface
-	middlewares []StrictMiddlewareFunc
-	options     StrictHTTPServerOptions
-}
+	request.Id = id
 
-// GetMain operation middleware
-func (sh *strictHandler) GetMain(w http.ResponseWriter, r *http.Request) {
-	var request GetMainRequestObject
+	if err := r.ParseForm(); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode formdata: %w", err))
+		return
+	}
+	var body UpsertProductFormdataRequestBody
+	if err := runtime.BindForm(&body, r.Form, nil, nil); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't bind formdata: %w", err))
+		return
+	}
+	request.Body = &body
 
 	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
-		return sh.ssi.GetMain(ctx, request.(GetMainRequestObject))
+		return sh.ssi.UpsertProduct(ctx, request.(UpsertProductRequestObject))
 	}
 	for _, middleware := range sh.middlewares {
-		handler = middleware(handler, "GetMain")
+		handler = middleware(handler, "UpsertProduct")
 	}
 
 	response, err := handler(r.Context(), w, r, request)
 
 	if err != nil {
 		sh.options.ResponseErrorHandlerFunc(w, r, err)
-	} else if validResponse, ok := response.(GetMainResponseObject); ok {
-		if err := validResponse.VisitGetMainResponse(w); err != nil {
+	} else if validResponse, ok := response.(UpsertProductResponseObject); ok {
+		if err := validResponse.VisitUpsertProductResponse(w); err != nil {
 			sh.options.ResponseErrorHandlerFunc(w, r, err)
 		}
 	} else if response != nil {
@@ -766,23 +3720,25 @@ func (sh *strictHandler) GetMain(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// AddProduct operation middleware
-func (sh *strictHandler) AddProduct(w http.ResponseWriter, r *http.Request) {
-	var request AddProductRequestObject
+// PurgeProducts operation middleware
+func (sh *strictHandler) PurgeProducts(w http.ResponseWriter, r *http.Request, params PurgeProductsParams) {
+	var request PurgeProductsRequestObject
+
+	request.Params = params
 
 	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
-		return sh.ssi.AddProduct(ctx, request.(AddProductRequestObject))
+		return sh.ssi.PurgeProducts(ctx, request.(PurgeProductsRequestObject))
 	}
 	for _, middleware := range sh.middlewares {
-		handler = middleware(handler, "AddProduct")
+		handler = middleware(handler, "PurgeProducts")
 	}
 
 	response, err := handler(r.Context(), w, r, request)
 
 	if err != nil {
 		sh.options.ResponseErrorHandlerFunc(w, r, err)
-	} else if validResponse, ok := response.(AddProductResponseObject); ok {
-		if err := validResponse.VisitAddProductResponse(w); err != nil {
+	} else if validResponse, ok := response.(PurgeProductsResponseObject); ok {
+		if err := validResponse.VisitPurgeProductsResponse(w); err != nil {
 			sh.options.ResponseErrorHandlerFunc(w, r, err)
 		}
 	} else if response != nil {
@@ -790,25 +3746,34 @@ func (sh *strictHandler) AddProduct(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// DeleteProduct operation middleware
-func (sh *strictHandler) DeleteProduct(w http.ResponseWriter, r *http.Request, params DeleteProductParams) {
-	var request DeleteProductRequestObject
+// RestoreProduct operation middleware
+func (sh *strictHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	var request RestoreProductRequestObject
 
-	request.Params = params
+	if err := r.ParseForm(); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode formdata: %w", err))
+		return
+	}
+	var body RestoreProductFormdataRequestBody
+	if err := runtime.BindForm(&body, r.Form, nil, nil); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't bind formdata: %w", err))
+		return
+	}
+	request.Body = &body
 
 	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
-		return sh.ssi.DeleteProduct(ctx, request.(DeleteProductRequestObject))
+		return sh.ssi.RestoreProduct(ctx, request.(RestoreProductRequestObject))
 	}
 	for _, middleware := range sh.middlewares {
-		handler = middleware(handler, "DeleteProduct")
+		handler = middleware(handler, "RestoreProduct")
 	}
 
 	response, err := handler(r.Context(), w, r, request)
 
 	if err != nil {
 		sh.options.ResponseErrorHandlerFunc(w, r, err)
-	} else if validResponse, ok := response.(DeleteProductResponseObject); ok {
-		if err := validResponse.VisitDeleteProductResponse(w); err != nil {
+	} else if validResponse, ok := response.(RestoreProductResponseObject); ok {
+		if err := validResponse.VisitRestoreProductResponse(w); err != nil {
 			sh.options.ResponseErrorHandlerFunc(w, r, err)
 		}
 	} else if response != nil {
@@ -816,25 +3781,25 @@ func (sh *strictHandler) DeleteProduct(w http.ResponseWriter, r *http.Request, p
 	}
 }
 
-// GetProduct operation middleware
-func (sh *strictHandler) GetProduct(w http.ResponseWriter, r *http.Request, params GetProductParams) {
-	var request GetProductRequestObject
+// SearchProducts operation middleware
+func (sh *strictHandler) SearchProducts(w http.ResponseWriter, r *http.Request, params SearchProductsParams) {
+	var request SearchProductsRequestObject
 
 	request.Params = params
 
 	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
-		return sh.ssi.GetProduct(ctx, request.(GetProductRequestObject))
+		return sh.ssi.SearchProducts(ctx, request.(SearchProductsRequestObject))
 	}
 	for _, middleware := range sh.middlewares {
-		handler = middleware(handler, "GetProduct")
+		handler = middleware(handler, "SearchProducts")
 	}
 
 	response, err := handler(r.Context(), w, r, request)
 
 	if err != nil {
 		sh.options.ResponseErrorHandlerFunc(w, r, err)
-	} else if validResponse, ok := response.(GetProductResponseObject); ok {
-		if err := validResponse.VisitGetProductResponse(w); err != nil {
+	} else if validResponse, ok := response.(SearchProductsResponseObject); ok {
+		if err := validResponse.VisitSearchProductsResponse(w); err != nil {
 			sh.options.ResponseErrorHandlerFunc(w, r, err)
 		}
 	} else if response != nil {
@@ -842,23 +3807,32 @@ func (sh *strictHandler) GetProduct(w http.ResponseWriter, r *http.Request, para
 	}
 }
 
-// GetProducts operation middleware
-func (sh *strictHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
-	var request GetProductsRequestObject
+// PatchProduct operation middleware
+func (sh *strictHandler) PatchProduct(w http.ResponseWriter, r *http.Request, params PatchProductParams) {
+	var request PatchProductRequestObject
+
+	request.Params = params
+
+	var body PatchProductApplicationMergePatchPlusJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
 
 	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
-		return sh.ssi.GetProducts(ctx, request.(GetProductsRequestObject))
+		return sh.ssi.PatchProduct(ctx, request.(PatchProductRequestObject))
 	}
 	for _, middleware := range sh.middlewares {
-		handler = middleware(handler, "GetProducts")
+		handler = middleware(handler, "PatchProduct")
 	}
 
 	response, err := handler(r.Context(), w, r, request)
 
 	if err != nil {
 		sh.options.ResponseErrorHandlerFunc(w, r, err)
-	} else if validResponse, ok := response.(GetProductsResponseObject); ok {
-		if err := validResponse.VisitGetProductsResponse(w); err != nil {
+	} else if validResponse, ok := response.(PatchProductResponseObject); ok {
+		if err := validResponse.VisitPatchProductResponse(w); err != nil {
 			sh.options.ResponseErrorHandlerFunc(w, r, err)
 		}
 	} else if response != nil {
@@ -867,19 +3841,34 @@ func (sh *strictHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateProduct operation middleware
-func (sh *strictHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+func (sh *strictHandler) UpdateProduct(w http.ResponseWriter, r *http.Request, params UpdateProductParams) {
 	var request UpdateProductRequestObject
 
-	if err := r.ParseForm(); err != nil {
-		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode formdata: %w", err))
-		return
+	request.Params = params
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+
+		var body UpdateProductJSONRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+			return
+		}
+		request.JSONBody = &body
 	}
-	var body UpdateProductFormdataRequestBody
-	if err := runtime.BindForm(&body, r.Form, nil, nil); err != nil {
-		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't bind formdata: %w", err))
-		return
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/msgpack") {
+		request.Body = r.Body
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		if err := r.ParseForm(); err != nil {
+			sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode formdata: %w", err))
+			return
+		}
+		var body UpdateProductFormdataRequestBody
+		if err := runtime.BindForm(&body, r.Form, nil, nil); err != nil {
+			sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't bind formdata: %w", err))
+			return
+		}
+		request.FormdataBody = &body
 	}
-	request.Body = &body
 
 	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
 		return sh.ssi.UpdateProduct(ctx, request.(UpdateProductRequestObject))
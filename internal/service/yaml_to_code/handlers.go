@@ -3,15 +3,134 @@ package yaml_to_code
 import (
 	"bytes"
 	"context"
-	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
+	"github.com/aleksandrzhukovskii/go-template/internal/service/content"
 )
 
+// errorResponse is the error response of every operation
+type errorResponse model.Problem
+
+func newErrorResponse(ctx context.Context, err error) errorResponse {
+	r := ctx.Value(reqKey).(*http.Request)
+	return errorResponse(model.NewProblem(ctx, err, r.URL.RequestURI()))
+}
+
+// sendProblem reports the errors of the generated code
+func sendProblem(w http.ResponseWriter, r *http.Request, err error) {
+	_ = errorResponse(model.NewProblem(r.Context(), err, r.URL.RequestURI())).visit(w)
+}
+
+func paramError(err error) error {
+	var required *RequiredParamError
+	var format *InvalidParamFormatError
+	var tooMany *TooManyValuesForParamError
+	var unmarshaling *UnmarshalingParamError
+	switch {
+	case errors.As(err, &required):
+		return model.InvalidField(required.ParamName, err)
+	case errors.As(err, &format):
+		return model.InvalidField(format.ParamName, err)
+	case errors.As(err, &tooMany):
+		return model.InvalidField(tooMany.ParamName, err)
+	case errors.As(err, &unmarshaling):
+		return model.InvalidField(unmarshaling.ParamName, err)
+	}
+	return model.Errorf(model.InvalidArgument, "%w", err)
+}
+
+func (e errorResponse) visit(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", model.ProblemContentType)
+	w.WriteHeader(e.Status)
+	return json.NewEncoder(w).Encode(model.Problem(e))
+}
+
+func (e errorResponse) VisitAddProductResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitAddProductsResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitUpsertProductResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitDeleteProductResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitDeleteProductsResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitGetProductResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitGetProductHistoryResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitGetProductsResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitSearchProductsResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitPurgeProductsResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitRestoreProductResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitUpdateProductResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitPatchProductResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitUpdateProductsResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitCreateProductResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitListProductsResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitGetProductByIDResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitPatchProductByIDResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitDeleteProductByIDResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
+func (e errorResponse) VisitStreamProductEventsResponse(w http.ResponseWriter) error {
+	return e.visit(w)
+}
+
 func (s *Service) GetMain(ctx context.Context, _ GetMainRequestObject) (GetMainResponseObject, error) {
 	r := ctx.Value(reqKey).(*http.Request)
 	var body []byte
@@ -42,100 +161,444 @@ func (s *Service) GetMain(ctx context.Context, _ GetMainRequestObject) (GetMainR
 	return GetMain200TextResponse(response.String()), nil
 }
 
-func (s *Service) AddProduct(ctx context.Context, _ AddProductRequestObject) (AddProductResponseObject, error) {
-	id, err := s.db.Add(ctx)
+func (s *Service) AddProduct(ctx context.Context, request AddProductRequestObject) (AddProductResponseObject, error) {
+	val, err := s.addProduct(ctx, *request.Body)
 	if err != nil {
-		return AddProduct500JSONResponse{
-			DbIssueJSONResponse{
-				Error: err.Error(),
-			},
-		}, nil
+		return newErrorResponse(ctx, err), nil
 	}
-	return AddProduct200JSONResponse{
-		Id: id,
+	return AddProduct200JSONResponse(val), nil
+}
+
+func (s *Service) CreateProduct(ctx context.Context, request CreateProductRequestObject) (CreateProductResponseObject, error) {
+	val, err := s.addProduct(ctx, *request.Body)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	return CreateProduct201JSONResponse{
+		Body: val,
+		Headers: CreateProduct201ResponseHeaders{
+			Location: model.ProductLocation(val.ID),
+		},
 	}, nil
 }
 
-func (s *Service) DeleteProduct(ctx context.Context, request DeleteProductRequestObject) (DeleteProductResponseObject, error) {
-	if err := s.db.Delete(ctx, request.Params.Id); err != nil {
-		if errors.Is(err, model.ErrorNoRowsDeleted) {
-			return DeleteProduct400JSONResponse{
-				Error: err.Error(),
-			}, nil
+func (s *Service) addProduct(ctx context.Context, body AddRequest) (model.Product, error) {
+	prod := model.SampleProduct()
+	if body.Sample == nil || !*body.Sample {
+		var id, name, price, currency string
+		if body.Id != nil {
+			id = *body.Id
 		}
-		return DeleteProduct500JSONResponse{
-			DbIssueJSONResponse{
-				Error: err.Error(),
-			},
-		}, nil
+		if body.Name != nil {
+			name = *body.Name
+		}
+		if body.Price != nil {
+			price = *body.Price
+		}
+		if body.Currency != nil {
+			currency = *body.Currency
+		}
+		var err error
+		prod, err = model.ParseNewProduct(id, name, price, currency)
+		if err != nil {
+			return model.Product{}, err
+		}
+	}
+	return s.db.Add(ctx, prod)
+}
+
+func (s *Service) AddProducts(ctx context.Context, request AddProductsRequestObject) (AddProductsResponseObject, error) {
+	val, err := s.db.AddBatch(ctx, *request.Body)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	return AddProducts200JSONResponse{
+		BatchJSONResponse{
+			Results: val,
+		},
+	}, nil
+}
+
+func (s *Service) UpsertProduct(ctx context.Context, request UpsertProductRequestObject) (UpsertProductResponseObject, error) {
+	prod, err := model.ParseNewProduct(request.Id, request.Body.Name, request.Body.Price, request.Body.Currency)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+
+	val, created, err := s.db.Upsert(ctx, prod)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	if created {
+		return UpsertProduct201JSONResponse(val), nil
+	}
+	return UpsertProduct200JSONResponse(val), nil
+}
+
+func (s *Service) DeleteProduct(ctx context.Context, request DeleteProductRequestObject) (DeleteProductResponseObject, error) {
+	var version uint64
+	if request.Params.Version != nil {
+		version = *request.Params.Version
+	}
+	version, err := s.checkIfMatch(ctx, request.Params.Id, version, request.Params.IfMatch)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	if _, err := s.db.Delete(ctx, request.Params.Id, version); err != nil {
+		return newErrorResponse(ctx, err), nil
 	}
 	return DeleteProduct200JSONResponse{
 		Msg: "Product deleted",
 	}, nil
 }
 
+func (s *Service) DeleteProductByID(ctx context.Context, request DeleteProductByIDRequestObject) (DeleteProductByIDResponseObject, error) {
+	var version uint64
+	if request.Params.Version != nil {
+		version = *request.Params.Version
+	}
+	version, err := s.checkIfMatch(ctx, request.Id, version, request.Params.IfMatch)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	if _, err := s.db.Delete(ctx, request.Id, version); err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	return DeleteProductByID204Response{}, nil
+}
+
+func (s *Service) DeleteProducts(ctx context.Context, request DeleteProductsRequestObject) (DeleteProductsResponseObject, error) {
+	val, err := s.db.DeleteBatch(ctx, *request.Body)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	return DeleteProducts200JSONResponse{
+		BatchJSONResponse{
+			Results: val,
+		},
+	}, nil
+}
+
 func (s *Service) GetProduct(ctx context.Context, request GetProductRequestObject) (GetProductResponseObject, error) {
-	val, err := s.db.Get(ctx, request.Params.Id)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return GetProduct400JSONResponse{
-				NoRowsJSONResponse{
-					Error: err.Error(),
-				},
-			}, nil
-		}
-		return GetProduct500JSONResponse{
-			DbIssueJSONResponse{
-				Error: err.Error(),
-			},
-		}, nil
+	val, err := model.GetAsOf(ctx, s.db, request.Params.Id, request.Params.AsOf)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	etag, notModified, err := tag(val, request.Params.IfNoneMatch)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
 	}
-	return GetProduct200JSONResponse(val), nil
+	if notModified {
+		return GetProduct304Response{Headers: NotModifiedResponseHeaders{ETag: etag}}, nil
+	}
+	return GetProduct200JSONResponse{Body: val, Headers: GetProduct200ResponseHeaders{ETag: etag}}, nil
 }
 
-func (s *Service) GetProducts(ctx context.Context, _ GetProductsRequestObject) (GetProductsResponseObject, error) {
-	val, err := s.db.GetAll(ctx)
+func (s *Service) GetProductByID(ctx context.Context, request GetProductByIDRequestObject) (GetProductByIDResponseObject, error) {
+	val, err := model.GetAsOf(ctx, s.db, request.Id, request.Params.AsOf)
 	if err != nil {
-		return GetProducts500JSONResponse{
-			DbIssueJSONResponse{
-				Error: err.Error(),
-			},
-		}, nil
+		return newErrorResponse(ctx, err), nil
+	}
+	etag, notModified, err := tag(val, request.Params.IfNoneMatch)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	if notModified {
+		return GetProductByID304Response{Headers: NotModifiedResponseHeaders{ETag: etag}}, nil
 	}
-	return GetProducts200JSONResponse(val), nil
+	return GetProductByID200JSONResponse{Body: val, Headers: GetProductByID200ResponseHeaders{ETag: etag}}, nil
 }
 
-func (s *Service) UpdateProduct(ctx context.Context, request UpdateProductRequestObject) (UpdateProductResponseObject, error) {
-	if request.Body.Name == nil && request.Body.Price == nil {
-		return UpdateProduct400JSONResponse{NoUpdateJSONResponse{
-			Error: model.ErrorNoUpdateParams.Error(),
-		}}, nil
+func (s *Service) GetProductHistory(ctx context.Context, request GetProductHistoryRequestObject) (GetProductHistoryResponseObject, error) {
+	entries, err := s.db.History(ctx, request.Params.Id)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	return GetProductHistory200JSONResponse{Entries: entries}, nil
+}
+
+func (s *Service) GetProducts(ctx context.Context, request GetProductsRequestObject) (GetProductsResponseObject, error) {
+	val, err := s.getProducts(ctx, request.Params)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	etag, notModified, err := tag(val, request.Params.IfNoneMatch)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	if notModified {
+		return GetProducts304Response{Headers: NotModifiedResponseHeaders{ETag: etag}}, nil
+	}
+	return GetProducts200JSONResponse{Body: val, Headers: GetProducts200ResponseHeaders{ETag: etag}}, nil
+}
+
+func (s *Service) ListProducts(ctx context.Context, request ListProductsRequestObject) (ListProductsResponseObject, error) {
+	val, err := s.getProducts(ctx, GetProductsParams(request.Params))
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	etag, notModified, err := tag(val, request.Params.IfNoneMatch)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	if notModified {
+		return ListProducts304Response{Headers: NotModifiedResponseHeaders{ETag: etag}}, nil
+	}
+	return ListProducts200JSONResponse{Body: val, Headers: ListProducts200ResponseHeaders{ETag: etag}}, nil
+}
+
+func (s *Service) getProducts(ctx context.Context, params GetProductsParams) (model.ProductPage, error) {
+	query := model.ProductQuery{
+		Filter: model.ProductFilter{
+			CreatedFrom: params.CreatedFrom,
+			CreatedTo:   params.CreatedTo,
+		},
+	}
+	var minPrice, maxPrice string
+	if params.MinPrice != nil {
+		minPrice = *params.MinPrice
+	}
+	if params.MaxPrice != nil {
+		maxPrice = *params.MaxPrice
+	}
+	var err error
+	if query.Filter.MinPrice, err = model.ParsePriceBound(minPrice, "min price"); err == nil {
+		query.Filter.MaxPrice, err = model.ParsePriceBound(maxPrice, "max price")
+	}
+	if err != nil {
+		return model.ProductPage{}, err
+	}
+	if params.Name != nil {
+		query.Filter.NameContains = *params.Name
+	}
+	if params.IncludeDeleted != nil {
+		query.Filter.IncludeDeleted = *params.IncludeDeleted
 	}
-	prod := model.Product{
-		ID: request.Body.Id,
+	if params.Sort != nil {
+		query.Sort = model.ParseSort(string(*params.Sort))
 	}
-	if request.Body.Name != nil {
-		prod.Name = *request.Body.Name
+	var page model.PageRequest
+	if params.PageSize != nil {
+		page.Size = *params.PageSize
 	}
-	if request.Body.Price != nil {
-		prod.Price = *request.Body.Price
+	if params.Cursor != nil {
+		page.Cursor = *params.Cursor
 	}
+	return s.db.GetAll(ctx, query, page)
+}
+
+func (s *Service) SearchProducts(ctx context.Context, request SearchProductsRequestObject) (SearchProductsResponseObject, error) {
+	var limit int
+	if request.Params.Limit != nil {
+		limit = *request.Params.Limit
+	}
+	query, err := model.NewSearchQuery(request.Params.Q, limit)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	val, err := s.db.Search(ctx, query)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	return SearchProducts200JSONResponse{Items: val}, nil
+}
+
+func (s *Service) StreamProductEvents(ctx context.Context, request StreamProductEventsRequestObject) (StreamProductEventsResponseObject, error) {
+	feed, err := s.feeds.Open(ctx, request.Params.LastEventID)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	return eventStreamResponse{feed: feed}, nil
+}
+
+// eventStreamResponse flushes every event, the generated response would copy the stream as a whole
+type eventStreamResponse struct {
+	feed *model.Feed
+}
+
+func (r eventStreamResponse) VisitStreamProductEventsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", model.EventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	// The stream has started, the errors can't be reported anymore
+	_ = r.feed.Write(w, http.NewResponseController(w).Flush)
+	return nil
+}
 
-	if err := s.db.Update(ctx, prod); err != nil {
-		if errors.Is(err, model.ErrorNoRowsUpdated) {
-			return UpdateProduct400JSONResponse{
-				NoUpdateJSONResponse{
-					Error: err.Error(),
-				},
-			}, nil
+func (s *Service) PurgeProducts(ctx context.Context, request PurgeProductsRequestObject) (PurgeProductsResponseObject, error) {
+	cnt, err := s.db.Purge(ctx, model.PurgeBefore(request.Params.Days))
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	return PurgeProducts200JSONResponse{
+		Purged: cnt,
+	}, nil
+}
+
+func (s *Service) RestoreProduct(ctx context.Context, request RestoreProductRequestObject) (RestoreProductResponseObject, error) {
+	if _, err := s.db.Restore(ctx, request.Body.Id); err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	return RestoreProduct200JSONResponse{
+		Msg: "Product restored",
+	}, nil
+}
+
+// UpdateProduct reads the bodies the generated code doesn't from the request itself
+func (s *Service) UpdateProduct(ctx context.Context, request UpdateProductRequestObject) (UpdateProductResponseObject, error) {
+	r := ctx.Value(reqKey).(*http.Request)
+	respFormat, err := content.ResponseFormat(r.Header.Get("Accept"))
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	reqFormat, err := content.RequestFormat(r.Header.Get("Content-Type"))
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	var prod model.ProductUpdate
+	switch reqFormat {
+	case content.Form:
+		prod, err = formUpdate(request.FormdataBody)
+	case content.MergePatch:
+		prod, err = model.ParseMergePatch(r.URL.Query().Get("id"), r.Body)
+		if err == nil {
+			prod.Version, err = model.ParseVersion(r.URL.Query().Get("version"))
+		}
+	case content.JSON:
+		body := io.Reader(r.Body)
+		if b, ok := ctx.Value(bodyKey).([]byte); ok {
+			body = bytes.NewReader(b)
 		}
-		return UpdateProduct500JSONResponse{
-			DbIssueJSONResponse{
-				Error: err.Error(),
-			},
+		prod, err = content.DecodeUpdate(reqFormat, body)
+	default:
+		prod, err = content.DecodeUpdate(reqFormat, r.Body)
+	}
+	if err == nil {
+		prod.Version, err = s.checkIfMatch(ctx, prod.ID, prod.Version, request.Params.IfMatch)
+	}
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+
+	if _, err := s.db.Update(ctx, prod); err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	if respFormat == content.JSON {
+		return UpdateProduct200JSONResponse{
+			Msg: "Product updated",
 		}, nil
 	}
-	return UpdateProduct200JSONResponse{
+	b, err := content.EncodeUpdateResponse(respFormat, "Product updated")
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	if respFormat == content.MsgPack {
+		return UpdateProduct200ApplicationmsgpackResponse{Body: bytes.NewReader(b), ContentLength: int64(len(b))}, nil
+	}
+	return UpdateProduct200ApplicationxProtobufResponse{Body: bytes.NewReader(b), ContentLength: int64(len(b))}, nil
+}
+
+// keepUpdateBody keeps the JSON body, the generated code doesn't reject the unknown fields
+func keepUpdateBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && r.URL.Path == "/update" &&
+			strings.HasPrefix(r.Header.Get("Content-Type"), string(content.JSON)) {
+			b, err := io.ReadAll(r.Body)
+			if err != nil {
+				sendProblem(w, r, model.Errorf(model.InvalidArgument, "%w", err))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(b))
+			r = r.WithContext(context.WithValue(r.Context(), bodyKey, b))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// formUpdate reads the form, it's missing without the Content-Type
+func formUpdate(body *UpdateRequest) (model.ProductUpdate, error) {
+	if body == nil {
+		body = &UpdateRequest{}
+	}
+	var name, price, currency string
+	if body.Name != nil {
+		name = *body.Name
+	}
+	if body.Price != nil {
+		price = *body.Price
+	}
+	if body.Currency != nil {
+		currency = *body.Currency
+	}
+	prod, err := model.ParseUpdate(body.Id, name, price, currency)
+	if err != nil {
+		return model.ProductUpdate{}, err
+	}
+	if body.Version != nil {
+		prod.Version = *body.Version
+	}
+	return prod, nil
+}
+
+func (s *Service) PatchProduct(ctx context.Context, request PatchProductRequestObject) (PatchProductResponseObject, error) {
+	_, err := s.patchProduct(ctx, request.Params.Id, request.Params.Version, request.Params.IfMatch, *request.Body)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	return PatchProduct200JSONResponse{
 		Msg: "Product updated",
 	}, nil
 }
+
+func (s *Service) PatchProductByID(ctx context.Context, request PatchProductByIDRequestObject) (PatchProductByIDResponseObject, error) {
+	val, err := s.patchProduct(ctx, request.Id, request.Params.Version, request.Params.IfMatch, *request.Body)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	return PatchProductByID200JSONResponse(val), nil
+}
+
+func (s *Service) patchProduct(ctx context.Context, id string, version *uint64, ifMatch *string,
+	prod model.ProductUpdate) (model.Product, error) {
+	prod.ID = id
+	prod.Version = 0
+	if version != nil {
+		prod.Version = *version
+	}
+	if err := prod.Validate(); err != nil {
+		return model.Product{}, err
+	}
+	var err error
+	if prod.Version, err = s.checkIfMatch(ctx, id, prod.Version, ifMatch); err != nil {
+		return model.Product{}, err
+	}
+	return s.db.Update(ctx, prod)
+}
+
+func (s *Service) checkIfMatch(ctx context.Context, id string, version uint64, ifMatch *string) (uint64, error) {
+	if ifMatch == nil {
+		return version, nil
+	}
+	return model.CheckIfMatch(ctx, s.db, id, version, *ifMatch)
+}
+
+// tag reports whether the If-None-Match parameter matches the tag of the value
+func tag(val any, ifNoneMatch *string) (string, bool, error) {
+	etag, err := model.ETag(val)
+	if err != nil || ifNoneMatch == nil {
+		return etag, false, err
+	}
+	return etag, model.NoneMatch(*ifNoneMatch, etag), nil
+}
+
+func (s *Service) UpdateProducts(ctx context.Context, request UpdateProductsRequestObject) (UpdateProductsResponseObject, error) {
+	val, err := s.db.UpdateBatch(ctx, *request.Body)
+	if err != nil {
+		return newErrorResponse(ctx, err), nil
+	}
+	return UpdateProducts200JSONResponse{
+		BatchJSONResponse{
+			Results: val,
+		},
+	}, nil
+}
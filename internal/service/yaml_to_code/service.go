@@ -2,15 +2,14 @@ package yaml_to_code
 
 import (
 	"context"
-	"errors"
 	"net"
 	"net/http"
 	"time"
 
 	strictnethttp "github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
-	"github.com/rs/zerolog/log"
 
 	"github.com/aleksandrzhukovskii/go-template/api"
+	"github.com/aleksandrzhukovskii/go-template/internal/config"
 	"github.com/aleksandrzhukovskii/go-template/internal/model"
 	"github.com/aleksandrzhukovskii/go-template/web/swagger"
 )
@@ -24,49 +23,71 @@ var _ implement = &Service{}
 
 type ctxKey string
 
-var reqKey = ctxKey("request")
+var (
+	reqKey  = ctxKey("request")
+	bodyKey = ctxKey("body")
+)
 
 type Service struct {
 	server *http.Server
 	db     model.DB
 	lis    net.Listener
+	feeds  *model.Feeds
 }
 
-func New(db model.DB, lis net.Listener) (model.Server, error) {
+func New(cfg config.Config, db model.DB, lis net.Listener) (model.Server, error) {
 	ret := &Service{
-		db:  db,
-		lis: lis,
+		db:    db,
+		lis:   lis,
+		feeds: model.NewFeeds(db, cfg.Events.Heartbeat),
 	}
 	mux := http.NewServeMux()
 	ret.server = &http.Server{
-		Handler: mux,
+		Handler: model.RequestContext(mux),
 	}
-	HandlerFromMux(NewStrictHandler(ret, []StrictMiddlewareFunc{
+	strict := NewStrictHandlerWithOptions(ret, []StrictMiddlewareFunc{
 		func(f strictnethttp.StrictHTTPHandlerFunc, operationID string) strictnethttp.StrictHTTPHandlerFunc {
 			return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (response interface{}, err error) {
 				return f(context.WithValue(ctx, reqKey, r), w, r, request)
 			}
 		},
-	}), mux)
+	}, StrictHTTPServerOptions{
+		RequestErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			sendProblem(w, r, model.Errorf(model.InvalidArgument, "%w", err))
+		},
+		ResponseErrorHandlerFunc: sendProblem,
+	})
+	HandlerWithOptions(strict, StdHTTPServerOptions{
+		BaseRouter:  mux,
+		Middlewares: []MiddlewareFunc{keepUpdateBody},
+		ErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			sendProblem(w, r, paramError(err))
+		},
+	})
 	mux.HandleFunc("GET /swagger.yaml", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
 		w.Header().Set("Content-Type", "text/yaml")
+		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write(api.SwaggerConfig)
 	})
 	mux.Handle("GET /swagger/", http.StripPrefix("/swagger/", http.FileServer(http.FS(swagger.Swagger))))
 	return ret, nil
 }
 
+func (s *Service) Handler() http.Handler {
+	return s.server.Handler
+}
+
+func (s *Service) Stop() {
+	s.feeds.Stop()
+}
+
 func (s *Service) Start(ctx context.Context) error {
-	log.Info().Msg("starting yaml_to_code server")
-	go func() {
-		if err := s.server.Serve(s.lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatal().Err(err).Msg("failed to start yaml_to_code server")
-		}
-	}()
-	<-ctx.Done()
-	log.Info().Msg("gracefully shutting down yaml_to_code server")
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
-	return s.server.Shutdown(ctx)
+	return model.Serve(ctx, "yaml_to_code", func() error {
+		return s.server.Serve(s.lis)
+	}, func() error {
+		s.Stop()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		return s.server.Shutdown(ctx)
+	})
 }
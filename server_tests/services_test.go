@@ -0,0 +1,439 @@
+package server_tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/aleksandrzhukovskii/go-template/internal/config"
+	"github.com/aleksandrzhukovskii/go-template/internal/model"
+	"github.com/aleksandrzhukovskii/go-template/internal/service"
+	"github.com/aleksandrzhukovskii/go-template/internal/service/graphql"
+	pb "github.com/aleksandrzhukovskii/go-template/internal/service/grpc"
+	"github.com/aleksandrzhukovskii/go-template/internal/service/in_memory"
+)
+
+type ServicesSuite struct {
+	suite.Suite
+}
+
+func (s *ServicesSuite) SetupTest() {
+	for k, v := range map[string]string{"DB": "in_memory", "SERVER": "net_http,grpc@:124", "IP": "127.0.0.1",
+		"PORT": "123"} {
+		if err := os.Setenv(k, v); err != nil {
+			s.FailNow(err.Error())
+		}
+	}
+}
+
+// start sends the error of Start to the returned channel
+func (s *ServicesSuite) start(ctx context.Context, listeners ...net.Listener) <-chan error {
+	cfg, err := config.New()
+	s.Require().NoError(err)
+	s.Equal([]config.Listener{
+		{Type: "net_http", IP: "127.0.0.1", Port: "123"},
+		{Type: "grpc", IP: "127.0.0.1", Port: "124"},
+	}, cfg.Servers)
+	services, err := service.NewWithListener(cfg, listeners...)
+	s.Require().NoError(err)
+	ret := make(chan error, 1)
+	go func() {
+		ret <- services.Start(ctx)
+	}()
+	return ret
+}
+
+func (s *ServicesSuite) Test_SharedDB() {
+	ctx, cancel := context.WithCancel(context.Background())
+	httpLis, grpcLis := bufconn.Listen(1024*1024), bufconn.Listen(1024*1024)
+	done := s.start(ctx, httpLis, grpcLis)
+	time.Sleep(100 * time.Millisecond)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+				return httpLis.Dial()
+			},
+		},
+	}
+	res, err := client.PostForm("http://127.0.0.1:8000/products",
+		url.Values{"name": {"Shared Product"}, "price": {"3.00"}, "currency": {"USD"}})
+	s.Require().NoError(err)
+	s.Equal(http.StatusCreated, res.StatusCode)
+	var created model.Product
+	s.NoError(json.NewDecoder(res.Body).Decode(&created))
+	res.Body.Close()
+
+	conn, err := grpc.NewClient("passthrough://bufnet", grpc.WithContextDialer(
+		func(context.Context, string) (net.Conn, error) {
+			return grpcLis.Dial()
+		}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	s.Require().NoError(err)
+	defer conn.Close()
+	product, err := pb.NewProductServiceClient(conn).GetProduct(ctx, &pb.GetProductRequest{Id: created.ID})
+	s.Require().NoError(err)
+	s.Equal("Shared Product", product.GetName())
+
+	cancel()
+	select {
+	case err = <-done:
+		s.NoError(err)
+	case <-time.After(5 * time.Second):
+		s.Fail("services haven't stopped")
+	}
+}
+
+func (s *ServicesSuite) Test_Failure() {
+	failing := bufconn.Listen(1024 * 1024)
+	s.NoError(failing.Close())
+	done := s.start(context.Background(), bufconn.Listen(1024*1024), failing)
+
+	select {
+	case err := <-done:
+		s.ErrorContains(err, "grpc server failed")
+	case <-time.After(5 * time.Second):
+		s.Fail("the failed server hasn't stopped the rest")
+	}
+}
+
+func (s *ServicesSuite) Test_SharedListener() {
+	s.Require().NoError(os.Setenv("SERVER", "grpc+net_http+graphql"))
+	cfg, err := config.New()
+	s.Require().NoError(err)
+	lis := bufconn.Listen(1024 * 1024)
+	services, err := service.NewWithListener(cfg, lis)
+	s.Require().NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- services.Start(ctx)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	dial := func(_ context.Context, _, _ string) (net.Conn, error) {
+		return lis.Dial()
+	}
+	client := &http.Client{Transport: &http.Transport{DialContext: dial}}
+	res, err := client.PostForm("http://127.0.0.1:8000/products",
+		url.Values{"name": {"Multiplexed Product"}, "price": {"4.00"}, "currency": {"USD"}})
+	s.Require().NoError(err)
+	s.Equal(http.StatusCreated, res.StatusCode)
+	var created model.Product
+	s.NoError(json.NewDecoder(res.Body).Decode(&created))
+	res.Body.Close()
+
+	// REST over the unencrypted HTTP/2 isn't taken for gRPC
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	h2c := &http.Client{Transport: &http.Transport{DialContext: dial, Protocols: protocols}}
+	res, err = h2c.Get("http://127.0.0.1:8000/products/" + created.ID)
+	s.Require().NoError(err)
+	s.Equal(2, res.ProtoMajor)
+	s.Equal(http.StatusOK, res.StatusCode)
+	var product model.Product
+	s.NoError(json.NewDecoder(res.Body).Decode(&product))
+	res.Body.Close()
+	s.Equal("Multiplexed Product", product.Name)
+
+	res, err = client.Post("http://127.0.0.1:8000/query", "application/json", strings.NewReader(
+		`{"query":"query($id: String!) { getProduct(filter: {id: $id}) { name } }","variables":{"id":"`+
+			created.ID+`"}}`))
+	s.Require().NoError(err)
+	s.Equal(http.StatusOK, res.StatusCode)
+	var graph struct {
+		Data struct {
+			GetProduct struct {
+				Name string `json:"name"`
+			} `json:"getProduct"`
+		} `json:"data"`
+	}
+	s.NoError(json.NewDecoder(res.Body).Decode(&graph))
+	res.Body.Close()
+	s.Equal("Multiplexed Product", graph.Data.GetProduct.Name)
+
+	conn, err := grpc.NewClient("passthrough://bufnet", grpc.WithContextDialer(
+		func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	s.Require().NoError(err)
+	defer conn.Close()
+	reply, err := pb.NewProductServiceClient(conn).GetProduct(ctx, &pb.GetProductRequest{Id: created.ID})
+	s.Require().NoError(err)
+	s.Equal("Multiplexed Product", reply.GetName())
+
+	// The streams open on the shutdown don't hold it
+	streamCtx, streamCancel := context.WithCancel(context.Background())
+	defer streamCancel()
+	watch, err := pb.NewProductServiceClient(conn).WatchProducts(streamCtx, &pb.WatchProductsRequest{})
+	s.Require().NoError(err)
+	_, err = watch.Header()
+	s.Require().NoError(err)
+	health, err := grpc_health_v1.NewHealthClient(conn).Watch(streamCtx, &grpc_health_v1.HealthCheckRequest{})
+	s.Require().NoError(err)
+	serving, err := health.Recv()
+	s.Require().NoError(err)
+	s.Equal(grpc_health_v1.HealthCheckResponse_SERVING, serving.GetStatus())
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodPost, "http://127.0.0.1:8000/subscription",
+		strings.NewReader(`{"query":"subscription { productsChanged { type } }"}`))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	res, err = client.Do(req)
+	s.Require().NoError(err)
+	defer res.Body.Close()
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+
+	cancel()
+	select {
+	case err = <-done:
+		s.NoError(err)
+	case <-time.After(5 * time.Second):
+		s.Fail("services haven't stopped")
+	}
+	_, err = watch.Recv()
+	s.Equal(codes.Unavailable, status.Code(err))
+	for err == nil {
+		_, err = health.Recv()
+	}
+	_, err = io.ReadAll(res.Body)
+	s.NoError(err)
+}
+
+func (s *ServicesSuite) Test_SharedListenerInvalid() {
+	for _, val := range []string{"net_http+gin", "grpc+grpc", "grpc+unknown"} {
+		s.Require().NoError(os.Setenv("SERVER", val))
+		cfg, err := config.New()
+		s.Require().NoError(err)
+		_, err = service.NewWithListener(cfg, bufconn.Listen(1024*1024))
+		s.Error(err, val)
+	}
+}
+
+func (s *ServicesSuite) Test_EventStreamShutdown() {
+	// The multiplexer of the shared listener stops the streams of the servers too
+	for _, typ := range []string{"net_http", "gin", "fiber", "yaml_to_code", "grpc+net_http", "graphql+gin",
+		"graphql+fiber"} {
+		s.Run(typ, func() {
+			s.Require().NoError(os.Setenv("SERVER", typ))
+			cfg, err := config.New()
+			s.Require().NoError(err)
+			lis := bufconn.Listen(1024 * 1024)
+			services, err := service.NewWithListener(cfg, lis)
+			s.Require().NoError(err)
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- services.Start(ctx)
+			}()
+			time.Sleep(100 * time.Millisecond)
+
+			client := &http.Client{Transport: &http.Transport{
+				DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+					return lis.Dial()
+				},
+				ResponseHeaderTimeout: 5 * time.Second,
+			}}
+			res, err := client.Get("http://127.0.0.1:8000/products/events")
+			s.Require().NoError(err)
+			defer res.Body.Close()
+			s.Require().Equal(http.StatusOK, res.StatusCode)
+			stream := bufio.NewReader(res.Body)
+			_, err = stream.ReadString('\n')
+			s.Require().NoError(err)
+
+			// The open stream doesn't hold the graceful shutdown
+			cancel()
+			select {
+			case err = <-done:
+				s.NoError(err)
+			case <-time.After(5 * time.Second):
+				s.Fail("services haven't stopped")
+			}
+			_, err = io.ReadAll(stream)
+			s.NoError(err)
+		})
+	}
+}
+
+// countingDB counts the reads of the products by id
+type countingDB struct {
+	model.DB
+	gets    atomic.Int32
+	getMany atomic.Int32
+}
+
+func (c *countingDB) Get(ctx context.Context, id string) (model.Product, error) {
+	c.gets.Add(1)
+	return c.DB.Get(ctx, id)
+}
+
+func (c *countingDB) GetMany(ctx context.Context, ids []string) ([]model.Product, error) {
+	c.getMany.Add(1)
+	return c.DB.GetMany(ctx, ids)
+}
+
+func (s *ServicesSuite) Test_GraphQLLoader() {
+	inner, err := in_memory.New(config.Config{})
+	s.Require().NoError(err)
+	var ids []string
+	for i := range 5 {
+		val, err := inner.Add(context.Background(), model.Product{Name: fmt.Sprintf("Loaded Product %d", i),
+			Price: decimal.NewFromInt(1), Currency: "USD"})
+		s.Require().NoError(err)
+		ids = append(ids, val.ID)
+	}
+	db := &countingDB{DB: inner}
+	lis := bufconn.Listen(1024 * 1024)
+	server, err := graphql.New(config.Config{}, db, lis)
+	s.Require().NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Start(ctx)
+	}()
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+			return lis.Dial()
+		},
+	}}
+
+	// The aliased fields are resolved concurrently, their products are read at once
+	var query strings.Builder
+	for i, id := range ids {
+		fmt.Fprintf(&query, "p%d: getProduct(filter: {id: %q}) { name } ", i, id)
+	}
+	body, err := json.Marshal(map[string]string{"query": "{ " + query.String() + "}"})
+	s.Require().NoError(err)
+	res, err := client.Post("http://127.0.0.1:8000/query", "application/json", bytes.NewReader(body))
+	s.Require().NoError(err)
+	var graph struct {
+		Data   map[string]*struct{ Name string }
+		Errors []any
+	}
+	s.NoError(json.NewDecoder(res.Body).Decode(&graph))
+	res.Body.Close()
+	s.Require().Len(graph.Data, len(ids))
+	for i := range ids {
+		s.Equal(fmt.Sprintf("Loaded Product %d", i), graph.Data[fmt.Sprintf("p%d", i)].Name)
+	}
+	s.Empty(graph.Errors)
+	s.Zero(db.gets.Load())
+	s.Equal(int32(1), db.getMany.Load())
+
+	cancel()
+	s.NoError(<-done)
+}
+
+// slowDB blocks the reads until the context is done and counts them
+type slowDB struct {
+	model.DB
+	cut atomic.Int32
+}
+
+func (*slowDB) Search(ctx context.Context, _ model.SearchQuery) ([]model.Product, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *slowDB) GetMany(ctx context.Context, _ []string) ([]model.Product, error) {
+	<-ctx.Done()
+	s.cut.Add(1)
+	return nil, ctx.Err()
+}
+
+func (s *ServicesSuite) Test_GraphQLLimits() {
+	inner, err := in_memory.New(config.Config{})
+	s.Require().NoError(err)
+	lis := bufconn.Listen(1024 * 1024)
+	db := &slowDB{DB: inner}
+	server, err := graphql.New(config.Config{GraphQL: config.GraphQL{Depth: 3, Timeout: 100 * time.Millisecond}},
+		db, lis)
+	s.Require().NoError(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Start(ctx)
+	}()
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+			return lis.Dial()
+		},
+	}}
+	errs := func(query string) []any {
+		body, err := json.Marshal(map[string]string{"query": query})
+		s.Require().NoError(err)
+		res, err := client.Post("http://127.0.0.1:8000/query", "application/json", bytes.NewReader(body))
+		s.Require().NoError(err)
+		defer res.Body.Close()
+		var graph struct {
+			Errors []any
+		}
+		s.Require().NoError(json.NewDecoder(res.Body).Decode(&graph))
+		return graph.Errors
+	}
+
+	s.Run("Depth", func() {
+		res := errs("{ getProducts { edges { node { id } } } }")
+		s.Require().Len(res, 1)
+		s.Equal("DEPTH_LIMIT_EXCEEDED", res[0].(map[string]any)["extensions"].(map[string]any)["code"])
+		s.Empty(errs("fragment edges on ProductConnection { edges { cursor } } { getProducts { ...edges } }"))
+	})
+
+	s.Run("Timeout", func() {
+		start := time.Now()
+		res := errs(`{ searchProducts(query: "product") { id } }`)
+		s.Require().Len(res, 1)
+		s.Equal("UNAVAILABLE", res[0].(map[string]any)["extensions"].(map[string]any)["code"])
+		s.Less(time.Since(start), 5*time.Second)
+	})
+
+	s.Run("Loader Timeout", func() {
+		res := errs(`{ getProduct(filter: {id: "slow"}) { id } }`)
+		s.Require().Len(res, 1)
+		s.Equal("UNAVAILABLE", res[0].(map[string]any)["extensions"].(map[string]any)["code"])
+		// The batched read ends with the request instead of running on
+		s.Eventually(func() bool {
+			return db.cut.Load() == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	s.Run("Introspection Disabled", func() {
+		s.NotEmpty(errs("{ __schema { queryType { name } } }"))
+	})
+
+	s.Run("Playground Disabled", func() {
+		for _, path := range []string{"/query_playground", "/subscription_playground"} {
+			res, err := client.Get("http://127.0.0.1:8000" + path)
+			s.Require().NoError(err)
+			s.Equal(http.StatusNotFound, res.StatusCode)
+			res.Body.Close()
+		}
+	})
+
+	cancel()
+	s.NoError(<-done)
+}
+
+func TestServices(t *testing.T) {
+	suite.Run(t, &ServicesSuite{})
+}